@@ -1,7 +1,12 @@
 package main
 
 import (
+	"os"
+
 	"customer-api/internal/config"
+	"customer-api/internal/handler"
+	"customer-api/internal/sla"
+	"customer-api/middleware"
 	"customer-api/routes"
 
 	_ "customer-api/cmd/api/docs"
@@ -12,7 +17,14 @@ import (
 )
 
 func main() {
+	// Subcommand: go run ./cmd/api migrate up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	r := gin.Default()
+	r.Use(middleware.RequestID())
 
 	// Swagger
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -25,11 +37,24 @@ func main() {
 	// DB
 	config.ConnectDatabase()
 
+	// Worker export berjalan di background dalam proses API
+	handler.StartExportWorker()
+
+	// Trash yang melewati TRASH_RETENTION dihapus permanen
+	handler.StartTrashPurger()
+
+	// Perubahan status customer terjadwal, misalnya auto-unblock
+	handler.StartCustomerStatusScheduler()
+
+	// SLA pipeline: tandai step yang lewat due dan kirim notifikasi breach
+	handler.SLANotifier = sla.NotifierFromEnv()
+	handler.StartSLAMonitor()
+
 	// Register all routes
 	routes.RegisterRoutes(r)
 
-	// Static files
-	r.Static("/uploads", "./uploads")
+	// Static files (file export hanya lewat link download)
+	r.StaticFS("/uploads", handler.PublicUploads("./uploads"))
 
 	r.Run(":8080")
 }
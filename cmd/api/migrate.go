@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"customer-api/internal/config"
+	"customer-api/internal/migration"
+)
+
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up            apply all pending migrations
+  down [steps]  roll back the last migration (or the last <steps> migrations)
+  status        list migrations and whether they are applied`
+
+// runMigrate handles `api migrate ...`
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		return
+	}
+
+	config.Connect()
+
+	switch args[0] {
+	case "up":
+		applied, err := migration.Up(config.DB)
+		for _, m := range applied {
+			fmt.Printf("applied  %s_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("nothing to migrate")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatal("steps must be a positive number")
+			}
+			steps = n
+		}
+		rolledBack, err := migration.Down(config.DB, steps)
+		for _, m := range rolledBack {
+			fmt.Printf("reverted %s_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(rolledBack) == 0 {
+			fmt.Println("nothing to roll back")
+		}
+
+	case "status":
+		statuses, err := migration.Status(config.DB)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%s_%-40s %s\n", s.Version, s.Name, applied)
+		}
+
+	default:
+		fmt.Println(migrateUsage)
+	}
+}
@@ -0,0 +1,283 @@
+// Package audit records every create, update and delete that goes through
+// GORM in the audit_logs table: entity type (table name), primary key, field
+// diff, acting user, client IP and request ID.
+//
+// The actor is read from the statement context, so handlers pass the request
+// along with config.DB.WithContext(c). Writes without a request context (seed,
+// background workers) are logged with an empty user.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"customer-api/internal/entity"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Actions
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// RequestIDKey is the gin context key of the request ID set by middleware.RequestID
+const RequestIDKey = "request_id"
+
+// Tabel yang tidak diaudit: log itu sendiri, history customer yang sudah
+// menyimpan diff per field, dan tabel teknis yang sering berubah
+var ignoredTables = map[string]bool{
+	"audit_logs":        true,
+	"history_customers": true,
+	"schema_migrations": true,
+	"export_jobs":       true,
+	"refresh_tokens":    true,
+	"revoked_tokens":    true,
+	"invoice_sequences": true,
+}
+
+// Batas baris yang dibandingkan untuk update/delete massal dalam satu statement
+const maxRows = 1000
+
+const beforeKey = "audit:before"
+
+// Actor is who made a change
+type Actor struct {
+	UserID    string
+	IP        string
+	RequestID string
+}
+
+type actorKey struct{}
+
+// WithActor attaches an actor to a context that is not a gin request, e.g. a
+// background job acting on behalf of a user
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func actorFrom(ctx context.Context) Actor {
+	if ctx == nil {
+		return Actor{}
+	}
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	if c, ok := ctx.(*gin.Context); ok && c.Request != nil {
+		return Actor{UserID: c.GetString("user_id"), IP: c.ClientIP(), RequestID: c.GetString(RequestIDKey)}
+	}
+	return Actor{}
+}
+
+// Register hooks the audit callbacks into db. Update and delete load the
+// affected rows first so the log holds the values before the change.
+func Register(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().After("gorm:after_create").Before("gorm:commit_or_rollback_transaction").Register("audit:create", afterCreate),
+		cb.Update().After("gorm:setup_reflect_value").Before("gorm:update").Register("audit:before_update", loadBefore),
+		cb.Update().After("gorm:after_update").Before("gorm:commit_or_rollback_transaction").Register("audit:update", afterUpdate),
+		cb.Delete().After("gorm:begin_transaction").Before("gorm:delete").Register("audit:before_delete", loadBefore),
+		cb.Delete().After("gorm:after_delete").Before("gorm:commit_or_rollback_transaction").Register("audit:delete", afterDelete),
+	)
+}
+
+func skip(db *gorm.DB) bool {
+	return db.Error != nil || db.DryRun || db.Statement.Schema == nil || ignoredTables[db.Statement.Table]
+}
+
+// session runs audit queries on the same connection (and transaction) as the statement
+func session(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true})
+}
+
+// query selects rows of the statement's model; soft-deleted rows are excluded
+// unless the statement itself is unscoped
+func query(db *gorm.DB) *gorm.DB {
+	stmt := db.Statement
+	q := session(db).Model(reflect.New(stmt.Schema.ModelType).Interface()).Table(stmt.Table)
+	if stmt.Unscoped {
+		q = q.Unscoped()
+	}
+	return q
+}
+
+// primaryKey returns the primary key of a row, joined with "," for composite keys
+func primaryKey(db *gorm.DB, row reflect.Value) string {
+	var values []string
+	for _, field := range db.Statement.Schema.PrimaryFields {
+		value, _ := field.ValueOf(db.Statement.Context, row)
+		values = append(values, fmt.Sprint(value))
+	}
+	return strings.Join(values, ",")
+}
+
+// structs lists the struct values of a create/update target (struct or slice)
+func structs(rv reflect.Value) []reflect.Value {
+	rv = reflect.Indirect(rv)
+	switch rv.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{rv}
+	case reflect.Slice, reflect.Array:
+		var rows []reflect.Value
+		for i := 0; i < rv.Len(); i++ {
+			if row := reflect.Indirect(rv.Index(i)); row.Kind() == reflect.Struct {
+				rows = append(rows, row)
+			}
+		}
+		return rows
+	}
+	return nil
+}
+
+// loadBefore loads the rows an update or delete is about to change: by the
+// primary key of the model when it is set, narrowed by the statement's own
+// conditions. Statements without any condition are left to GORM to reject.
+func loadBefore(db *gorm.DB) {
+	if skip(db) {
+		return
+	}
+	stmt := db.Statement
+	pk := stmt.Schema.PrioritizedPrimaryField
+	if pk == nil {
+		return
+	}
+
+	q := query(db)
+	filtered := false
+	var ids []interface{}
+	for _, row := range structs(stmt.ReflectValue) {
+		if value, zero := pk.ValueOf(stmt.Context, row); !zero {
+			ids = append(ids, value)
+		}
+	}
+	if len(ids) > 0 {
+		q = q.Where(clause.IN{Column: clause.Column{Table: stmt.Table, Name: pk.DBName}, Values: ids})
+		filtered = true
+	}
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			q = q.Clauses(where)
+			filtered = true
+		}
+	}
+	if !filtered {
+		return
+	}
+
+	rows := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+	if err := q.Limit(maxRows).Find(rows.Interface()).Error; err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+	db.InstanceSet(beforeKey, rows.Elem())
+}
+
+func before(db *gorm.DB) (reflect.Value, bool) {
+	if skip(db) || db.Statement.RowsAffected == 0 {
+		return reflect.Value{}, false
+	}
+	value, ok := db.InstanceGet(beforeKey)
+	if !ok {
+		return reflect.Value{}, false
+	}
+	rows := value.(reflect.Value)
+	return rows, rows.Len() > 0
+}
+
+func afterCreate(db *gorm.DB) {
+	if skip(db) || db.Statement.RowsAffected == 0 {
+		return
+	}
+	var logs []entity.AuditLog
+	for _, row := range structs(db.Statement.ReflectValue) {
+		logs = append(logs, newLog(db, ActionCreate, primaryKey(db, row), Diff(nil, row.Interface())))
+	}
+	write(db, logs)
+}
+
+func afterUpdate(db *gorm.DB) {
+	old, ok := before(db)
+	if !ok {
+		return
+	}
+	stmt := db.Statement
+	pk := stmt.Schema.PrioritizedPrimaryField
+
+	// Dibaca ulang berdasarkan primary key karena kondisi WHERE bisa saja
+	// tidak cocok lagi setelah update
+	ids := make([]interface{}, old.Len())
+	for i := range ids {
+		ids[i], _ = pk.ValueOf(stmt.Context, old.Index(i))
+	}
+	rows := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+	err := query(db).Unscoped().Where(clause.IN{Column: clause.Column{Table: stmt.Table, Name: pk.DBName}, Values: ids}).Find(rows.Interface()).Error
+	if err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+		return
+	}
+	current := make(map[string]reflect.Value)
+	for i := 0; i < rows.Elem().Len(); i++ {
+		row := rows.Elem().Index(i)
+		current[primaryKey(db, row)] = row
+	}
+
+	var logs []entity.AuditLog
+	for i := 0; i < old.Len(); i++ {
+		id := primaryKey(db, old.Index(i))
+		row, ok := current[id]
+		if !ok {
+			continue
+		}
+		if changes := Diff(old.Index(i).Interface(), row.Interface()); len(changes) > 0 {
+			logs = append(logs, newLog(db, ActionUpdate, id, changes))
+		}
+	}
+	write(db, logs)
+}
+
+func afterDelete(db *gorm.DB) {
+	old, ok := before(db)
+	if !ok {
+		return
+	}
+	var logs []entity.AuditLog
+	for i := 0; i < old.Len(); i++ {
+		row := old.Index(i)
+		logs = append(logs, newLog(db, ActionDelete, primaryKey(db, row), Diff(row.Interface(), nil)))
+	}
+	write(db, logs)
+}
+
+func newLog(db *gorm.DB, action, entityID string, changes interface{}) entity.AuditLog {
+	actor := actorFrom(db.Statement.Context)
+	data, _ := json.Marshal(changes)
+	return entity.AuditLog{
+		EntityType: db.Statement.Table,
+		EntityID:   entityID,
+		Action:     action,
+		Changes:    string(data),
+		UserID:     actor.UserID,
+		IP:         actor.IP,
+		RequestID:  actor.RequestID,
+	}
+}
+
+// write inserts the logs in the statement's transaction; a failure fails the
+// statement so no change is left unaudited
+func write(db *gorm.DB, logs []entity.AuditLog) {
+	if len(logs) == 0 {
+		return
+	}
+	if err := session(db).Create(&logs).Error; err != nil {
+		db.AddError(fmt.Errorf("audit: %w", err))
+	}
+}
@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"customer-api/internal/dto"
+
+	"gorm.io/gorm"
+)
+
+// Field pembukuan yang tidak pernah dicatat sebagai perubahan
+var ignoredFields = map[string]bool{"id": true, "created_at": true, "updated_at": true, "version": true}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+)
+
+type field struct {
+	name  string
+	value string
+}
+
+// fields lists the json fields of a record in declaration order. Relations,
+// bookkeeping fields and fields hidden from JSON (such as passwords) are left
+// out, except deleted_at so restoring a soft-deleted record shows up as a change.
+func fields(record interface{}) []field {
+	v := reflect.Indirect(reflect.ValueOf(record))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	var list []field
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if t.Field(i).Type == deletedAtType {
+			name = "deleted_at"
+		}
+		if name == "" || name == "-" || ignoredFields[name] || !t.Field(i).IsExported() {
+			continue
+		}
+		if value, ok := format(v.Field(i)); ok {
+			list = append(list, field{name, value})
+		}
+	}
+	return list
+}
+
+// format renders a scalar field; ok is false for relations
+func format(v reflect.Value) (string, bool) {
+	if v.Kind() == reflect.Pointer {
+		elem := v.Type().Elem()
+		if elem.Kind() == reflect.Struct && elem != timeType {
+			return "", false
+		}
+		if v.IsNil() {
+			return "", true
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true
+	case reflect.Struct:
+		if v.Type() == deletedAtType {
+			deletedAt := v.Interface().(gorm.DeletedAt)
+			if !deletedAt.Valid {
+				return "", true
+			}
+			return deletedAt.Time.Format(time.RFC3339), true
+		}
+		if v.Type() == timeType {
+			t := v.Interface().(time.Time)
+			if t.IsZero() {
+				return "", true
+			}
+			return t.Format(time.RFC3339), true
+		}
+	}
+	return "", false
+}
+
+// Diff compares two versions of the same record (pointers to the same struct
+// type). For created records (before nil) and deleted ones (after nil) every
+// non-empty field is listed.
+func Diff(before, after interface{}) []dto.FieldChange {
+	changes := []dto.FieldChange{}
+	switch {
+	case before == nil:
+		for _, f := range fields(after) {
+			if f.value != "" {
+				changes = append(changes, dto.FieldChange{Field: f.name, After: f.value})
+			}
+		}
+	case after == nil:
+		for _, f := range fields(before) {
+			if f.value != "" {
+				changes = append(changes, dto.FieldChange{Field: f.name, Before: f.value})
+			}
+		}
+	default:
+		old, cur := fields(before), fields(after)
+		for i := range cur {
+			if i < len(old) && cur[i].value != old[i].value {
+				changes = append(changes, dto.FieldChange{Field: cur[i].name, Before: old[i].value, After: cur[i].value})
+			}
+		}
+	}
+	return changes
+}
@@ -6,7 +6,9 @@ import (
 	"os"
 	"strconv"
 
+	"customer-api/internal/audit"
 	"customer-api/internal/entity"
+	"customer-api/internal/migration"
 
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
@@ -15,7 +17,9 @@ import (
 
 var DB *gorm.DB
 
-func ConnectDatabase() {
+// Connect membuka koneksi database tanpa menjalankan migrasi atau seed.
+// Dipakai langsung oleh subcommand `migrate`.
+func Connect() {
 	// Load .env file
 	err := godotenv.Load()
 	if err != nil {
@@ -41,88 +45,38 @@ func ConnectDatabase() {
 
 	// Assign database connection to global DB variable
 	DB = database
-
-	fmt.Println("Starting auto migration...")
-
-
-	isProd, _ := strconv.ParseBool(os.Getenv("IS_PRODUCTION"))
-	// Drop semua tabel yang bermasalah untuk memastikan skema bersih
-	// Drop tabel dengan foreign key terlebih dahulu
-	if !isProd {
-    tables := []interface{}{
-       
-		&entity.ActivityAttendee{},
-		&entity.ActivityCheckin{},
-		&entity.ActivityType{},
-		&entity.Address{},
-		&entity.Contact{},
-		&entity.Customer{},
-		&entity.Document{},
-		&entity.HistoryCustomer{},
-		&entity.Event{},
-		&entity.EventAttendee{},
-		&entity.Group{},
-		&entity.Invoice{},
-		&entity.Other{},
-		&entity.Payment{},
-		&entity.Project{},
-		&entity.Role{},
-		&entity.Sosmed{},
-		&entity.Status{},
-		&entity.StatusReasons{},
-		&entity.Structure{},
-		&entity.User{},
-		&entity.Stages{},
-		&entity.StagesDetail{},
-		&entity.Workflows{},
-		&entity.WorkflowsDetail{},
-		&entity.GroupConfig{},
-		&entity.GroupConfigDetail{},
-		 &entity.Activity{},
-		
-		
-    }
-    for _, t := range tables {
-        _ = DB.Migrator().DropTable(t)
-    }
 }
 
+func ConnectDatabase() {
+	Connect()
+
+	// Skema hanya berubah lewat migrasi berversi (`go run ./cmd/api migrate up`).
+	// Set AUTO_MIGRATE=true untuk menjalankan migrasi pending otomatis saat boot (dev).
+	autoMigrate, _ := strconv.ParseBool(os.Getenv("AUTO_MIGRATE"))
+	if autoMigrate {
+		applied, err := migration.Up(DB)
+		if err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		for _, m := range applied {
+			fmt.Printf("Applied migration %s_%s\n", m.Version, m.Name)
+		}
+	} else {
+		pending, err := migration.Pending(DB)
+		if err != nil {
+			log.Fatal("Failed to check migrations:", err)
+		}
+		if len(pending) > 0 {
+			for _, m := range pending {
+				fmt.Printf("Pending migration %s_%s\n", m.Version, m.Name)
+			}
+			log.Fatal("Database schema is out of date, run `go run ./cmd/api migrate up` first")
+		}
+	}
 
-	// Auto migrate the schema - akan membuat tabel sesuai model Go
-	err = DB.AutoMigrate(
-		
-		&entity.ActivityAttendee{},
-		&entity.ActivityCheckin{},
-		&entity.ActivityType{},
-		&entity.Address{},
-		&entity.Contact{},
-		&entity.Customer{},
-		&entity.Document{},
-		&entity.HistoryCustomer{},
-		&entity.Event{},
-		&entity.EventAttendee{},
-		&entity.Group{},
-		&entity.Invoice{},
-		&entity.Other{},
-		&entity.Payment{},
-		&entity.Project{},
-		&entity.Role{},
-		&entity.Sosmed{},
-		&entity.Status{},
-		&entity.StatusReasons{},
-		&entity.Structure{},
-		&entity.User{},
-		&entity.Stages{},
-		&entity.StagesDetail{},
-		&entity.Workflows{},
-		&entity.WorkflowsDetail{},
-		&entity.GroupConfig{},
-		&entity.GroupConfigDetail{},
-		&entity.Activity{},
-		
-	)
-	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	// Semua perubahan lewat GORM dicatat di audit_logs
+	if err := audit.Register(DB); err != nil {
+		log.Fatal("Failed to register audit callbacks:", err)
 	}
 
 	// Insert default roles if they don't exist
@@ -140,5 +94,8 @@ func ConnectDatabase() {
 		fmt.Println("Created User role")
 	}
 
-	fmt.Println("Database connected and migrated successfully!")
+	// Seed permissions dan assignment default untuk role Admin & User
+	SeedPermissions()
+
+	fmt.Println("Database connected successfully!")
 }
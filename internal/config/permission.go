@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"log"
+
+	"customer-api/internal/entity"
+)
+
+// Actions yang dikenal oleh middleware.Authorize, dipetakan dari HTTP method
+const (
+	ActionRead   = "read"
+	ActionWrite  = "write"
+	ActionDelete = "delete"
+)
+
+// Resources adalah daftar modul yang dilindungi permission "<resource>:<action>"
+var Resources = []string{
+	"roles",
+	"users",
+	"customers",
+	"addresses",
+	"sosmeds",
+	"contacts",
+	"structures",
+	"groups",
+	"others",
+	"activities",
+	"invoices",
+	"payments",
+	"statuses",
+	"events",
+	"activity_types",
+	"stages",
+	"workflows",
+	"pipeline",
+	"sla",
+	"approvals",
+	"group_configs",
+	"assessments",
+	"teams",
+	"audit_logs",
+	"trash",
+}
+
+// userWritable adalah resource yang boleh dibuat/diubah oleh role "User" bawaan
+var userWritable = map[string]bool{
+	"customers":  true,
+	"addresses":  true,
+	"sosmeds":    true,
+	"contacts":   true,
+	"structures": true,
+	"others":     true,
+	"activities": true,
+	"events":     true,
+	"pipeline":   true,
+	"approvals":  true, // yang boleh memutuskan tetap dibatasi role/team tiap level
+}
+
+// userHidden adalah resource yang sama sekali tidak boleh diakses role "User" bawaan
+var userHidden = map[string]bool{
+	"roles":      true,
+	"users":      true,
+	"audit_logs": true,
+	"trash":      true,
+}
+
+// PermissionName builds the "<resource>:<action>" permission name
+func PermissionName(resource, action string) string {
+	return resource + ":" + action
+}
+
+// AllPermissions returns every permission name known to the API
+func AllPermissions() []string {
+	var names []string
+	for _, resource := range Resources {
+		for _, action := range []string{ActionRead, ActionWrite, ActionDelete} {
+			names = append(names, PermissionName(resource, action))
+		}
+	}
+	return names
+}
+
+// defaultUserPermissions returns the permissions granted to the "User" role on first seed
+func defaultUserPermissions() []string {
+	var names []string
+	for _, resource := range Resources {
+		if userHidden[resource] {
+			continue
+		}
+		names = append(names, PermissionName(resource, ActionRead))
+		if userWritable[resource] {
+			names = append(names, PermissionName(resource, ActionWrite))
+		}
+	}
+	return names
+}
+
+// SeedPermissions makes sure every permission exists and that the default
+// "Admin" and "User" roles carry their default permission sets.
+// Admin always receives all permissions; User only receives its defaults the
+// first time it has no permission at all, so changes made by admins are kept.
+func SeedPermissions() {
+	permissions := make(map[string]entity.Permission)
+	for _, name := range AllPermissions() {
+		var permission entity.Permission
+		if err := DB.Where("name = ?", name).FirstOrCreate(&permission, entity.Permission{Name: name}).Error; err != nil {
+			log.Fatal("Failed to seed permission "+name+":", err)
+		}
+		permissions[name] = permission
+	}
+
+	var adminRole entity.Role
+	if err := DB.Where("role_name = ?", "Admin").First(&adminRole).Error; err == nil {
+		var all []entity.Permission
+		for _, p := range permissions {
+			all = append(all, p)
+		}
+		if err := DB.Model(&adminRole).Association("Permissions").Append(all); err != nil {
+			log.Fatal("Failed to seed Admin permissions:", err)
+		}
+	}
+
+	var userRole entity.Role
+	if err := DB.Where("role_name = ?", "User").First(&userRole).Error; err == nil {
+		if DB.Model(&userRole).Association("Permissions").Count() == 0 {
+			var defaults []entity.Permission
+			for _, name := range defaultUserPermissions() {
+				defaults = append(defaults, permissions[name])
+			}
+			if err := DB.Model(&userRole).Association("Permissions").Append(defaults); err != nil {
+				log.Fatal("Failed to seed User permissions:", err)
+			}
+			fmt.Println("Seeded default User permissions")
+		}
+	}
+}
@@ -17,8 +17,10 @@ type LoginRequest struct {
 
 // LoginResponse represents login response with token
 type LoginResponse struct {
-	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	User  User   `json:"user"`
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string `json:"refresh_token" example:"Zk9yV2VyZV9hX3JhbmRvbV9yZWZyZXNoX3Rva2Vu"`
+	ExpiresIn    int64  `json:"expires_in" example:"900"`
+	User         User   `json:"user"`
 }
 
 // ErrorResponse represents error response
@@ -41,8 +43,9 @@ type CustomerRequest struct {
 
 // CustomersResponse represents customers list response
 type CustomersResponse struct {
-	Customers []Customer `json:"customers"`
-	Stats     Stats      `json:"stats"`
+	Customers  []Customer `json:"customers"`
+	Pagination Pagination `json:"pagination"`
+	Stats      Stats      `json:"stats"`
 }
 
 // Stats represents customer statistics
@@ -140,7 +143,7 @@ type CreateCustomerRequest struct {
 	AccountManagerId *string                  `json:"accountManagerId"`
 	Logo             string                   `json:"logo"`
 	LogoSmall        string                   `json:"logoSmall"`
-	StatusName       string                   `json:"status_name"`
+	StatusName       string                   `json:"status_name"` // "Draft" atau kosong (Active)
 	Addresses        []CreateAddressRequest   `json:"addresses,omitempty"`
 	Socials          []CreateSocialRequest    `json:"socials,omitempty"`
 	Contacts         []CreateContactRequest   `json:"contacts,omitempty"`
@@ -151,11 +154,11 @@ type CreateCustomerRequest struct {
 
 // CreateAddressRequest represents address creation in customer request
 type CreateAddressRequest struct {
-	// CustomerID uint   `json:"customer_id" binding:"required"` // Hapus field ini
-	Name    string `json:"name" binding:"required" example:"Head Office"`
-	Address string `json:"address" binding:"required" example:"Jl. Sudirman No. 123, Jakarta Selatan"`
-	IsMain  bool   `json:"isMain" example:"true"`
-	Active  bool   `json:"active" example:"true"`
+	CustomerID string `json:"customer_id,omitempty" example:"01J8Z6K2Q9M4X7V3B5N1C0D2E4"` // wajib untuk POST /addresses
+	Name       string `json:"name" binding:"required" example:"Head Office"`
+	Address    string `json:"address" binding:"required" example:"Jl. Sudirman No. 123, Jakarta Selatan"`
+	IsMain     bool   `json:"isMain" example:"true"`
+	Active     bool   `json:"active" example:"true"`
 }
 
 // CreateSocialRequest represents social media creation in customer request
@@ -168,7 +171,7 @@ type CreateSocialRequest struct {
 
 // CreateContactRequest represents contact creation in customer request
 type CreateContactRequest struct {
-	// CustomerID  uint   `json:"customer_id" binding:"required"` // Hapus field ini
+	CustomerID  string `json:"customer_id,omitempty" example:"01J8Z6K2Q9M4X7V3B5N1C0D2E4"` // wajib untuk POST /contacts
 	Name        string `json:"name" binding:"required" example:"Budi Santoso"`
 	Birthdate   string `json:"birthdate" example:"1985-03-15"`
 	JobPosition string `json:"jobPosition" example:"CEO"`
@@ -181,13 +184,13 @@ type CreateContactRequest struct {
 
 // CreateStructureRequest represents structure creation in customer request
 type CreateStructureRequest struct {
-	// CustomerID uint    `json:"customer_id" binding:"required"` // Hapus field ini
-	TempKey   string  `json:"tempKey" example:"1"`
-	ParentKey *string `json:"parentKey" example:"null"`
-	Name      string  `json:"name" binding:"required" example:"Board of Directors"`
-	Level     int     `json:"level" binding:"required" example:"1"`
-	Address   string  `json:"address" example:"Jakarta"`
-	Active    bool    `json:"active" example:"true"`
+	CustomerID string  `json:"customer_id,omitempty" example:"01J8Z6K2Q9M4X7V3B5N1C0D2E4"` // wajib untuk POST /structures
+	TempKey    string  `json:"tempKey" example:"1"`
+	ParentKey  *string `json:"parentKey" example:"null"`
+	Name       string  `json:"name" binding:"required" example:"Board of Directors"`
+	Level      int     `json:"level" binding:"required" example:"1"`
+	Address    string  `json:"address" example:"Jakarta"`
+	Active     bool    `json:"active" example:"true"`
 }
 
 // CreateGroupsRequest represents groups assignment in customer request
@@ -200,15 +203,15 @@ type CreateGroupsRequest struct {
 
 // CreateOtherRequest represents other attributes in customer request
 type CreateOtherRequest struct {
-	// CustomerID uint    `json:"customer_id" binding:"required"` // Hapus field ini
-	Key    string  `json:"key" binding:"required" example:"company_size"`
-	Value  *string `json:"value" example:"50-100 employees"`
-	Active bool    `json:"active" example:"true"`
+	CustomerID string  `json:"customer_id,omitempty" example:"01J8Z6K2Q9M4X7V3B5N1C0D2E4"` // wajib untuk POST /others
+	Key        string  `json:"key" binding:"required" example:"company_size"`
+	Value      *string `json:"value" example:"50-100 employees"`
+	Active     bool    `json:"active" example:"true"`
 }
 
 // CreateActivityRequest represents activity creation request
 type CreateActivityRequest struct {
-	CustomerID   uint   `json:"customer_id" binding:"required" example:"1"`
+	CustomerID   string `json:"customer_id" binding:"required" example:"01J8Z6K2Q9M4X7V3B5N1C0D2E4"`
 	Title        string `json:"title" binding:"required" example:"Client Meeting"`
 	Type         string `json:"type" binding:"required" example:"Meeting"`
 	Agenda       string `json:"agenda" example:"Discuss project requirements"`
@@ -233,7 +236,7 @@ type UpdateActivityRequest struct {
 // ActivityResponse represents activity response
 type ActivityResponse struct {
 	ID           string `json:"id"`
-	CustomerID   uint   `json:"customer_id" example:"1"`
+	CustomerID   string `json:"customer_id" example:"01J8Z6K2Q9M4X7V3B5N1C0D2E4"`
 	Title        string `json:"title" example:"Client Meeting"`
 	Type         string `json:"type" example:"Meeting"`
 	Agenda       string `json:"agenda" example:"Discuss project requirements"`
@@ -249,7 +252,8 @@ type ActivityResponse struct {
 // ActivitiesResponse represents activities list response
 type ActivitiesResponse struct {
 	Activities []ActivityResponse `json:"activities"`
-	Total      int64              `json:"total" example:"10"`
+	Total      int64              `json:"total" example:"10"` // jumlah sesuai filter
+	Pagination Pagination         `json:"pagination"`
 }
 
 // ActivityAttendeeRequest represents activity attendee request
@@ -265,62 +269,166 @@ type ActivityCheckinRequest struct {
 }
 
 // Invoice DTOs
-/* type CreateInvoiceRequest struct {
-	CustomerID    uint      `json:"customer_id" binding:"required"`
-	ProjectID     string    `json:"project_id"`
-	InvoiceNumber string    `json:"invoice_number" binding:"required"`
-	Amount        float64   `json:"amount" binding:"required,gt=0"`
-	IssuedDate    time.Time `json:"issued_date" binding:"required"`
-	DueDate       time.Time `json:"due_date" binding:"required"`
-	PaidAmount    float64   `json:"paid_amount" binding:"gte=0"`
-} */
-
-/* type UpdateInvoiceRequest struct {
-	ProjectID     *string    `json:"project_id"`
-	InvoiceNumber *string    `json:"invoice_number"`
-	Amount        *float64   `json:"amount" binding:"omitempty,gt=0"`
-	IssuedDate    *time.Time `json:"issued_date"`
-	DueDate       *time.Time `json:"due_date"`
-	PaidAmount    *float64   `json:"paid_amount" binding:"omitempty,gte=0"`
-} */
-
-/* type InvoiceResponse struct {
-	ID            uint              `json:"id"`
-	CustomerID    uint              `json:"customer_id"`
-	ProjectID     string            `json:"project_id"`
-	InvoiceNumber string            `json:"invoice_number"`
-	Amount        float64           `json:"amount"`
-	IssuedDate    time.Time         `json:"issued_date"`
-	DueDate       time.Time         `json:"due_date"`
-	PaidAmount    float64           `json:"paid_amount"`
-	Balance       float64           `json:"balance"` // Amount - PaidAmount
-	Status        string            `json:"status"`  // Paid, Partial, Unpaid, Overdue
-	CreatedAt     time.Time         `json:"created_at"`
-	UpdatedAt     time.Time         `json:"updated_at"`
-	Customer      *CustomerResponse `json:"customer,omitempty"`
-} */
+// InvoiceNumber digenerate otomatis dan PaidAmount hanya berubah lewat pembayaran.
+// Jika Items diisi, Amount dihitung dari subtotal item + pajak (TaxRate);
+// tanpa Items, Amount adalah total termasuk pajak.
+type CreateInvoiceRequest struct {
+	CustomerID string               `json:"customer_id" binding:"required" example:"01J8Z6K2Q9M4X7V3B5N1C0D2E4"`
+	ProjectID  string               `json:"project_id"`
+	Amount     float64              `json:"amount" binding:"omitempty,gt=0" example:"15000000"`
+	TaxRate    float64              `json:"tax_rate" binding:"gte=0,lte=100" example:"11"`
+	IssuedDate time.Time            `json:"issued_date" binding:"required" example:"2025-01-15T00:00:00Z"`
+	DueDate    time.Time            `json:"due_date" binding:"required" example:"2025-02-14T00:00:00Z"`
+	Notes      string               `json:"notes" example:"Termin 1"`
+	TemplateID *string              `json:"template_id"`
+	Items      []InvoiceItemRequest `json:"items,omitempty" binding:"omitempty,dive"`
+}
+
+type UpdateInvoiceRequest struct {
+	ProjectID  *string    `json:"project_id"`
+	Amount     *float64   `json:"amount" binding:"omitempty,gt=0"`
+	TaxRate    *float64   `json:"tax_rate" binding:"omitempty,gte=0,lte=100"`
+	IssuedDate *time.Time `json:"issued_date"`
+	DueDate    *time.Time `json:"due_date"`
+	Notes      *string    `json:"notes"`
+	TemplateID *string    `json:"template_id"`
+	// Jika diisi, seluruh item diganti
+	Items *[]InvoiceItemRequest `json:"items,omitempty" binding:"omitempty,dive"`
+}
+
+type InvoiceItemRequest struct {
+	Description string  `json:"description" binding:"required" example:"Lisensi software 12 bulan"`
+	Quantity    float64 `json:"quantity" binding:"required,gt=0" example:"1"`
+	UnitPrice   float64 `json:"unit_price" binding:"gte=0" example:"13500000"`
+}
+
+type InvoiceTemplateRequest struct {
+	Name                string `json:"name" binding:"required" example:"PT Maju Bersama"`
+	CompanyName         string `json:"company_name" binding:"required" example:"PT Maju Bersama"`
+	CompanyAddress      string `json:"company_address" example:"Jl. Sudirman No. 1, Jakarta"`
+	CompanyPhone        string `json:"company_phone" example:"021-5550000"`
+	CompanyEmail        string `json:"company_email" example:"finance@majubersama.co.id"`
+	CompanyTaxID        string `json:"company_tax_id" example:"01.234.567.8-901.000"`
+	LogoPath            string `json:"logo_path" example:"uploads/logos/company.png"` // harus di dalam uploads/logos/
+	PrimaryColor        string `json:"primary_color" example:"#1F4E79"`
+	PaymentInstructions string `json:"payment_instructions" example:"Transfer ke BCA 123-456-7890 a.n. PT Maju Bersama"`
+	FooterText          string `json:"footer_text" example:"Terima kasih atas kepercayaan Anda"`
+	IsDefault           bool   `json:"is_default" example:"true"`
+}
+
+type InvoiceResponse struct {
+	ID            string                `json:"id"`
+	CustomerID    string                `json:"customer_id"`
+	ProjectID     string                `json:"project_id"`
+	InvoiceNumber string                `json:"invoice_number" example:"INV-2025-00001"`
+	Amount        float64               `json:"amount"`
+	TaxRate       float64               `json:"tax_rate"`
+	TaxAmount     float64               `json:"tax_amount"`
+	IssuedDate    time.Time             `json:"issued_date"`
+	DueDate       time.Time             `json:"due_date"`
+	PaidAmount    float64               `json:"paid_amount"`
+	Balance       float64               `json:"balance"` // Amount - PaidAmount
+	Status        string                `json:"status"`  // Paid, Partial, Unpaid, Overdue
+	Notes         string                `json:"notes"`
+	TemplateID    *string               `json:"template_id"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+	Customer      *CustomerResponse     `json:"customer,omitempty"`
+	Items         []InvoiceItemResponse `json:"items,omitempty"`
+}
+
+type InvoiceItemResponse struct {
+	ID          string  `json:"id"`
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+}
 
 // Payment DTOs
-/* type CreatePaymentRequest struct {
-	InvoiceID uint      `json:"invoice_id" binding:"required"`
-	Amount    float64   `json:"amount" binding:"required,gt=0"`
-	PaidAt    time.Time `json:"paid_at" binding:"required"`
-}
-
-type UpdatePaymentRequest struct {
-	Amount *float64   `json:"amount" binding:"omitempty,gt=0"`
-	PaidAt *time.Time `json:"paid_at"`
-} */
-
-/* type PaymentResponse struct {
-	ID        uint             `json:"id"`
-	InvoiceID uint             `json:"invoice_id"`
-	Amount    float64          `json:"amount"`
-	PaidAt    time.Time        `json:"paid_at"`
-	CreatedAt time.Time        `json:"created_at"`
-	UpdatedAt time.Time        `json:"updated_at"`
-	Invoice   *InvoiceResponse `json:"invoice,omitempty"`
-} */
+// Pembayaran tidak bisa diubah; koreksi dilakukan dengan reverse lalu input ulang
+type CreatePaymentRequest struct {
+	Amount    float64   `json:"amount" binding:"required,gt=0" example:"5000000"`
+	PaidAt    time.Time `json:"paid_at" binding:"required" example:"2025-01-20T00:00:00Z"`
+	Method    string    `json:"method" example:"Transfer"`
+	Reference string    `json:"reference" example:"TRX-889120"`
+	Notes     string    `json:"notes"`
+	// Jika true, kelebihan bayar dicatat sebagai kredit customer, bukan ditolak
+	AllowCredit bool `json:"allow_credit" example:"false"`
+}
+
+type ReversePaymentRequest struct {
+	Reason string `json:"reason" binding:"required" example:"Transfer dibatalkan bank"`
+}
+
+type PaymentResponse struct {
+	ID             string           `json:"id"`
+	InvoiceID      string           `json:"invoice_id"`
+	CustomerID     string           `json:"customer_id"`
+	Amount         float64          `json:"amount"`
+	AppliedAmount  float64          `json:"applied_amount"`
+	CreditAmount   float64          `json:"credit_amount"`
+	PaidAt         time.Time        `json:"paid_at"`
+	Method         string           `json:"method"`
+	Reference      string           `json:"reference"`
+	Notes          string           `json:"notes"`
+	CreatedBy      string           `json:"created_by"`
+	ReversedAt     *time.Time       `json:"reversed_at"`
+	ReversedBy     string           `json:"reversed_by,omitempty"`
+	ReversalReason string           `json:"reversal_reason,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	Invoice        *InvoiceResponse `json:"invoice,omitempty"`
+}
+
+// LedgerEntry is one line of a customer's receivable ledger
+type LedgerEntry struct {
+	Date        time.Time `json:"date"`
+	Type        string    `json:"type"` // Invoice, Payment, Reversal
+	Reference   string    `json:"reference"`
+	InvoiceID   string    `json:"invoice_id"`
+	PaymentID   string    `json:"payment_id,omitempty"`
+	Description string    `json:"description"`
+	Debit       float64   `json:"debit"`
+	Credit      float64   `json:"credit"`
+	Balance     float64   `json:"balance"` // saldo berjalan, negatif berarti customer punya kredit
+}
+
+type LedgerResponse struct {
+	CustomerID       string        `json:"customer_id"`
+	TotalInvoiced    float64       `json:"total_invoiced"`
+	TotalPaid        float64       `json:"total_paid"`
+	CreditBalance    float64       `json:"credit_balance"`
+	OutstandingTotal float64       `json:"outstanding_total"`
+	Entries          []LedgerEntry `json:"entries"`
+}
+
+// AgingBuckets holds outstanding balances per days-past-due bucket
+type AgingBuckets struct {
+	Current    float64 `json:"current"`
+	Days1To30  float64 `json:"days_1_30"`
+	Days31To60 float64 `json:"days_31_60"`
+	Days61To90 float64 `json:"days_61_90"`
+	Days90Plus float64 `json:"days_90_plus"`
+	Total      float64 `json:"total"`
+}
+
+// AgingRow is one customer or account manager in the AR aging report
+type AgingRow struct {
+	Key              string `json:"key"` // customer_id atau account_manager_id
+	Name             string `json:"name"`
+	Code             string `json:"code,omitempty"`
+	AccountManagerID string `json:"account_manager_id,omitempty"`
+	InvoiceCount     int    `json:"invoice_count"`
+	AgingBuckets
+}
+
+type AgingReportResponse struct {
+	AsOf    string       `json:"as_of" example:"2025-03-31"`
+	GroupBy string       `json:"group_by" example:"customer"`
+	Rows    []AgingRow   `json:"rows"`
+	Totals  AgingBuckets `json:"totals"`
+}
 
 // Status DTOs
 type CreateStatusRequest struct {
@@ -339,17 +447,421 @@ type StatusResponse struct {
 }
 
 type Assessment struct {
-	Name   string `json:"name"`
-	RoleID string `json:"role_id"`
+	Name        string `json:"name" binding:"required" example:"Credit worthiness"`
+	Description string `json:"description"`
+	RoleID      string `json:"role_id"`
+	IsActive    *bool  `json:"is_active"`
+}
+
+// CustomerSearchResult is one ranked hit of GET /api/customers/search
+type CustomerSearchResult struct {
+	Customer CustomerResponse      `json:"customer"`
+	Score    float64               `json:"score" example:"0.8125"`
+	Matches  []CustomerSearchMatch `json:"matches"`
+}
+
+// CustomerSearchMatch shows which field matched, with the matching words in <mark>
+type CustomerSearchMatch struct {
+	Field   string `json:"field" example:"contact.email"`
+	Value   string `json:"value" example:"budi@teknologimaju.com"`
+	Snippet string `json:"snippet" example:"budi@<mark>teknologimaju</mark>.com"`
+}
+
+// CustomerImportRow is the outcome of one spreadsheet row. Status is valid
+// (dry run), created, failed or skipped (another row of the customer failed)
+type CustomerImportRow struct {
+	Row        int      `json:"row" example:"2"`
+	Code       string   `json:"code" example:"TM001"`
+	Name       string   `json:"name" example:"PT Teknologi Maju"`
+	Status     string   `json:"status" example:"created"`
+	CustomerID string   `json:"customer_id,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+type CustomerImportResponse struct {
+	DryRun           bool                `json:"dry_run"`
+	TotalRows        int                 `json:"total_rows"`
+	TotalCustomers   int                 `json:"total_customers"`
+	ValidCustomers   int                 `json:"valid_customers"`
+	CreatedCustomers int                 `json:"created_customers"`
+	FailedRows       int                 `json:"failed_rows"`
+	Rows             []CustomerImportRow `json:"rows"`
+}
+
+// CustomerDuplicate is a pair of customers that probably describe the same
+// company. Matches holds the similarity (0-1) of every compared field.
+type CustomerDuplicate struct {
+	Customer  CustomerResponse   `json:"customer"`
+	Duplicate CustomerResponse   `json:"duplicate"`
+	Score     float64            `json:"score" example:"0.87"`
+	Matches   map[string]float64 `json:"matches"`
+}
+
+// MergeCustomerRequest merges DuplicateID into the customer of the URL
+type MergeCustomerRequest struct {
+	DuplicateID string `json:"duplicate_id" binding:"required" example:"01J8Z6K2Q9M4X7V3B5N1C0D2E4"`
+	// Version (ETag) duplikat yang terakhir dibaca; If-Match untuk customer URL
+	DuplicateVersion int `json:"duplicate_version" binding:"required" example:"3"`
+	// Isi field kosong pada customer yang dipertahankan dengan nilai dari duplikat
+	FillEmptyFields bool `json:"fill_empty_fields" example:"true"`
+}
+
+type MergeCustomerResponse struct {
+	Customer CustomerResponse `json:"customer"`
+	MergedID string           `json:"merged_id"`
+	Moved    map[string]int64 `json:"moved"` // jumlah data yang dipindah per tabel
+}
+
+// FieldChange is the value of one field before and after a change. Before is
+// empty for created records, After for deleted ones.
+type FieldChange struct {
+	Field  string `json:"field" example:"email"`
+	Before string `json:"before" example:"info@maju.co.id"`
+	After  string `json:"after" example:"sales@maju.co.id"`
+}
+
+type HistoryUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username" example:"admin"`
+}
+
+// CustomerHistoryEntry is one change in the customer timeline
+type CustomerHistoryEntry struct {
+	ID         string        `json:"id"`
+	Action     string        `json:"action" example:"update"` // create, update, delete, merge
+	Status     string        `json:"status" example:"Updated"`
+	EntityType string        `json:"entity_type" example:"contact"` // customer, address, contact, sosmed, structure, other
+	EntityID   string        `json:"entity_id"`
+	Summary    string        `json:"summary" example:"Updated contact Budi Santoso: email, phone"`
+	Changes    []FieldChange `json:"changes"`
+	User       HistoryUser   `json:"user"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// AuditLogResponse is one audited change on any entity
+type AuditLogResponse struct {
+	ID         string        `json:"id"`
+	EntityType string        `json:"entity_type" example:"roles"` // nama tabel
+	EntityID   string        `json:"entity_id"`
+	Action     string        `json:"action" example:"update"` // create, update, delete
+	Changes    []FieldChange `json:"changes"`
+	User       *HistoryUser  `json:"user"` // null untuk perubahan oleh sistem
+	IP         string        `json:"ip" example:"10.0.0.12"`
+	RequestID  string        `json:"request_id"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// TimelineEntry is one item of the customer timeline
+type TimelineEntry struct {
+	Type       string       `json:"type" example:"invoice"` // history, status, document, activity, checkin, event, invoice, payment
+	ID         string       `json:"id"`
+	OccurredAt time.Time    `json:"occurred_at"`
+	Title      string       `json:"title" example:"INV-2025-0001"`
+	Subtype    string       `json:"subtype,omitempty" example:"Meeting"` // action history, jenis document/activity, metode payment
+	Status     string       `json:"status,omitempty" example:"Partial"`
+	Amount     *float64     `json:"amount,omitempty" example:"15000000"` // hanya invoice dan payment
+	Reference  string       `json:"reference,omitempty"`                 // record terkait: entity history, file document, activity check-in, invoice payment
+	User       *HistoryUser `json:"user"`                                // null jika tidak diketahui
+}
+
+// TrashItem is one soft-deleted record
+type TrashItem struct {
+	Type       string    `json:"type" example:"customer"` // customer, address, contact, sosmed, structure, other
+	ID         string    `json:"id"`
+	CustomerID string    `json:"customer_id,omitempty"` // kosong untuk customer
+	Label      string    `json:"label" example:"PT Teknologi Maju"`
+	DeletedAt  time.Time `json:"deleted_at"`
+}
+
+// TrashPurgeResult counts the records purged from the trash per table
+type TrashPurgeResult struct {
+	Cutoff  time.Time        `json:"cutoff"`
+	Purged  map[string]int64 `json:"purged"`
+	Skipped []string         `json:"skipped"` // customer yang masih punya activity, invoice, dll. atau gagal dipurge
+}
+
+// CustomerStatusChangeRequest moves a customer to another status. Sent as
+// multipart form when a document is attached (field "file"), otherwise JSON.
+// Times are RFC3339 or a date (2006-01-02, local midnight).
+type CustomerStatusChangeRequest struct {
+	Status      string `json:"status" form:"status" binding:"required" example:"Blocked"` // tidak case-sensitive
+	Reason      string `json:"reason" form:"reason"`
+	Notes       string `json:"notes" form:"notes"`                              // catatan untuk dokumen
+	EffectiveAt string `json:"effective_at" form:"effective_at"`                // kosong = sekarang
+	RevertAt    string `json:"revert_at" form:"revert_at" example:"2025-12-31"` // kembali ke status sebelumnya, misalnya auto-unblock
+}
+
+// CreatePipelineItemRequest places a customer or opportunity on the first
+// step of a workflow, or on StepID when given. Without WorkflowID the workflow
+// is resolved from WorkflowType and Value by threshold routing.
+type CreatePipelineItemRequest struct {
+	WorkflowID   string  `json:"workflow_id"`
+	WorkflowType string  `json:"workflow_type" example:"deal"`
+	CustomerID   string  `json:"customer_id" binding:"required"`
+	Type         string  `json:"type" example:"opportunity"` // customer (default) atau opportunity
+	Title        string  `json:"title" example:"Renewal kontrak 2025"`
+	Value        float64 `json:"value" example:"150000000"`
+	StepID       string  `json:"step_id"`
+}
+
+// PipelineMoveRequest is the optional body of advance and revert
+type PipelineMoveRequest struct {
+	Notes string `json:"notes"`
+}
+
+// PipelineBoardItem is one card of the Kanban board
+type PipelineBoardItem struct {
+	ID            string     `json:"id"`
+	CustomerID    string     `json:"customer_id"`
+	CustomerName  string     `json:"customer_name"`
+	Type          string     `json:"type"`
+	Title         string     `json:"title"`
+	Value         float64    `json:"value"`
+	OwnerID       string     `json:"owner_id"`
+	StepEnteredAt time.Time  `json:"step_entered_at"`
+	DueAt         *time.Time `json:"due_at"`
+	SLAState      string     `json:"sla_state"`
+}
+
+// PipelineBoardColumn is one step of the Kanban board with its open items
+type PipelineBoardColumn struct {
+	StepID     string              `json:"step_id"`
+	Name       string              `json:"name"`
+	FlowOrder  int                 `json:"flow_order"`
+	Count      int                 `json:"count"`
+	TotalValue float64             `json:"total_value"`
+	Items      []PipelineBoardItem `json:"items"` // paling lama di step dulu, dibatasi limit
+}
+
+// PipelineBoard groups the open items of a workflow by step
+type PipelineBoard struct {
+	WorkflowID string                `json:"workflow_id"`
+	Workflow   string                `json:"workflow"`
+	Columns    []PipelineBoardColumn `json:"columns"`
+	Completed  int64                 `json:"completed"`
+}
+
+// SLAItem is an open pipeline item on the SLA dashboard
+type SLAItem struct {
+	ID            string     `json:"id"`
+	CustomerID    string     `json:"customer_id"`
+	CustomerName  string     `json:"customer_name"`
+	Title         string     `json:"title"`
+	WorkflowID    string     `json:"workflow_id"`
+	StepID        string     `json:"step_id"`
+	Step          string     `json:"step"`
+	OwnerID       string     `json:"owner_id"`
+	StepEnteredAt time.Time  `json:"step_entered_at"`
+	DueAt         *time.Time `json:"due_at"`
+	SLAState      string     `json:"sla_state" example:"at_risk"` // none, on_track, at_risk, breached
+}
+
+// SLASummary counts open pipeline items per SLA state
+type SLASummary struct {
+	Open     int `json:"open"`
+	OnTrack  int `json:"on_track"`
+	AtRisk   int `json:"at_risk"`
+	Breached int `json:"breached"`
+	NoSLA    int `json:"no_sla"`
+}
+
+// SLAStepStats is the SLA result of one stage step
+type SLAStepStats struct {
+	StepID          string  `json:"step_id"`
+	Name            string  `json:"name"`
+	FlowOrder       int     `json:"flow_order"`
+	Sla             int     `json:"sla"`
+	Uom             string  `json:"uom"`
+	Open            int     `json:"open"`
+	OnTrack         int     `json:"on_track"`
+	AtRisk          int     `json:"at_risk"`
+	Breached        int     `json:"breached"`
+	Completed       int     `json:"completed"`         // kunjungan yang selesai sejak from
+	Met             int     `json:"met"`               // selesai sebelum due
+	Missed          int     `json:"missed"`            // selesai setelah due
+	AvgWorkingHours float64 `json:"avg_working_hours"` // rata-rata jam kerja per kunjungan selesai
+	ComplianceRate  float64 `json:"compliance_rate"`   // persen met dari kunjungan ber-SLA
+}
+
+// SLADashboard is the response of GET /api/sla/dashboard
+type SLADashboard struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	From        time.Time      `json:"from"`
+	Summary     SLASummary     `json:"summary"`
+	Steps       []SLAStepStats `json:"steps"`
+	AtRisk      []SLAItem      `json:"at_risk"`  // due paling dekat dulu
+	Breached    []SLAItem      `json:"breached"` // paling lama lewat due dulu
+}
+
+// HolidayRequest adds a holiday to the SLA calendar
+type HolidayRequest struct {
+	Date string `json:"date" binding:"required" example:"2025-08-17"`
+	Name string `json:"name" binding:"required" example:"Hari Kemerdekaan"`
+}
+
+// ResolveWorkflowRequest asks which workflow handles a value of a type
+type ResolveWorkflowRequest struct {
+	Type    string   `json:"type" binding:"required" example:"invoice"`
+	Value   *float64 `json:"value" binding:"required" example:"75000000"` // deal size, amount, skor
+	StageID string   `json:"stage_id"`                                    // kosong = semua stage
+}
+
+// ResolveWorkflowResponse is the workflow selected by threshold routing
+type ResolveWorkflowResponse struct {
+	WorkflowID   string   `json:"workflow_id"`
+	Name         string   `json:"name"`
+	StageID      string   `json:"stage_id"`
+	ThresFrom    int      `json:"thres_from"`
+	ThresTo      int      `json:"thres_to"` // 0 = tanpa batas atas
+	Steps        int      `json:"steps"`    // jumlah detail workflow aktif
+	CandidateIDs []string `json:"candidate_ids"`
+}
+
+// WorkflowThreshold is the new range of one workflow
+type WorkflowThreshold struct {
+	ID        string `json:"id" binding:"required"`
+	ThresFrom int    `json:"thres_from"`
+	ThresTo   int    `json:"thres_to"`
+	Version   int    `json:"version" binding:"required"` // ETag terakhir dari GET workflow
+}
+
+// UpdateThresholdsRequest changes several workflow ranges in one transaction
+type UpdateThresholdsRequest struct {
+	Workflows []WorkflowThreshold `json:"workflows" binding:"required,min=1,dive"`
+}
+
+// StatusChangeDecisionRequest approves or rejects a pending status change
+type StatusChangeDecisionRequest struct {
+	Note string `json:"note"`
+}
+
+// ApprovalDecisionRequest approves, rejects or cancels an approval request
+type ApprovalDecisionRequest struct {
+	Note string `json:"note" example:"Outstanding balance checked with finance"`
+}
+
+// CustomerStatusTransition is one allowed move of the customer status machine
+type CustomerStatusTransition struct {
+	From            string `json:"from" example:"Active"`
+	To              string `json:"to" example:"Blocked"`
+	RequireReason   bool   `json:"require_reason"`
+	RequireDocument bool   `json:"require_document"`
+	RequireApproval bool   `json:"require_approval"` // disetujui user lain sebelum diterapkan
+}
+
+// CreateExportJobRequest queues a background export. Filters and sort use the
+// same syntax as the list endpoint of the type, e.g. {"status": "Active", "name[like]": "maju"}
+type CreateExportJobRequest struct {
+	Type    string            `json:"type" binding:"required,oneof=customers activities invoices history" example:"customers"`
+	Format  string            `json:"format" binding:"required,oneof=excel pdf csv jsonl" example:"csv"`
+	Filters map[string]string `json:"filters"`
+	Sort    string            `json:"sort" example:"-created_at"`
+	Columns string            `json:"columns" example:"code,name,main_contact_email"` // customers only
+}
+
+type ExportJobResponse struct {
+	ID                string     `json:"id"`
+	Type              string     `json:"type" example:"customers"`
+	Format            string     `json:"format" example:"csv"`
+	Status            string     `json:"status" example:"running"` // queued, running, completed, failed, expired
+	Progress          int        `json:"progress" example:"40"`    // persen
+	TotalRows         int        `json:"total_rows" example:"50000"`
+	ProcessedRows     int        `json:"processed_rows" example:"20000"`
+	FileName          string     `json:"file_name,omitempty" example:"customers-20250115-101500.csv"`
+	FileSize          int64      `json:"file_size,omitempty"`
+	Error             string     `json:"error,omitempty"`
+	DownloadURL       string     `json:"download_url,omitempty"`
+	DownloadExpiresAt *time.Time `json:"download_expires_at,omitempty"`
+	StartedAt         *time.Time `json:"started_at"`
+	FinishedAt        *time.Time `json:"finished_at"`
+	ExpiresAt         *time.Time `json:"expires_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// Pagination describes one page of a list endpoint. Total and TotalPages are
+// only filled for page/page_size requests, NextCursor only for cursor requests.
+type Pagination struct {
+	Page       int    `json:"page,omitempty" example:"1"`
+	PageSize   int    `json:"page_size" example:"20"`
+	Total      *int64 `json:"total,omitempty" example:"135"`
+	TotalPages int    `json:"total_pages,omitempty" example:"7"`
+	NextCursor string `json:"next_cursor,omitempty" example:"01J8Z6K2Q9M4X7V3B5N1C0D2E4"`
+	HasMore    bool   `json:"has_more" example:"true"`
 }
 
 type Response struct {
-	Status  int         `json:"status"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+	Status     int         `json:"status"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data"`
+	Pagination *Pagination `json:"pagination,omitempty"`
 }
 
+// AssessmentDetail creates or updates an assessment question. Options are
+// required for choice questions and replace the existing ones on update.
 type AssessmentDetail struct {
-	Name     string `json:"name"`
-	IsActive bool   `json:"is_active"`
+	Name      string                  `json:"name" binding:"required" example:"Pays invoices on time"`
+	Type      string                  `json:"type" example:"scale" enums:"scale,yes_no,choice"`
+	Weight    *float64                `json:"weight" example:"2"`
+	ScaleMin  *int                    `json:"scale_min" example:"1"`
+	ScaleMax  *int                    `json:"scale_max" example:"5"`
+	Required  bool                    `json:"required"`
+	SortOrder *int                    `json:"sort_order"`
+	IsActive  *bool                   `json:"is_active"`
+	Options   []AssessmentOptionInput `json:"options" binding:"dive"`
+}
+
+// AssessmentOptionInput is one answer of a choice question, score 0-100
+type AssessmentOptionInput struct {
+	Label string  `json:"label" binding:"required" example:"Enterprise"`
+	Score float64 `json:"score" binding:"min=0,max=100" example:"100"`
+}
+
+// AssessmentAnswerInput answers one question: value for scale, yes for
+// yes_no and option_id for choice questions
+type AssessmentAnswerInput struct {
+	DetailID string   `json:"detail_id" binding:"required"`
+	Value    *float64 `json:"value" example:"4"`
+	Yes      *bool    `json:"yes"`
+	OptionID string   `json:"option_id"`
+}
+
+// CreateAssessmentRunRequest submits an assessment of a customer
+type CreateAssessmentRunRequest struct {
+	CustomerID   string                  `json:"customer_id" binding:"required"`
+	Notes        string                  `json:"notes"`
+	UpdateRating bool                    `json:"update_rating"` // salin rating hasil ke customer
+	Answers      []AssessmentAnswerInput `json:"answers" binding:"required,dive"`
+}
+
+// AssessmentRunSummary is one run in a comparison
+type AssessmentRunSummary struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Score     float64   `json:"score"`
+	Rating    float64   `json:"rating"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AssessmentAnswerDiff compares the answers of one question in two runs.
+// Scores are empty when the question was not answered in that run.
+type AssessmentAnswerDiff struct {
+	DetailID   string   `json:"detail_id"`
+	Question   string   `json:"question"`
+	Weight     float64  `json:"weight"`
+	FromAnswer string   `json:"from_answer"`
+	ToAnswer   string   `json:"to_answer"`
+	FromScore  *float64 `json:"from_score"`
+	ToScore    *float64 `json:"to_score"`
+	Delta      *float64 `json:"delta"`
+}
+
+// AssessmentComparison compares two runs of the same assessment for a customer
+type AssessmentComparison struct {
+	AssessmentID string                 `json:"assessment_id"`
+	CustomerID   string                 `json:"customer_id"`
+	From         AssessmentRunSummary   `json:"from"`
+	To           AssessmentRunSummary   `json:"to"`
+	ScoreDelta   float64                `json:"score_delta"`
+	RatingDelta  float64                `json:"rating_delta"`
+	Questions    []AssessmentAnswerDiff `json:"questions"`
 }
@@ -10,7 +10,7 @@ import (
 // Activity model - tabel untuk aktivitas customer
 type Activity struct {
 	ID		string         		`json:"id" gorm:"primaryKey;size:26"`
-	CustomerID   uint           `json:"customer_id" gorm:"not null"`
+	CustomerID   string         `json:"customer_id" gorm:"size:26;not null;index"`
 	Title        string         `json:"title" gorm:"not null"`
 	Type         string         `json:"type" gorm:"not null"`
 	Agenda       string         `json:"agenda"`
@@ -22,6 +22,7 @@ type Address struct {
 	PostalCode string         `json:"postal_code"`
 	Main       bool           `json:"main" gorm:"default:false"`
 	Active     bool           `json:"active" gorm:"default:true"`
+	Version    int            `json:"version" gorm:"not null;default:1"` // optimistic locking, dikirim sebagai ETag
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
@@ -30,6 +31,12 @@ type Address struct {
 	Customer Customer `json:"-" gorm:"foreignKey:CustomerID"`
 }
 
+// BeforeUpdate hook - naikkan version
+func (s *Address) BeforeUpdate(tx *gorm.DB) error {
+	bumpVersion(tx, s.Version)
+	return nil
+}
+
 // BeforeCreate hook - generate ulid for ID
 // before save generate id
 func (s *Address) BeforeCreate(tx *gorm.DB) (err error) {
@@ -0,0 +1,91 @@
+package entity
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// Aksi yang butuh approval. Nama aksi juga menjadi type workflow yang
+// menentukan level approval-nya.
+const (
+	ApprovalCustomerBlock  = "customer_block"
+	ApprovalCustomerDelete = "customer_delete"
+	ApprovalInvoiceCreate  = "invoice_create"
+)
+
+// Status permintaan approval
+const (
+	ApprovalPending   = "pending"
+	ApprovalApproved  = "approved" // semua level setuju dan perubahan sudah diterapkan
+	ApprovalRejected  = "rejected"
+	ApprovalCancelled = "cancelled"
+	ApprovalFailed    = "failed" // disetujui tapi perubahan gagal diterapkan
+)
+
+// Keputusan satu approver
+const (
+	ApprovalDecisionApprove = "approve"
+	ApprovalDecisionReject  = "reject"
+	ApprovalDecisionCancel  = "cancel" // ditarik oleh yang meminta
+)
+
+// ApprovalRequest model - perubahan yang menunggu persetujuan berjenjang.
+// Level approval adalah WorkflowsDetail dari workflow yang dipilih lewat
+// threshold Value; Payload menyimpan perubahan yang diterapkan setelah level
+// terakhir setuju.
+type ApprovalRequest struct {
+	ID            string     `json:"id" gorm:"primaryKey;size:26"`
+	Action        string     `json:"action" gorm:"type:varchar(50);not null;index"`
+	WorkflowID    string     `json:"workflow_id" gorm:"size:26;not null"`
+	EntityType    string     `json:"entity_type" gorm:"type:varchar(50);not null"`
+	EntityID      string     `json:"entity_id" gorm:"size:26;index"` // kosong untuk data yang belum dibuat, diisi saat diterapkan
+	CustomerID    string     `json:"customer_id" gorm:"size:26;index"`
+	Summary       string     `json:"summary"`
+	Value         float64    `json:"value" gorm:"type:decimal(15,2)"`
+	Payload       string     `json:"payload" gorm:"type:jsonb;default:'{}'"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;index"`
+	CurrentStepID string     `json:"current_step_id" gorm:"size:26"`
+	CurrentLevel  int        `json:"current_level"`
+	Levels        int        `json:"levels"`
+	StepDueAt     *time.Time `json:"step_due_at"`
+	RequestedBy   string     `json:"requested_by" gorm:"size:26;not null;index"`
+	DecidedAt     *time.Time `json:"decided_at"`
+	AppliedAt     *time.Time `json:"applied_at"`
+	Error         string     `json:"error"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relations
+	CurrentStep *WorkflowsDetail `json:"current_step,omitempty" gorm:"foreignKey:CurrentStepID"`
+	Actions     []ApprovalAction `json:"actions,omitempty" gorm:"foreignKey:ApprovalRequestID"`
+}
+
+// ApprovalAction model - riwayat keputusan, siapa menyetujui/menolak level apa
+type ApprovalAction struct {
+	ID                string    `json:"id" gorm:"primaryKey;size:26"`
+	ApprovalRequestID string    `json:"approval_request_id" gorm:"size:26;not null;index"`
+	StepID            string    `json:"step_id" gorm:"size:26"`
+	StepName          string    `json:"step_name"`
+	Level             int       `json:"level"`
+	UserID            string    `json:"user_id" gorm:"size:26;not null"`
+	Decision          string    `json:"decision" gorm:"type:varchar(20);not null"`
+	Note              string    `json:"note"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// before save generate id
+func (s *ApprovalRequest) BeforeCreate(tx *gorm.DB) (err error) {
+	entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+	s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+	return
+}
+
+// before save generate id
+func (s *ApprovalAction) BeforeCreate(tx *gorm.DB) (err error) {
+	entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+	s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+	return
+}
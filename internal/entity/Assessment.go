@@ -8,9 +8,12 @@ import (
 )
 
 
+// Assessment adalah scorecard: daftar pertanyaan berbobot (AssessmentDetail)
+// yang diisi user untuk seorang customer (AssessmentRun)
 type Assessment struct {
 	ID        string         `json:"id" gorm:"primaryKey;size:26"`
 	Name       string         `json:"name" gorm:"not null;unique"`
+	Description string        `json:"description"`
 	RoleID	string         `json:"role_id" gorm:"not null"` // foreign key to Role table
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
@@ -19,6 +22,7 @@ type Assessment struct {
 	
 	// Relationships
 	Role Role `json:"-" gorm:"foreignKey:RoleID"`
+	Details []AssessmentDetail `json:"details,omitempty" gorm:"foreignKey:AssessmentID"`
 
 
 }
@@ -30,4 +34,4 @@ func (s *Assessment) BeforeCreate(tx *gorm.DB) (err error) {
     entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
     s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
     return
-}
\ No newline at end of file
+}
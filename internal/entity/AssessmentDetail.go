@@ -7,17 +7,43 @@ import (
 	"gorm.io/gorm"
 )
 
+// Tipe pertanyaan assessment
+const (
+	QuestionScale  = "scale"  // angka ScaleMin..ScaleMax
+	QuestionYesNo  = "yes_no" // ya = skor penuh
+	QuestionChoice = "choice" // skor dari opsi yang dipilih
+)
+
 
+// AssessmentDetail adalah satu pertanyaan assessment. Skornya 0-100 dikali
+// Weight; nilai assessment adalah rata-rata berbobot semua jawaban.
 type AssessmentDetail struct {
 	ID        string         `json:"id" gorm:"primaryKey;size:26"`
 	AssessmentID string         `json:"assessment_id" gorm:"not null"` // ULID string
-	Name       string         `json:"name" gorm:"not null;unique"`
+	Name       string         `json:"name" gorm:"not null"` // teks pertanyaan
+	Type       string         `json:"type" gorm:"type:varchar(20);not null;default:'scale'"`
+	Weight     float64        `json:"weight" gorm:"not null;default:1"`
+	ScaleMin   int            `json:"scale_min" gorm:"not null;default:1"`
+	ScaleMax   int            `json:"scale_max" gorm:"not null;default:5"`
+	Required   bool           `json:"required" gorm:"default:false"`
+	SortOrder  int            `json:"sort_order" gorm:"not null;default:0"`
 	IsActive  bool           `json:"is_active" gorm:"default:true"`
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
 	// Relations - hilangkan dari JSON response
 	Assessment Assessment `json:"-" gorm:"foreignKey:AssessmentID"`
+	Options []AssessmentOption `json:"options,omitempty" gorm:"foreignKey:AssessmentDetailID"`
+}
+
+// AssessmentOption adalah pilihan jawaban pertanyaan choice
+type AssessmentOption struct {
+	ID                 string    `json:"id" gorm:"primaryKey;size:26"`
+	AssessmentDetailID string    `json:"assessment_detail_id" gorm:"size:26;not null;index"`
+	Label              string    `json:"label" gorm:"not null"`
+	Score              float64   `json:"score" gorm:"not null"` // 0-100
+	SortOrder          int       `json:"sort_order" gorm:"not null;default:0"`
+	CreatedAt          time.Time `json:"created_at"`
 }
 
 
@@ -27,4 +53,11 @@ func (s *AssessmentDetail) BeforeCreate(tx *gorm.DB) (err error) {
     entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
     s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
     return
-}
\ No newline at end of file
+}
+
+// before save generate id
+func (s *AssessmentOption) BeforeCreate(tx *gorm.DB) (err error) {
+    entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+    s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+    return
+}
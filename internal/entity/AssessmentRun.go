@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// AssessmentRun model - satu pengisian assessment untuk customer. Skor 0-100
+// adalah rata-rata berbobot jawaban, Rating adalah skor pada skala rating
+// customer (0-5).
+type AssessmentRun struct {
+	ID            string    `json:"id" gorm:"primaryKey;size:26"`
+	AssessmentID  string    `json:"assessment_id" gorm:"size:26;not null;index"`
+	CustomerID    string    `json:"customer_id" gorm:"size:26;not null;index"`
+	UserID        string    `json:"user_id" gorm:"size:26;not null"`
+	Score         float64   `json:"score" gorm:"type:decimal(5,2);not null"`
+	Rating        float64   `json:"rating" gorm:"type:decimal(3,2);not null"`
+	Notes         string    `json:"notes"`
+	RatingApplied bool      `json:"rating_applied" gorm:"default:false"` // rating sudah disalin ke customer
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+
+	// Relations
+	Assessment *Assessment        `json:"assessment,omitempty" gorm:"foreignKey:AssessmentID"`
+	Answers    []AssessmentAnswer `json:"answers,omitempty" gorm:"foreignKey:AssessmentRunID"`
+}
+
+// AssessmentAnswer model - jawaban satu pertanyaan. Teks pertanyaan, tipe dan
+// bobot disalin saat disimpan supaya riwayat tetap utuh walau pertanyaannya
+// diubah kemudian.
+type AssessmentAnswer struct {
+	ID                 string   `json:"id" gorm:"primaryKey;size:26"`
+	AssessmentRunID    string   `json:"assessment_run_id" gorm:"size:26;not null;index"`
+	AssessmentDetailID string   `json:"assessment_detail_id" gorm:"size:26;not null"`
+	Question           string   `json:"question"`
+	Type               string   `json:"type" gorm:"type:varchar(20);not null"`
+	Weight             float64  `json:"weight"`
+	Value              *float64 `json:"value"` // jawaban scale, 1/0 untuk yes_no
+	OptionID           *string  `json:"option_id" gorm:"size:26"`
+	Answer             string   `json:"answer"` // jawaban dalam teks, mis. "4", "yes", label opsi
+	Score              float64  `json:"score"`  // 0-100 sebelum bobot
+	SortOrder          int      `json:"sort_order"`
+}
+
+// before save generate id
+func (s *AssessmentRun) BeforeCreate(tx *gorm.DB) (err error) {
+	entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+	s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+	return
+}
+
+// before save generate id
+func (s *AssessmentAnswer) BeforeCreate(tx *gorm.DB) (err error) {
+	entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+	s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+	return
+}
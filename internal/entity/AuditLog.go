@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"time"
+	"math/rand"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// AuditLog model - satu perubahan (create/update/delete) pada tabel mana pun,
+// dicatat otomatis oleh callback GORM di package audit
+type AuditLog struct {
+	ID         string    `json:"id" gorm:"primaryKey;size:26"`
+	EntityType string    `json:"entity_type" gorm:"size:64;not null;index:idx_audit_logs_entity"` // nama tabel, mis. roles
+	EntityID   string    `json:"entity_id" gorm:"size:64;index:idx_audit_logs_entity"`
+	Action     string    `json:"action" gorm:"size:16;not null"` // create, update, delete
+	// Nilai sebelum/sesudah per field, JSON array dto.FieldChange
+	Changes   string    `json:"changes" gorm:"type:jsonb;default:'[]'"`
+	UserID    string    `json:"user_id" gorm:"size:26;index"` // kosong untuk proses sistem (seed, worker)
+	IP        string    `json:"ip" gorm:"size:64"`
+	RequestID string    `json:"request_id" gorm:"size:64;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// before save generate id
+func (s *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
+    entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+    s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+    return
+}
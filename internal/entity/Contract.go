@@ -22,6 +22,7 @@ type Contact struct {
 	Department  string         `json:"department"`
 	Main        bool           `json:"main" gorm:"default:false"`
 	Active      bool           `json:"active" gorm:"default:true"`
+	Version     int            `json:"version" gorm:"not null;default:1"` // optimistic locking, dikirim sebagai ETag
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
@@ -30,6 +31,12 @@ type Contact struct {
 	Customer Customer `json:"-" gorm:"foreignKey:CustomerID"`
 }
 
+// BeforeUpdate hook - naikkan version
+func (c *Contact) BeforeUpdate(tx *gorm.DB) error {
+	bumpVersion(tx, c.Version)
+	return nil
+}
+
 // BeforeCreate hook - generate ID before create
 func (c *Contact) BeforeCreate(tx *gorm.DB) error {
 	id, err := ulid.New(ulid.Timestamp(time.Now()), rand.Reader)
@@ -7,6 +7,15 @@ import (
 	"gorm.io/gorm"
 )
 
+// Status customer. Perpindahan antar status hanya lewat state machine di
+// handler (customerStatusTransitions), bukan PUT/PATCH customer.
+const (
+	CustomerStatusDraft    = "Draft"
+	CustomerStatusActive   = "Active"
+	CustomerStatusInactive = "Inactive"
+	CustomerStatusBlocked  = "Blocked"
+)
+
 // Customer model - update untuk menambahkan field baru
 // Customer model - update untuk menambahkan field baru
 type Customer struct {
@@ -20,11 +29,12 @@ type Customer struct {
 	Website          string         `json:"website"`
 	Description      string         `json:"description"`
 	Logo             string         `json:"logo"`
-	Status           string         `json:"status" gorm:"default:'Active'"` // Status internal
+	Status           string         `json:"status" gorm:"default:'Active'"` // Status internal, lihat CustomerStatus*
 	Category         string         `json:"category"`
 	Rating           float64        `json:"rating" gorm:"default:0"`
 	AverageCost      float64        `json:"average_cost" gorm:"default:0"`
 	LogoSmall 		 string         `json:"logo_small"`
+	Version          int            `json:"version" gorm:"not null;default:1"` // optimistic locking, dikirim sebagai ETag
 	CreatedAt        time.Time      `json:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at"`
 	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
@@ -40,6 +50,12 @@ type Customer struct {
 	Events     []Event     `json:"events,omitempty" gorm:"foreignKey:CustomerID"`
 }
 
+// BeforeUpdate hook - naikkan version
+func (c *Customer) BeforeUpdate(tx *gorm.DB) error {
+	bumpVersion(tx, c.Version)
+	return nil
+}
+
 // BeforeCreate hook - generate ID before create
 func (c *Customer) BeforeCreate(tx *gorm.DB) error {
 	id, err := ulid.New(ulid.Timestamp(time.Now()), rand.Reader)
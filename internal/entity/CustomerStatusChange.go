@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// State permintaan perubahan status customer
+const (
+	StatusChangePending   = "pending"   // menunggu approval user lain
+	StatusChangeScheduled = "scheduled" // diterapkan otomatis pada EffectiveAt
+	StatusChangeApplied   = "applied"
+	StatusChangeRejected  = "rejected"
+	StatusChangeCancelled = "cancelled"
+	StatusChangeFailed    = "failed" // status customer sudah berubah sebelum diterapkan
+)
+
+// CustomerStatusChange model - satu permintaan perpindahan status customer.
+// Perpindahan yang butuh approval atau dijadwalkan disimpan di sini sampai
+// diterapkan; perpindahan langsung juga dicatat dengan state applied.
+type CustomerStatusChange struct {
+	ID           string     `json:"id" gorm:"primaryKey;size:26"`
+	CustomerID   string     `json:"customer_id" gorm:"size:26;not null;index"`
+	FromStatus   string     `json:"from_status" gorm:"type:varchar(20);not null"`
+	ToStatus     string     `json:"to_status" gorm:"type:varchar(20);not null"`
+	Reason       string     `json:"reason"`
+	Notes        string     `json:"notes"`
+	DocumentID   *string    `json:"document_id" gorm:"size:26"`
+	State        string     `json:"state" gorm:"type:varchar(20);not null;index"`
+	EffectiveAt  *time.Time `json:"effective_at" gorm:"index"` // kosong = segera setelah disetujui
+	RevertAt     *time.Time `json:"revert_at"`                 // jadwalkan perpindahan balik, misalnya auto-unblock
+	RequestedBy  string     `json:"requested_by" gorm:"size:26;not null"`
+	DecidedBy    string     `json:"decided_by" gorm:"size:26"`
+	DecidedAt    *time.Time `json:"decided_at"`
+	DecisionNote string     `json:"decision_note"`
+	AppliedAt    *time.Time `json:"applied_at"`
+	Error        string     `json:"error"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Relations
+	Customer Customer  `json:"-" gorm:"foreignKey:CustomerID"`
+	Document *Document `json:"document,omitempty" gorm:"foreignKey:DocumentID"`
+}
+
+// before save generate id
+func (s *CustomerStatusChange) BeforeCreate(tx *gorm.DB) (err error) {
+	entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+	s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+	return
+}
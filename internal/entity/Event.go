@@ -12,7 +12,7 @@ type Event struct {
 	ActivityTypeId  uint       `json:"activity_type_id" gorm:"not null"`
 	ScheduledAt    time.Time  `json:"scheduled_at" gorm:"not null"`
 	ScheduledTime time.Time  `json:"scheduled_time" gorm:"not null"`
-	CustomerID  uint           `json:"customer_id" gorm:"not null"`
+	CustomerID  string         `json:"customer_id" gorm:"size:26;not null;index"`
 	ProjectID   uint           `json:"project_id" gorm:"not null"`
 	Attendees  []User         `json:"attendees,omitempty" gorm:"many2many:event_attendees;"`
 	Location 	string         `json:"location"`
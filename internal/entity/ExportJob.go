@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"time"
+	"math/rand"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// Status export job
+const (
+	ExportJobQueued    = "queued"
+	ExportJobRunning   = "running"
+	ExportJobCompleted = "completed"
+	ExportJobFailed    = "failed"
+	ExportJobExpired   = "expired"
+)
+
+// ExportJob model - export besar yang dibuat di background oleh worker.
+// Params menyimpan filter/sort dalam format query string supaya job bisa
+// dijalankan ulang setelah server restart.
+type ExportJob struct {
+	ID            string     `json:"id" gorm:"primaryKey;size:26"`
+	UserID        string     `json:"user_id" gorm:"size:26;not null;index"`
+	Type          string     `json:"type" gorm:"not null"`   // customers, activities, invoices, history
+	Format        string     `json:"format" gorm:"not null"` // excel, pdf, csv, jsonl
+	Params        string     `json:"params"`
+	Columns       string     `json:"columns"`
+	Status        string     `json:"status" gorm:"not null;default:'queued';index"`
+	TotalRows     int        `json:"total_rows" gorm:"default:0"`
+	ProcessedRows int        `json:"processed_rows" gorm:"default:0"`
+	FilePath      string     `json:"-"`
+	FileName      string     `json:"file_name"`
+	FileSize      int64      `json:"file_size" gorm:"default:0"`
+	Error         string     `json:"error"`
+	StartedAt     *time.Time `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	ExpiresAt     *time.Time `json:"expires_at"` // file dihapus setelah waktu ini
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relations
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// before save generate id
+func (s *ExportJob) BeforeCreate(tx *gorm.DB) (err error) {
+    entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+    s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+    return
+}
@@ -10,14 +10,21 @@ import (
 // Customer model - update untuk menambahkan field baru
 type HistoryCustomer struct {
 	ID		string         		`json:"id" gorm:"primaryKey;size:26"`
-	CustomerID      string         `json:"customer_id" gorm:"not null"`
+	CustomerID      string         `json:"customer_id" gorm:"not null;index"`
 	UserID          string         `json:"user_id" gorm:"not null"`
 	Status          string         `json:"status" gorm:"default:'Active'"` // Status internal
 	Notes      string         `json:"notes"`
+	// Record yang berubah: customer atau salah satu data turunannya (address, contact, ...)
+	EntityType string `json:"entity_type" gorm:"size:32;default:'customer'"`
+	EntityID   string `json:"entity_id" gorm:"size:36"`
+	Action     string `json:"action" gorm:"size:16"` // create, update, delete, merge
+	// Nilai sebelum/sesudah per field, JSON array dto.FieldChange
+	Changes string `json:"changes" gorm:"type:jsonb;default:'[]'"`
 	CreatedAt        time.Time      `json:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at"`
 
-	Customer Customer `json:"customer,omitempty" gorm:"foreignKey:CustomerID;references:ID"`
+	// Tanpa foreign key: riwayat tetap ada setelah customer dipurge
+	Customer Customer `json:"customer,omitempty" gorm:"foreignKey:CustomerID;references:ID;constraint:-"`
 	User     User     `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
 
 }
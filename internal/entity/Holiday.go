@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// Holiday model - hari libur kalender SLA, jam kerja tidak dihitung
+type Holiday struct {
+	ID        string    `json:"id" gorm:"primaryKey;size:26"`
+	Date      time.Time `json:"date" gorm:"type:date;not null;uniqueIndex"`
+	Name      string    `json:"name" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// before save generate id
+func (h *Holiday) BeforeCreate(tx *gorm.DB) (err error) {
+	entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+	h.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+	return
+}
@@ -10,20 +10,25 @@ import (
 // Invoice model - tabel untuk invoice
 type Invoice struct {
 	ID            string         `json:"id" gorm:"type:char(26);primary_key"`
-	CustomerID    uint           `json:"customer_id" gorm:"not null"`
+	CustomerID    string         `json:"customer_id" gorm:"size:26;not null;index"`
 	ProjectID     string         `json:"project_id"`
-	InvoiceNumber string         `json:"invoice_number" gorm:"unique;not null"`
-	Amount        float64        `json:"amount" gorm:"not null"`
+	InvoiceNumber string         `json:"invoice_number" gorm:"unique;not null"` // INV-<tahun>-<urutan>, digenerate otomatis
+	Amount        float64        `json:"amount" gorm:"not null"` // total termasuk pajak
+	TaxRate       float64        `json:"tax_rate" gorm:"default:0"` // persen, mis. 11 untuk PPN 11%
+	TaxAmount     float64        `json:"tax_amount" gorm:"default:0"`
+	TemplateID    *string        `json:"template_id" gorm:"size:26"`
 	IssuedDate    time.Time      `json:"issued_date" gorm:"not null"`
 	DueDate       time.Time      `json:"due_date" gorm:"not null"`
 	PaidAmount    float64        `json:"paid_amount" gorm:"default:0"`
+	Notes         string         `json:"notes"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relations
-	Customer Customer  `json:"customer,omitempty" gorm:"foreignKey:CustomerID"`
-	Payments []Payment `json:"payments,omitempty" gorm:"foreignKey:InvoiceID"` // Tambahkan ini
+	Customer Customer      `json:"customer,omitempty" gorm:"foreignKey:CustomerID"`
+	Payments []Payment     `json:"payments,omitempty" gorm:"foreignKey:InvoiceID"` // Tambahkan ini
+	Items    []InvoiceItem `json:"items,omitempty" gorm:"foreignKey:InvoiceID"`
 }
 
 
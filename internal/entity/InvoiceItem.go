@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+	"math/rand"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// InvoiceItem model - baris item pada invoice
+type InvoiceItem struct {
+	ID          string         `json:"id" gorm:"primaryKey;size:26"`
+	InvoiceID   string         `json:"invoice_id" gorm:"size:26;not null;index"`
+	Description string         `json:"description" gorm:"not null"`
+	Quantity    float64        `json:"quantity" gorm:"not null;default:1"`
+	UnitPrice   float64        `json:"unit_price" gorm:"not null;default:0"`
+	Amount      float64        `json:"amount" gorm:"not null;default:0"` // Quantity * UnitPrice
+	Position    int            `json:"position" gorm:"default:0"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// before save generate id
+func (s *InvoiceItem) BeforeCreate(tx *gorm.DB) (err error) {
+    entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+    s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+    return
+}
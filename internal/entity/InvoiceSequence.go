@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+// InvoiceSequence model - counter nomor invoice per tahun
+type InvoiceSequence struct {
+	Year       int       `json:"year" gorm:"primaryKey;autoIncrement:false"`
+	LastNumber int       `json:"last_number" gorm:"not null;default:0"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
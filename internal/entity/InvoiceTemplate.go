@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"time"
+	"math/rand"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// InvoiceTemplate model - identitas perusahaan penerbit dan tampilan PDF invoice.
+// Invoice tanpa TemplateID memakai template dengan IsDefault = true.
+type InvoiceTemplate struct {
+	ID                  string         `json:"id" gorm:"primaryKey;size:26"`
+	Name                string         `json:"name" gorm:"not null;unique"`
+	CompanyName         string         `json:"company_name" gorm:"not null"`
+	CompanyAddress      string         `json:"company_address"`
+	CompanyPhone        string         `json:"company_phone"`
+	CompanyEmail        string         `json:"company_email"`
+	CompanyTaxID        string         `json:"company_tax_id"` // NPWP
+	LogoPath            string         `json:"logo_path"`
+	PrimaryColor        string         `json:"primary_color" gorm:"default:'#1F4E79'"` // hex, mis. #1F4E79
+	PaymentInstructions string         `json:"payment_instructions"`
+	FooterText          string         `json:"footer_text"`
+	IsDefault           bool           `json:"is_default" gorm:"default:false"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// before save generate id
+func (s *InvoiceTemplate) BeforeCreate(tx *gorm.DB) (err error) {
+    entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+    s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+    return
+}
@@ -7,15 +7,28 @@ import (
 	"gorm.io/gorm"
 )
 
-// Payment model - tabel untuk pembayaran invoice
+// Payment model - tabel untuk pembayaran invoice.
+// Amount adalah uang yang diterima; AppliedAmount yang mengurangi saldo invoice,
+// sisanya (CreditAmount) dicatat sebagai kredit customer.
+// Pembayaran tidak diubah/dihapus, melainkan di-reverse.
 type Payment struct {
-	ID        string         `json:"id" gorm:"type:char(26);primary_key"`
-	InvoiceID uint           `json:"invoice_id" gorm:"not null"`
-	Amount    float64        `json:"amount" gorm:"not null"`
-	PaidAt    time.Time      `json:"paid_at" gorm:"not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID             string         `json:"id" gorm:"type:char(26);primary_key"`
+	InvoiceID      string         `json:"invoice_id" gorm:"size:26;not null;index"`
+	CustomerID     string         `json:"customer_id" gorm:"size:26;not null;index"`
+	Amount         float64        `json:"amount" gorm:"not null"`
+	AppliedAmount  float64        `json:"applied_amount" gorm:"not null;default:0"`
+	CreditAmount   float64        `json:"credit_amount" gorm:"not null;default:0"`
+	PaidAt         time.Time      `json:"paid_at" gorm:"not null"`
+	Method         string         `json:"method"`
+	Reference      string         `json:"reference"`
+	Notes          string         `json:"notes"`
+	CreatedBy      string         `json:"created_by"`
+	ReversedAt     *time.Time     `json:"reversed_at"`
+	ReversedBy     string         `json:"reversed_by"`
+	ReversalReason string         `json:"reversal_reason"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relations
 	Invoice Invoice `json:"invoice,omitempty" gorm:"foreignKey:InvoiceID"`
@@ -26,4 +39,4 @@ func (s *Payment) BeforeCreate(tx *gorm.DB) (err error) {
     entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
     s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
     return
-}
\ No newline at end of file
+}
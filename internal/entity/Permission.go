@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+	"math/rand"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// Permission model - hak akses bernama dengan format "<resource>:<action>", mis. "customers:delete"
+type Permission struct {
+	ID          string         `json:"id" gorm:"primaryKey;size:26"`
+	Name        string         `json:"name" gorm:"not null;unique"`
+	Description string         `json:"description"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Roles []Role `json:"roles,omitempty" gorm:"many2many:role_permissions;"`
+}
+
+// before save generate id
+func (s *Permission) BeforeCreate(tx *gorm.DB) (err error) {
+    entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+    s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+    return
+}
@@ -0,0 +1,85 @@
+package entity
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// Status item pipeline
+const (
+	PipelineOpen      = "open"
+	PipelineCompleted = "completed" // sudah melewati step terakhir
+)
+
+// Jenis item pipeline
+const (
+	PipelineTypeCustomer    = "customer"
+	PipelineTypeOpportunity = "opportunity"
+)
+
+// PipelineItem model - customer atau opportunity yang berjalan di sebuah
+// workflow. Step-nya adalah StagesDetail dari stage workflow tersebut,
+// berurutan menurut FlowOrder.
+type PipelineItem struct {
+	ID            string         `json:"id" gorm:"primaryKey;size:26"`
+	WorkflowID    string         `json:"workflow_id" gorm:"size:26;not null;index"`
+	StageID       string         `json:"stage_id" gorm:"size:26;not null;index"`
+	CustomerID    string         `json:"customer_id" gorm:"size:26;not null;index"`
+	Type          string         `json:"type" gorm:"type:varchar(20);not null;default:'customer'"` // customer, opportunity
+	Title         string         `json:"title"`
+	Value         float64        `json:"value" gorm:"default:0"`
+	CurrentStepID string         `json:"current_step_id" gorm:"size:26;not null;index"`
+	StepEnteredAt time.Time      `json:"step_entered_at"`
+	StepDueAt     *time.Time     `json:"step_due_at" gorm:"index"` // SLA step sekarang, kosong jika tanpa SLA
+	SLAState      string         `json:"sla_state,omitempty" gorm:"-"`
+	Status        string         `json:"status" gorm:"type:varchar(20);not null;default:'open';index"`
+	OwnerID       string         `json:"owner_id" gorm:"size:26"`
+	CompletedAt   *time.Time     `json:"completed_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Customer    *Customer      `json:"customer,omitempty" gorm:"foreignKey:CustomerID"`
+	Workflow    *Workflows     `json:"workflow,omitempty" gorm:"foreignKey:WorkflowID"`
+	CurrentStep *StagesDetail  `json:"current_step,omitempty" gorm:"foreignKey:CurrentStepID"`
+	Steps       []PipelineStep `json:"steps,omitempty" gorm:"foreignKey:PipelineItemID"`
+}
+
+// PipelineStep model - satu kunjungan item ke sebuah step. ExitedAt kosong
+// selama item masih di step tersebut.
+type PipelineStep struct {
+	ID             string     `json:"id" gorm:"primaryKey;size:26"`
+	PipelineItemID string     `json:"pipeline_item_id" gorm:"size:26;not null;index"`
+	StepID         string     `json:"step_id" gorm:"size:26;not null;index"`
+	StepName       string     `json:"step_name"`
+	FlowOrder      int        `json:"flow_order"`
+	Action         string     `json:"action" gorm:"type:varchar(20);not null"` // start, advance, revert
+	EnteredAt      time.Time  `json:"entered_at"`
+	EnteredBy      string     `json:"entered_by" gorm:"size:26"`
+	ExitedAt       *time.Time `json:"exited_at"`
+	ExitedBy       string     `json:"exited_by" gorm:"size:26"`
+	DueAt          *time.Time `json:"due_at" gorm:"index"`
+	BreachedAt     *time.Time `json:"breached_at"`        // saat monitor mendeteksi lewat due
+	NotifiedAt     *time.Time `json:"breach_notified_at"` // notifikasi breach terkirim
+	SLAState       string     `json:"sla_state,omitempty" gorm:"-"`
+	Notes          string     `json:"notes"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// before save generate id
+func (s *PipelineItem) BeforeCreate(tx *gorm.DB) (err error) {
+	entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+	s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+	return
+}
+
+// before save generate id
+func (s *PipelineStep) BeforeCreate(tx *gorm.DB) (err error) {
+	entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+	s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+	return
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+	"math/rand"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// RefreshToken model - refresh token yang di-rotate setiap kali dipakai.
+// Token asli tidak pernah disimpan, hanya hash SHA-256-nya.
+type RefreshToken struct {
+	ID           string     `json:"id" gorm:"primaryKey;size:26"`
+	UserID       string     `json:"user_id" gorm:"not null;index"`
+	TokenHash    string     `json:"-" gorm:"not null;unique"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ReplacedByID string     `json:"replaced_by_id"`
+	UserAgent    string     `json:"user_agent"`
+	IP           string     `json:"ip"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Relations
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// before save generate id
+func (s *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
+    entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+    s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+    return
+}
@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+	"math/rand"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// RevokedToken model - daftar jti access token yang sudah dicabut (logout).
+// Baris boleh dihapus setelah ExpiresAt karena token-nya sudah tidak berlaku.
+type RevokedToken struct {
+	ID        string    `json:"id" gorm:"primaryKey;size:26"`
+	JTI       string    `json:"jti" gorm:"not null;unique"`
+	UserID    string    `json:"user_id" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// before save generate id
+func (s *RevokedToken) BeforeCreate(tx *gorm.DB) (err error) {
+    entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+    s.ID = ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+    return
+}
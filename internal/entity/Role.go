@@ -16,7 +16,8 @@ type Role struct {
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relations
-	Users []User `json:"users,omitempty" gorm:"foreignKey:RoleID"`
+	Users       []User       `json:"users,omitempty" gorm:"foreignKey:RoleID"`
+	Permissions []Permission `json:"permissions,omitempty" gorm:"many2many:role_permissions;"`
 }
 
 
@@ -15,9 +15,16 @@ type Stages struct {
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
 	IsActive  bool           `json:"is_active" gorm:"default:true"`
+	Version   int            `json:"version" gorm:"not null;default:1"` // optimistic locking, dikirim sebagai ETag
 }
 
 
+// BeforeUpdate hook - naikkan version
+func (s *Stages) BeforeUpdate(tx *gorm.DB) error {
+	bumpVersion(tx, s.Version)
+	return nil
+}
+
 // before save generate id
 func (s *Stages) BeforeCreate(tx *gorm.DB) (err error) {
     entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
@@ -14,6 +14,7 @@ type StagesDetail struct {
 	Name       string         `json:"name" gorm:"not null;unique"`
 	Sla		int            `json:"sla" gorm:"not null"` // in hours
 	Uom 	 string         `json:"uom" gorm:"not null"` // unit of measure
+	FlowOrder int           `json:"flow_order" gorm:"not null;default:0"` // urutan step di pipeline
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
@@ -12,7 +12,7 @@ type StatusReasons struct {
 	ID        string         `json:"id" gorm:"primaryKey;size:26"`
 	CustomerID string           `json:"customer_id" gorm:"not null"`
 	Reason string         	  `json:"reason" gorm:"not null"`
-	Status     string         `json:"status" gorm:"type:varchar(20);check:status IN ('active','blocked');not null"`
+	Status     string         `json:"status" gorm:"type:varchar(20);check:status IN ('Draft','Active','Inactive','Blocked');not null"`
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 	IsActive   bool           `json:"is_active" gorm:"default:true"`
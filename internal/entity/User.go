@@ -16,6 +16,8 @@ type User struct {
 	Email     string         `json:"email" gorm:"unique;not null"`
 	Password  string         `json:"-" gorm:"not null"`
 	RoleID    string           `json:"role_id" gorm:"default:2"` // Default to regular user role
+	// Access token yang diterbitkan sebelum waktu ini dianggap dicabut (revoke semua sesi)
+	TokensValidAfter *time.Time `json:"-"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -20,11 +20,18 @@ type Workflows struct {
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
 	IsActive  bool           `json:"is_active" gorm:"default:true"`
+	Version   int            `json:"version" gorm:"not null;default:1"` // optimistic locking, dikirim sebagai ETag
 	// Relations - hilangkan dari JSON response
 	Stage Stages `json:"-" gorm:"foreignKey:StageID"`
 }
 
 
+// BeforeUpdate hook - naikkan version
+func (s *Workflows) BeforeUpdate(tx *gorm.DB) error {
+	bumpVersion(tx, s.Version)
+	return nil
+}
+
 // before save generate id
 func (s *Workflows) BeforeCreate(tx *gorm.DB) (err error) {
     entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
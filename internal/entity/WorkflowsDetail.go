@@ -18,6 +18,9 @@ type WorkflowsDetail struct {
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
 	IsActive  bool           `json:"is_active" gorm:"default:true"`
+	FlowOrder int            `json:"flow_order" gorm:"not null;default:0"` // urutan level approval
+	ApproverRoleID string    `json:"approver_role_id" gorm:"size:36"` // step disetujui user dengan role ini
+	ApproverTeamID string    `json:"approver_team_id" gorm:"size:26"` // atau oleh team lead team ini
 	Workflow Workflows `json:"-" gorm:"foreignKey:WorkflowsID"`
 }
 
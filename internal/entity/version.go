@@ -0,0 +1,16 @@
+package entity
+
+import "gorm.io/gorm"
+
+// bumpVersion increments the optimistic locking version of a record on every
+// update. A struct update (Save/Updates) carries the loaded version forward so
+// the caller sees the new value; map and single-column updates, which may hit
+// many rows, increment in SQL.
+func bumpVersion(tx *gorm.DB, current int) {
+	switch tx.Statement.Dest.(type) {
+	case map[string]interface{}, []map[string]interface{}:
+		tx.Statement.SetColumn("version", gorm.Expr("version + 1"))
+	default:
+		tx.Statement.SetColumn("version", current+1)
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"customer-api/internal/config"
 	"customer-api/internal/dto"
 	"customer-api/internal/entity"
+	"customer-api/internal/query"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Hapus fungsi helper floatPtrToFloat dan floatToFloatPtr karena tidak diperlukan lagi
@@ -23,36 +25,51 @@ func floatToFloatPtr(val float64) *float64 {
 	return &val
 }
 
+var activityListSpec = query.Spec{
+	Table: "activities",
+	Fields: map[string]query.Field{
+		"customer_id":   {},
+		"title":         {},
+		"type":          {},
+		"status":        {},
+		"location_name": {},
+		"created_by":    {},
+		"start_time":    {Type: query.Time},
+		"end_time":      {Type: query.Time},
+		"created_at":    {Type: query.Time},
+	},
+	DefaultSort: "-start_time",
+}
+
 // @Summary Get all activities
-// @Description Get list of all activities with pagination
+// @Description Get a page of activities, filterable on customer_id, title, type, status, location_name, created_by, start_time, end_time, created_at
 // @Tags Activities
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param customer_id query string false "Customer ID"
+// @Param status query string false "Status"
+// @Param type query string false "Activity type"
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 20, max 200)"
+// @Param sort query string false "Sort fields, prefix - for descending"
+// @Param cursor query string false "Cursor pagination on id"
 // @Success 200 {object} dto.ActivitiesResponse
+// @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/activities [get]
 func GetActivities(c *gin.Context) {
-	var activities []entity.Activity
-	customerID := c.Query("customer_id")
-	status := c.Query("status")
-	activityType := c.Query("type")
-
-	db := config.DB.Preload("Customer").Preload("Creator")
-
-	if customerID != "" {
-		db = db.Where("customer_id = ?", customerID)
-	}
-	if status != "" {
-		db = db.Where("status = ?", status)
-	}
-	if activityType != "" {
-		db = db.Where("type = ?", activityType)
+	params, ok := parseList(c, activityListSpec)
+	if !ok {
+		return
 	}
 
-	result := db.Find(&activities)
-	if result.Error != nil {
+	var activities []entity.Activity
+	page, err := activityListSpec.Find(config.DB, params, &activities, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Customer").Preload("Creator")
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activities"})
 		return
 	}
@@ -76,13 +93,16 @@ func GetActivities(c *gin.Context) {
 		})
 	}
 
-	// Get total count
+	// Total mengikuti filter; pada mode cursor total tidak dihitung
 	var total int64
-	config.DB.Model(&entity.Activity{}).Count(&total)
+	if page.Total != nil {
+		total = *page.Total
+	}
 
 	response := dto.ActivitiesResponse{
 		Activities: activityResponses,
 		Total:      total,
+		Pagination: page,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -129,7 +149,7 @@ func CreateActivity(c *gin.Context) {
 
 	// Verify customer exists
 	var customer entity.Customer
-	if err := config.DB.First(&customer, req.CustomerID).Error; err != nil {
+	if err := config.DB.Where("id = ?", req.CustomerID).First(&customer).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Customer not found"})
 		return
 	}
@@ -146,7 +166,7 @@ func CreateActivity(c *gin.Context) {
 		CreatedBy:    userID.(uint),
 	}
 
-	result := config.DB.Create(&activity)
+	result := config.DB.WithContext(c).Create(&activity)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create activity"})
 		return
@@ -286,7 +306,7 @@ func UpdateActivityByCustomer(c *gin.Context) {
 		activity.Status = *req.Status
 	}
 
-	result = config.DB.Save(&activity)
+	result = config.DB.WithContext(c).Save(&activity)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update activity"})
 		return
@@ -335,7 +355,7 @@ func DeleteActivity(c *gin.Context) {
 		return
 	}
 
-	result := config.DB.Delete(&activity)
+	result := config.DB.WithContext(c).Delete(&activity)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete activity"})
 		return
@@ -398,7 +418,7 @@ func AddActivityAttendees(c *gin.Context) {
 			UserID:     userID,
 		}
 		// Use FirstOrCreate to avoid duplicates
-		config.DB.FirstOrCreate(&attendee, entity.ActivityAttendee{
+		config.DB.WithContext(c).FirstOrCreate(&attendee, entity.ActivityAttendee{
 			ActivityID: uint(activityID),
 			UserID:     userID,
 		})
@@ -436,7 +456,7 @@ func RemoveActivityAttendees(c *gin.Context) {
 	}
 
 	// Remove attendees
-	result := config.DB.Where("activity_id = ? AND user_id IN ?", activityID, req.UserIDs).Delete(&entity.ActivityAttendee{})
+	result := config.DB.WithContext(c).Where("activity_id = ? AND user_id IN ?", activityID, req.UserIDs).Delete(&entity.ActivityAttendee{})
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove attendees"})
 		return
@@ -504,7 +524,7 @@ func CheckinActivity(c *gin.Context) {
 		CheckedInAt: time.Now(),
 	}
 
-	result := config.DB.Create(&checkin)
+	result := config.DB.WithContext(c).Create(&checkin)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check-in"})
 		return
@@ -577,7 +597,7 @@ func UpdateActivity(c *gin.Context) {
 		activity.Status = *req.Status
 	}
 
-	result = config.DB.Save(&activity)
+	result = config.DB.WithContext(c).Save(&activity)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update activity"})
 		return
@@ -6,6 +6,7 @@ import (
 
 	"customer-api/internal/config"
 	"customer-api/internal/entity"
+	"customer-api/internal/query"
 	"github.com/gin-gonic/gin"
 
 	"customer-api/internal/dto"
@@ -13,69 +14,58 @@ import (
 
 
 
+var activityTypeListSpec = query.Spec{
+	Table: "activity_types",
+	Fields: map[string]query.Field{
+		"name": {},
+	},
+	DefaultSort:     "name",
+	DefaultPageSize: 10,
+}
+
 // @Summary Get all Activitiy Types
 // @Description Get list of all activity types
 // @Tags Activity Types
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param limit query int false "Limit"
+// @Param limit query int false "Page size (alias of page_size)"
 // @Param page query int false "Page"
+// @Param page_size query int false "Page size (default 10, max 200)"
+// @Param sort query string false "Sort fields, prefix - for descending"
+// @Param name query string false "Filter by name, name[like]= for partial match"
 // @Success 200 {array} entity.ActivityType
+// @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/activity-types [get]
 func ReadActivityTypes(c *gin.Context) {
+	params, ok := parseList(c, activityTypeListSpec)
+	if !ok {
+		return
+	}
 
-		db := config.DB
-		limit, _ := strconv.Atoi(c.Query("limit"))
-		page, _ := strconv.Atoi(c.Query("page"))
-		if limit == 0 {
-			limit = 10 // Default limit
-		}
-
-		if page == 0 {
-			page = 1 // Default page
-		}
-		var activityTypes []entity.ActivityType
-		offset := (page - 1) * limit
-		if result := db.Limit(limit).Offset(offset).Find(&activityTypes); result.Error != nil {
-			c.JSON(http.StatusNotFound, dto.Response{
-				Status:  http.StatusNotFound,
-				Message: "No activity types found",
-				Data:    []entity.ActivityType{},
-			})
-			return
-		}
-
-		if len(activityTypes) == 0 {
-			c.JSON(http.StatusOK, dto.Response{
-				Status:  http.StatusOK,
-				Message: "No activity types found",
-				Data:    activityTypes,
-			})
-			return
-		}
-
-		
-		if result := db.Limit(limit).Offset(offset).Find(&activityTypes); result.Error != nil {
-			c.JSON(http.StatusInternalServerError, dto.Response{
-				Status:  http.StatusInternalServerError,
-				Message: "Failed to retrieve activity types",
-				Data:    []entity.ActivityType{},
-			})
-			return
-		}
-		
-
-		c.JSON(http.StatusOK, dto.Response{
-			Status:  http.StatusOK,
-			Message: "Activity types found",
-			Data:    activityTypes,
+	var activityTypes []entity.ActivityType
+	page, err := activityTypeListSpec.Find(config.DB, params, &activityTypes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to retrieve activity types",
+			Data:    []entity.ActivityType{},
 		})
+		return
+	}
 
-	
-
+	message := "Activity types found"
+	if len(activityTypes) == 0 {
+		message = "No activity types found"
+	}
+	c.JSON(http.StatusOK, dto.Response{
+		Status:     http.StatusOK,
+		Message:    message,
+		Data:       activityTypes,
+		Pagination: &page,
+	})
 }
 
 
@@ -102,7 +92,7 @@ func CreateActivityType(c *gin.Context) {
 			})
 			return
 		}
-		db := config.DB
+		db := config.DB.WithContext(c)
 		if result := db.Create(&activityType); result.Error != nil {
 			c.JSON(http.StatusInternalServerError, dto.Response{
 				Status:  http.StatusInternalServerError,
@@ -157,7 +147,7 @@ func UpdateActivityType(c *gin.Context) {
 		return
 	}
 
-	db := config.DB
+	db := config.DB.WithContext(c)
 
 	// Pastikan record ada
 	var activityType entity.ActivityType
@@ -212,7 +202,7 @@ func DeleteActivityType(c *gin.Context) {
 	}
 
 
-	db := config.DB
+	db := config.DB.WithContext(c)
 	if result := db.Delete(&entity.ActivityType{ID: id}); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Status:  http.StatusInternalServerError,
@@ -244,7 +234,7 @@ func DeleteActivityType(c *gin.Context) {
 func ReadActivityType(c *gin.Context) {
 	
 		id, _ := strconv.Atoi(c.Param("id"))
-		db := config.DB
+		db := config.DB.WithContext(c)
 		var activityType entity.ActivityType
 		if result := db.First(&activityType, id); result.Error != nil {
 			c.JSON(http.StatusNotFound, dto.Response{
@@ -286,7 +276,7 @@ func ReadActivitiesByActivityType(c *gin.Context) {
 		if page == 0 {
 			page = 1 // Default page
 		}
-		db := config.DB
+		db := config.DB.WithContext(c)
 		var activities []entity.Activity
 		offset := (page - 1) * limit
 		if result := db.Limit(limit).Offset(offset).Find(&activities, "activity_type_id = ?", id); result.Error != nil {
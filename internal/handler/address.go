@@ -4,10 +4,11 @@ import (
 	"customer-api/internal/config"
 	"customer-api/internal/dto"
 	"customer-api/internal/entity"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	// Hapus import "gorm.io/gorm" karena tidak digunakan
+	"gorm.io/gorm"
 )
 
 // @Summary Create address for customer
@@ -41,20 +42,32 @@ func CreateAddress(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.CustomerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "customer_id is required"})
+		return
+	}
 
-	// Hapus variabel customerID yang tidak digunakan
-	// customerID := c.Param("id") // Jika dari endpoint /customers/{id}/addresses
+	var customer entity.Customer
+	if err := config.DB.Where("id = ?", req.CustomerID).First(&customer).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
 
 	address := entity.Address{
-		// CustomerID: customer.ID, // Akan diset sesuai kebutuhan
-		Name:    req.Name,
-		Address: req.Address,
-		Main:    req.IsMain,
-		Active:  req.Active,
+		CustomerID: customer.ID,
+		Name:       req.Name,
+		Address:    req.Address,
+		Main:       req.IsMain,
+		Active:     req.Active,
 	}
 
-	result := config.DB.Create(&address)
-	if result.Error != nil {
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&address).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, c.GetString("user_id"), "", nil, &address)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create address"})
 		return
 	}
@@ -94,6 +107,7 @@ func GetCustomerAddresses(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path int true "Address ID"
 // @Success 200 {object} entity.Address
+// @Header 200 {string} ETag "Version of the address, send it as If-Match on update/delete"
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
 // @Router /api/addresses/{id} [get]
@@ -101,12 +115,13 @@ func GetAddress(c *gin.Context) {
 	id := c.Param("id")
 
 	var address entity.Address
-	result := config.DB.First(&address, id)
+	result := config.DB.Where("id = ?", id).First(&address)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
 		return
 	}
 
+	setETag(c, &address)
 	c.JSON(http.StatusOK, address)
 }
 
@@ -117,38 +132,90 @@ func GetAddress(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Address ID"
+// @Param If-Match header string true "ETag from the last GET"
 // @Param address body entity.Address true "Address data"
 // @Success 200 {object} entity.Address
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse "Modified by someone else, data holds the current address"
+// @Failure 428 {object} dto.ErrorResponse
 // @Router /api/addresses/{id} [put]
 func UpdateAddress(c *gin.Context) {
 	id := c.Param("id")
+	userID := c.GetString("user_id")
 
 	var address entity.Address
-	result := config.DB.First(&address, id)
+	result := config.DB.Where("id = ?", id).First(&address)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
 		return
 	}
+	if !checkIfMatch(c, &address) {
+		return
+	}
+	before := address
 
 	var updateData entity.Address
 	if err := c.ShouldBindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	updateData.ID = ""
+	updateData.CreatedAt = address.CreatedAt
 
-	// If this is set as main address, set all other addresses to false
-	if updateData.Main {
-		config.DB.Model(&entity.Address{}).Where("customer_id = ? AND id != ?", address.CustomerID, address.ID).Update("main", false)
-	}
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := lockVersion(tx, &entity.Address{}, address.ID, before.Version); err != nil {
+			return err
+		}
 
-	// Update the address
-	config.DB.Model(&address).Updates(updateData)
+		// If this is set as main address, set all other addresses to false
+		if updateData.Main {
+			if err := demoteMainAddresses(tx, userID, &address); err != nil {
+				return err
+			}
+		}
+
+		// Update the address
+		if err := tx.Model(&address).Updates(updateData).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", address.ID).First(&address).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, userID, "", &before, &address)
+	})
+	if errors.Is(err, errVersionConflict) {
+		sendVersionConflict(c, &entity.Address{}, id)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update address"})
+		return
+	}
+	setETag(c, &address)
 	c.JSON(http.StatusOK, address)
 }
 
+// demoteMainAddresses clears the main flag of the customer's other addresses
+// so address stays the only main one
+func demoteMainAddresses(tx *gorm.DB, userID string, address *entity.Address) error {
+	var mains []entity.Address
+	if err := tx.Where("customer_id = ? AND id != ? AND main = ?", address.CustomerID, address.ID, true).Find(&mains).Error; err != nil {
+		return err
+	}
+	for i := range mains {
+		previous := mains[i]
+		if err := tx.Model(&mains[i]).Update("main", false).Error; err != nil {
+			return err
+		}
+		if err := recordCustomerChange(tx, userID, "", &previous, &mains[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // @Summary Delete address
 // @Description Delete an address by ID
 // @Tags Addresses
@@ -156,21 +223,40 @@ func UpdateAddress(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Address ID"
+// @Param If-Match header string true "ETag from the last GET"
 // @Success 200 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse "Modified by someone else, data holds the current address"
+// @Failure 428 {object} dto.ErrorResponse
 // @Router /api/addresses/{id} [delete]
 func DeleteAddress(c *gin.Context) {
 	id := c.Param("id")
 
-	result := config.DB.Delete(&entity.Address{}, id)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete address"})
+	var address entity.Address
+	if err := config.DB.Where("id = ?", id).First(&address).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
+		return
+	}
+	if !checkIfMatch(c, &address) {
 		return
 	}
 
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := lockVersion(tx, &entity.Address{}, address.ID, address.Version); err != nil {
+			return err
+		}
+		if err := tx.Delete(&address).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, c.GetString("user_id"), "", &address, nil)
+	})
+	if errors.Is(err, errVersionConflict) {
+		sendVersionConflict(c, &entity.Address{}, id)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete address"})
 		return
 	}
 
@@ -0,0 +1,720 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+	"customer-api/internal/query"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Approval berjenjang: aksi sensitif ditahan sebagai ApprovalRequest bila ada
+// workflow aktif dengan type = nama aksi yang thresholdnya mencakup nilai aksi
+// (outstanding invoice customer, amount invoice). Setiap WorkflowsDetail
+// workflow itu adalah satu level, diurutkan flow_order, dan disetujui oleh
+// role atau team lead yang ditunjuk. Perubahan baru diterapkan setelah level
+// terakhir setuju. Tanpa workflow yang cocok aksi langsung dijalankan.
+
+// approvalAction is an action that can be held for approval
+type approvalAction struct {
+	entityType string
+	// apply menerapkan payload setelah level terakhir setuju, approverID
+	// adalah approver terakhir
+	apply func(tx *gorm.DB, request *entity.ApprovalRequest, approverID string) error
+	// close dipanggil saat permintaan ditolak, dibatalkan atau gagal (opsional)
+	close func(tx *gorm.DB, request *entity.ApprovalRequest, userID, note string) error
+}
+
+var approvalActions = map[string]approvalAction{
+	entity.ApprovalCustomerBlock:  {entityType: "customer_status_changes", apply: applyCustomerBlock, close: closeCustomerBlock},
+	entity.ApprovalCustomerDelete: {entityType: "customers", apply: applyCustomerDelete},
+	entity.ApprovalInvoiceCreate:  {entityType: "invoices", apply: applyInvoiceCreate},
+}
+
+var (
+	errApprovalClosed      = errors.New("approval request is no longer pending")
+	errApprovalSelf        = errors.New("requester cannot approve their own request")
+	errApprovalDecided     = errors.New("user already decided a level of this request")
+	errApprovalNotApprover = errors.New("user is not an approver of the current level")
+	errApprovalNotOwner    = errors.New("only the requester can cancel")
+	// errApprovalStale menandai perubahan yang tidak bisa lagi diterapkan,
+	// permintaannya ditutup sebagai failed
+	errApprovalStale = errors.New("the change can no longer be applied")
+	// errApprovalPending: request lain untuk data yang sama sudah pending,
+	// dijaga unique index uni_approval_requests_pending
+	errApprovalPending = errors.New("another approval request for the entity is pending")
+)
+
+var approvalListSpec = query.Spec{
+	Table: "approval_requests",
+	Fields: map[string]query.Field{
+		"action":          {},
+		"status":          {},
+		"entity_type":     {},
+		"entity_id":       {},
+		"customer_id":     {},
+		"workflow_id":     {},
+		"current_step_id": {},
+		"requested_by":    {},
+		"value":           {Type: query.Number},
+		"step_due_at":     {Type: query.Time},
+		"created_at":      {Type: query.Time},
+	},
+	DefaultSort: "-created_at",
+}
+
+// validateApprover checks the approver of a step of a workflow of
+// workflowType, returns the error message or "" when valid. Steps of an
+// approval workflow must name exactly one approver.
+func validateApprover(db *gorm.DB, workflowType, roleID, teamID string) string {
+	if roleID != "" && teamID != "" {
+		return "Pilih salah satu approver_role_id atau approver_team_id"
+	}
+	if _, ok := approvalActions[workflowType]; ok && roleID == "" && teamID == "" {
+		return "Level approval wajib punya approver_role_id atau approver_team_id"
+	}
+	if roleID != "" {
+		var count int64
+		if db.Model(&entity.Role{}).Where("id = ?", roleID).Count(&count); count == 0 {
+			return "Role approver tidak ditemukan"
+		}
+	}
+	if teamID != "" && teamLead(db, teamID) == "" {
+		return "Team approver tidak ditemukan"
+	}
+	return ""
+}
+
+// teamLead returns the user leading the team, "" when the team is unknown
+func teamLead(db *gorm.DB, teamID string) string {
+	var leads []string
+	db.Table("teams").Where("id = ? AND deleted_at IS NULL", teamID).Pluck("team_lead", &leads)
+	if len(leads) == 0 {
+		return ""
+	}
+	return leads[0]
+}
+
+// canApprove reports whether userID is an approver of step
+func canApprove(db *gorm.DB, step entity.WorkflowsDetail, userID string) bool {
+	switch {
+	case step.ApproverRoleID != "":
+		var user entity.User
+		if err := db.Select("id", "role_id").Where("id = ?", userID).First(&user).Error; err != nil {
+			return false
+		}
+		return user.RoleID == step.ApproverRoleID
+	case step.ApproverTeamID != "":
+		return teamLead(db, step.ApproverTeamID) == userID
+	}
+	// Level tanpa approver (dibuat sebelum approver diwajibkan) tidak bisa
+	// diputuskan siapa pun sampai approvernya diisi
+	return false
+}
+
+// approvalSteps returns the active levels of a workflow in approval order
+func approvalSteps(db *gorm.DB, workflowID string) ([]entity.WorkflowsDetail, error) {
+	var steps []entity.WorkflowsDetail
+	err := db.Where("workflows_id = ? AND is_active = ?", workflowID, true).
+		Order("flow_order, created_at").Find(&steps).Error
+	return steps, err
+}
+
+// enterApprovalLevel moves request to step, level counts from 1
+func enterApprovalLevel(db *gorm.DB, request *entity.ApprovalRequest, step entity.WorkflowsDetail, level int, now time.Time) {
+	request.CurrentStepID = step.ID
+	request.CurrentLevel = level
+	request.StepDueAt = slaCalendar(db).Due(now, step.Sla, step.Uom)
+}
+
+// requestApproval holds request.Action for approval when a workflow of that
+// type covers request.Value. The caller fills Action, EntityID, CustomerID,
+// Summary, Value and RequestedBy. Returns false when no approval is needed,
+// errApprovalPending when the entity already has a pending request.
+func requestApproval(db *gorm.DB, request *entity.ApprovalRequest, payload interface{}) (bool, error) {
+	workflow, _, err := resolveWorkflow(db, request.Action, "", request.Value)
+	if errors.Is(err, errNoWorkflowMatch) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	steps, err := approvalSteps(db, workflow.ID)
+	if err != nil || len(steps) == 0 {
+		return false, err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return false, err
+	}
+	request.WorkflowID = workflow.ID
+	request.EntityType = approvalActions[request.Action].entityType
+	request.Payload = string(body)
+	request.Status = entity.ApprovalPending
+	request.Levels = len(steps)
+	enterApprovalLevel(db, request, steps[0], 1, time.Now())
+	if err := db.Create(request).Error; err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "uni_approval_requests_pending" {
+			return false, errApprovalPending
+		}
+		return false, err
+	}
+	request.CurrentStep = &steps[0]
+	return true, nil
+}
+
+// sendApprovalRequired responds 202 for an action held by requestApproval
+func sendApprovalRequired(c *gin.Context, request *entity.ApprovalRequest) {
+	c.JSON(http.StatusAccepted, gin.H{
+		"status": "success",
+		"message": fmt.Sprintf("Approval required: %d level(s), waiting for %s (approval request %s)",
+			request.Levels, request.CurrentStep.Name, request.ID),
+		"data": request,
+	})
+}
+
+// pendingApprovalID returns the pending request of action for entityID, "" when none
+func pendingApprovalID(db *gorm.DB, action, entityID string) string {
+	var ids []string
+	db.Model(&entity.ApprovalRequest{}).
+		Where("action = ? AND entity_id = ? AND status = ?", action, entityID, entity.ApprovalPending).
+		Limit(1).Pluck("id", &ids)
+	if len(ids) == 0 {
+		return ""
+	}
+	return ids[0]
+}
+
+// customerOutstanding is the unpaid invoice total of a customer, the value
+// that routes customer approvals
+func customerOutstanding(db *gorm.DB, customerID string) float64 {
+	var total float64
+	db.Model(&entity.Invoice{}).Where("customer_id = ?", customerID).
+		Select("COALESCE(SUM(amount - paid_amount), 0)").Row().Scan(&total)
+	return total
+}
+
+// applyCustomerBlock approves the pending status change held by the request;
+// it is applied now or scheduled for its effective_at
+func applyCustomerBlock(tx *gorm.DB, request *entity.ApprovalRequest, approverID string) error {
+	var change entity.CustomerStatusChange
+	err := lockOpenStatusChange(tx, request.CustomerID, request.EntityID, &change, entity.StatusChangePending)
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, errStatusChangeClosed) {
+		return fmt.Errorf("%w: status change is no longer pending", errApprovalStale)
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	change.DecidedBy = approverID
+	change.DecidedAt = &now
+	change.DecisionNote = "Approved through approval request " + request.ID
+	if change.EffectiveAt != nil && change.EffectiveAt.After(now) {
+		change.State = entity.StatusChangeScheduled
+		return tx.Save(&change).Error
+	}
+	if err := applyStatusChange(tx, &change); errors.Is(err, errStatusChanged) {
+		return fmt.Errorf("%w: %v", errApprovalStale, err)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// closeCustomerBlock closes the status change together with the request
+func closeCustomerBlock(tx *gorm.DB, request *entity.ApprovalRequest, userID, note string) error {
+	state := entity.StatusChangeRejected
+	switch request.Status {
+	case entity.ApprovalCancelled:
+		state = entity.StatusChangeCancelled
+	case entity.ApprovalFailed:
+		state = entity.StatusChangeFailed
+	}
+	now := time.Now()
+	return tx.Model(&entity.CustomerStatusChange{}).
+		Where("id = ? AND state = ?", request.EntityID, entity.StatusChangePending).
+		Updates(map[string]interface{}{
+			"state":         state,
+			"decided_by":    userID,
+			"decided_at":    now,
+			"decision_note": note,
+			"error":         request.Error,
+		}).Error
+}
+
+// customerDeletePayload is the payload of a customer_delete request. A merge
+// deletes the duplicate as well, MergeInto then names the survivor.
+type customerDeletePayload struct {
+	Version         int    `json:"version"`
+	MergeInto       string `json:"merge_into,omitempty"`
+	MergeVersion    int    `json:"merge_version,omitempty"`
+	FillEmptyFields bool   `json:"fill_empty_fields,omitempty"`
+}
+
+// applyCustomerDelete deletes (or merges) the customer on behalf of the
+// requester, as long as it was not modified since the request
+func applyCustomerDelete(tx *gorm.DB, request *entity.ApprovalRequest, _ string) error {
+	var payload customerDeletePayload
+	if err := json.Unmarshal([]byte(request.Payload), &payload); err != nil {
+		return err
+	}
+	if payload.MergeInto != "" {
+		var survivor entity.Customer
+		_, err := mergeCustomers(tx, request.RequestedBy, request.EntityID, request.ID, payload, &survivor)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return fmt.Errorf("%w: customer not found", errApprovalStale)
+		case errors.Is(err, errVersionConflict):
+			return fmt.Errorf("%w: customers were modified after the request", errApprovalStale)
+		}
+		return err
+	}
+	var customer entity.Customer
+	if err := tx.Where("id = ?", request.EntityID).First(&customer).Error; err != nil {
+		return fmt.Errorf("%w: customer not found", errApprovalStale)
+	}
+	err := softDeleteCustomer(tx, request.RequestedBy, &customer, payload.Version)
+	if errors.Is(err, errVersionConflict) {
+		return fmt.Errorf("%w: customer was modified after the request", errApprovalStale)
+	}
+	return err
+}
+
+// applyInvoiceCreate creates the invoice of the request and links it. A
+// request that already has an entity raises the amount of that invoice.
+func applyInvoiceCreate(tx *gorm.DB, request *entity.ApprovalRequest, _ string) error {
+	if request.EntityID != "" {
+		return applyInvoiceIncrease(tx, request)
+	}
+	var req dto.CreateInvoiceRequest
+	if err := json.Unmarshal([]byte(request.Payload), &req); err != nil {
+		return err
+	}
+	if !templateExists(req.TemplateID) {
+		return fmt.Errorf("%w: invoice template not found", errApprovalStale)
+	}
+	var customer entity.Customer
+	if err := tx.Where("id = ?", req.CustomerID).First(&customer).Error; err != nil {
+		return fmt.Errorf("%w: customer not found", errApprovalStale)
+	}
+	invoice := buildInvoice(req, customer.ID)
+	if err := insertInvoice(tx, &invoice); err != nil {
+		return err
+	}
+	request.EntityID = invoice.ID
+	return nil
+}
+
+// applyInvoiceIncrease re-applies a held invoice update, as long as the
+// result does not exceed the approved amount
+func applyInvoiceIncrease(tx *gorm.DB, request *entity.ApprovalRequest) error {
+	var req dto.UpdateInvoiceRequest
+	if err := json.Unmarshal([]byte(request.Payload), &req); err != nil {
+		return err
+	}
+	if req.TemplateID != nil && !templateExists(req.TemplateID) {
+		return fmt.Errorf("%w: invoice template not found", errApprovalStale)
+	}
+	var invoice entity.Invoice
+	var rejected errInvoiceRejected
+	_, err := lockInvoiceUpdate(tx, request.EntityID, req, &invoice)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("%w: invoice not found", errApprovalStale)
+	case errors.As(err, &rejected):
+		return fmt.Errorf("%w: %s", errApprovalStale, rejected.message)
+	case err != nil:
+		return err
+	}
+	if invoice.Amount > request.Value {
+		return fmt.Errorf("%w: invoice amount would exceed the approved %.2f", errApprovalStale, request.Value)
+	}
+	return saveInvoiceUpdate(tx, &invoice, req.Items != nil)
+}
+
+// lockApproval loads a pending request for update
+func lockApproval(tx *gorm.DB, id string, request *entity.ApprovalRequest) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(request).Error; err != nil {
+		return err
+	}
+	if request.Status != entity.ApprovalPending {
+		return errApprovalClosed
+	}
+	return nil
+}
+
+// closeApproval sets the final status and runs the close hook of the action
+func closeApproval(tx *gorm.DB, request *entity.ApprovalRequest, status, userID, note string) error {
+	now := time.Now()
+	request.Status = status
+	request.DecidedAt = &now
+	request.StepDueAt = nil
+	if hook := approvalActions[request.Action].close; hook != nil {
+		if err := hook(tx, request, userID, note); err != nil {
+			return err
+		}
+	}
+	return tx.Omit(clause.Associations).Save(request).Error
+}
+
+// markApprovalFailed records the final approval of a request whose change
+// could not be applied and closes it as failed
+func markApprovalFailed(db *gorm.DB, id string, action entity.ApprovalAction, cause error) {
+	db.Transaction(func(tx *gorm.DB) error {
+		var request entity.ApprovalRequest
+		if err := lockApproval(tx, id, &request); err != nil {
+			return err
+		}
+		if err := tx.Create(&action).Error; err != nil {
+			return err
+		}
+		request.Error = cause.Error()
+		return closeApproval(tx, &request, entity.ApprovalFailed, action.UserID, action.Note)
+	})
+}
+
+// @Summary Get approval requests
+// @Description Page of approval requests. assigned=me lists the pending requests the current user can decide now.
+// @Tags Approvals
+// @Produce json
+// @Security BearerAuth
+// @Param assigned query string false "me: waiting for the current user"
+// @Param action query string false "Filter by action" Enums(customer_block, customer_delete, invoice_create)
+// @Param status query string false "Filter by status" Enums(pending, approved, rejected, cancelled, failed)
+// @Param customer_id query string false "Filter by customer"
+// @Param requested_by query string false "Filter by requester"
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 20, max 200)"
+// @Param sort query string false "Sort, e.g. step_due_at"
+// @Success 200 {array} entity.ApprovalRequest
+// @Failure 400 {object} dto.ErrorResponse
+// @Router /api/approvals [get]
+func GetApprovalRequests(c *gin.Context) {
+	params, ok := parseList(c, approvalListSpec)
+	if !ok {
+		return
+	}
+	db := config.DB.WithContext(c)
+	if c.Query("assigned") == "me" {
+		db = whereAssignedTo(db, c.GetString("user_id"))
+	}
+
+	var requests []entity.ApprovalRequest
+	page, err := approvalListSpec.Find(db, params, &requests, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("CurrentStep")
+	})
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch approval requests")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"message":    "Approval requests fetched successfully",
+		"data":       requests,
+		"pagination": page,
+	})
+}
+
+// whereAssignedTo keeps the pending requests userID can decide: the current
+// level is assigned to their role, a team they lead or nobody, they did not
+// request it and did not decide an earlier level
+func whereAssignedTo(db *gorm.DB, userID string) *gorm.DB {
+	var user entity.User
+	config.DB.Select("id", "role_id").Where("id = ?", userID).First(&user)
+	var teams []string
+	config.DB.Table("teams").Where("team_lead = ? AND deleted_at IS NULL", userID).Pluck("id", &teams)
+
+	// Level tanpa approver tidak ditugaskan ke siapa pun, lihat canApprove
+	conds := []string{"1 = 0"}
+	var args []interface{}
+	if user.RoleID != "" {
+		conds = append(conds, "approver_role_id = ?")
+		args = append(args, user.RoleID)
+	}
+	if len(teams) > 0 {
+		conds = append(conds, "approver_team_id IN ?")
+		args = append(args, teams)
+	}
+	steps := config.DB.Model(&entity.WorkflowsDetail{}).Select("id").Where("("+strings.Join(conds, " OR ")+")", args...)
+
+	return db.Where("approval_requests.status = ? AND approval_requests.requested_by <> ?", entity.ApprovalPending, userID).
+		Where("approval_requests.current_step_id IN (?)", steps).
+		Where("NOT EXISTS (SELECT 1 FROM approval_actions a WHERE a.approval_request_id = approval_requests.id AND a.user_id = ?)", userID)
+}
+
+// @Summary Get approval request
+// @Description Approval request with its payload and the full history of decisions, oldest first
+// @Tags Approvals
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Approval request ID"
+// @Success 200 {object} entity.ApprovalRequest
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/approvals/{id} [get]
+func GetApprovalRequest(c *gin.Context) {
+	var request entity.ApprovalRequest
+	err := config.DB.Preload("CurrentStep").
+		Preload("Actions", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Where("id = ?", c.Param("id")).First(&request).Error
+	if err != nil {
+		sendError(c, http.StatusNotFound, "Approval request not found")
+		return
+	}
+	steps, _ := approvalSteps(config.DB, request.WorkflowID)
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Approval request fetched successfully",
+		"data": gin.H{
+			"request": request,
+			"steps":   steps,
+		},
+	})
+}
+
+// decideApproval records the decision of the current level. Approving the
+// last level applies the change in the same transaction.
+func decideApproval(c *gin.Context, approve bool) {
+	userID := c.GetString("user_id")
+	var req dto.ApprovalDecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			sendError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	note := strings.TrimSpace(req.Note)
+	if !approve && note == "" {
+		sendError(c, http.StatusBadRequest, "Note is required to reject an approval request")
+		return
+	}
+
+	var request entity.ApprovalRequest
+	var action entity.ApprovalAction
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := lockApproval(tx, c.Param("id"), &request); err != nil {
+			return err
+		}
+		if request.RequestedBy == userID {
+			return errApprovalSelf
+		}
+		var decided int64
+		tx.Model(&entity.ApprovalAction{}).Where("approval_request_id = ? AND user_id = ?", request.ID, userID).Count(&decided)
+		if decided > 0 {
+			return errApprovalDecided
+		}
+		var step entity.WorkflowsDetail
+		if err := tx.Unscoped().Where("id = ?", request.CurrentStepID).First(&step).Error; err != nil {
+			return err
+		}
+		if !canApprove(tx, step, userID) {
+			return errApprovalNotApprover
+		}
+
+		action = entity.ApprovalAction{
+			ApprovalRequestID: request.ID,
+			StepID:            step.ID,
+			StepName:          step.Name,
+			Level:             request.CurrentLevel,
+			UserID:            userID,
+			Decision:          entity.ApprovalDecisionApprove,
+			Note:              note,
+		}
+		if !approve {
+			action.Decision = entity.ApprovalDecisionReject
+		}
+		if err := tx.Create(&action).Error; err != nil {
+			return err
+		}
+		if !approve {
+			return closeApproval(tx, &request, entity.ApprovalRejected, userID, note)
+		}
+
+		steps, err := approvalSteps(tx, request.WorkflowID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if next := nextApprovalStep(steps, step); next != nil {
+			enterApprovalLevel(tx, &request, *next, request.CurrentLevel+1, now)
+			if request.CurrentLevel > request.Levels {
+				request.Levels = request.CurrentLevel
+			}
+			return tx.Omit(clause.Associations).Save(&request).Error
+		}
+		if err := approvalActions[request.Action].apply(tx, &request, userID); err != nil {
+			return err
+		}
+		request.AppliedAt = &now
+		return closeApproval(tx, &request, entity.ApprovalApproved, userID, note)
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		sendError(c, http.StatusNotFound, "Approval request not found")
+		return
+	case errors.Is(err, errApprovalClosed):
+		sendError(c, http.StatusConflict, "Approval request is already "+request.Status)
+		return
+	case errors.Is(err, errApprovalSelf):
+		sendError(c, http.StatusForbidden, "An approval request must be decided by another user")
+		return
+	case errors.Is(err, errApprovalDecided):
+		sendError(c, http.StatusForbidden, "You already decided a level of this approval request")
+		return
+	case errors.Is(err, errApprovalNotApprover):
+		sendError(c, http.StatusForbidden, "You are not an approver of the current level")
+		return
+	case errors.Is(err, errApprovalStale):
+		markApprovalFailed(config.DB.WithContext(c), request.ID, action, err)
+		sendError(c, http.StatusConflict, "Approved, but the change could not be applied: "+err.Error())
+		return
+	case err != nil:
+		sendError(c, http.StatusInternalServerError, "Failed to update approval request")
+		return
+	}
+
+	message := "Approval request rejected"
+	switch {
+	case request.Status == entity.ApprovalApproved:
+		message = "Approval request approved and the change applied"
+	case approve:
+		message = fmt.Sprintf("Level %d approved, waiting for level %d of %d", action.Level, request.CurrentLevel, request.Levels)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": message,
+		"data":    request,
+	})
+}
+
+// nextApprovalStep returns the level after current, nil after the last one.
+// A level that was deactivated while current continues with the next order.
+func nextApprovalStep(steps []entity.WorkflowsDetail, current entity.WorkflowsDetail) *entity.WorkflowsDetail {
+	for i, step := range steps {
+		if step.ID == current.ID {
+			if i+1 < len(steps) {
+				return &steps[i+1]
+			}
+			return nil
+		}
+	}
+	for i, step := range steps {
+		if step.FlowOrder > current.FlowOrder {
+			return &steps[i]
+		}
+	}
+	return nil
+}
+
+// @Summary Approve approval request
+// @Description Approve the current level. The change is applied when the last level approves.
+// @Description The requester cannot approve and one user decides at most one level of a request.
+// @Tags Approvals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Approval request ID"
+// @Param request body dto.ApprovalDecisionRequest false "Optional note"
+// @Success 200 {object} entity.ApprovalRequest
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /api/approvals/{id}/approve [post]
+func ApproveApprovalRequest(c *gin.Context) {
+	decideApproval(c, true)
+}
+
+// @Summary Reject approval request
+// @Description Reject the request at the current level, the change is discarded
+// @Tags Approvals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Approval request ID"
+// @Param request body dto.ApprovalDecisionRequest true "Reason of the rejection"
+// @Success 200 {object} entity.ApprovalRequest
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /api/approvals/{id}/reject [post]
+func RejectApprovalRequest(c *gin.Context) {
+	decideApproval(c, false)
+}
+
+// @Summary Cancel approval request
+// @Description Withdraw a pending request, only by the requester
+// @Tags Approvals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Approval request ID"
+// @Param request body dto.ApprovalDecisionRequest false "Optional note"
+// @Success 200 {object} entity.ApprovalRequest
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /api/approvals/{id}/cancel [post]
+func CancelApprovalRequest(c *gin.Context) {
+	userID := c.GetString("user_id")
+	var req dto.ApprovalDecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			sendError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	var request entity.ApprovalRequest
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := lockApproval(tx, c.Param("id"), &request); err != nil {
+			return err
+		}
+		if request.RequestedBy != userID {
+			return errApprovalNotOwner
+		}
+		action := entity.ApprovalAction{
+			ApprovalRequestID: request.ID,
+			StepID:            request.CurrentStepID,
+			Level:             request.CurrentLevel,
+			UserID:            userID,
+			Decision:          entity.ApprovalDecisionCancel,
+			Note:              strings.TrimSpace(req.Note),
+		}
+		if err := tx.Create(&action).Error; err != nil {
+			return err
+		}
+		return closeApproval(tx, &request, entity.ApprovalCancelled, userID, action.Note)
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		sendError(c, http.StatusNotFound, "Approval request not found")
+		return
+	case errors.Is(err, errApprovalClosed):
+		sendError(c, http.StatusConflict, "Approval request is already "+request.Status)
+		return
+	case errors.Is(err, errApprovalNotOwner):
+		sendError(c, http.StatusForbidden, "Only the requester can cancel an approval request")
+		return
+	case err != nil:
+		sendError(c, http.StatusInternalServerError, "Failed to cancel approval request")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Approval request cancelled",
+		"data":    request,
+	})
+}
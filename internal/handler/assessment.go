@@ -4,24 +4,27 @@ import (
 	"customer-api/internal/config"
 	"customer-api/internal/dto"
 	"customer-api/internal/entity"
+	"customer-api/internal/query"
+	"fmt"
 	"net/http"
-	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-// @Summary Create assessmenet
-// @Description Create a new organizational structure for specific customer
-// @Tags Structures
+// @Summary Create assessment
+// @Description Create a new assessment (scorecard), questions are added through /details
+// @Tags assessments
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 201 {object} entity.Structure
+// @Param assessment body dto.Assessment true "Assessment object"
+// @Success 201 {object} entity.Assessment
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
-// @Router /api/structures [post]
+// @Router /api/assessment [post]
 func CreateAssessment(c *gin.Context) {
 	var assessment dto.Assessment
 	if err := c.ShouldBindJSON(&assessment); err != nil {
@@ -32,11 +35,13 @@ func CreateAssessment(c *gin.Context) {
 	}
 
 	newAssessment := entity.Assessment{
-		Name:   assessment.Name,
-		RoleID: assessment.RoleID,
+		Name:        assessment.Name,
+		Description: assessment.Description,
+		RoleID:      assessment.RoleID,
+		IsActive:    assessment.IsActive == nil || *assessment.IsActive,
 	}
 
-	if err := config.DB.Create(&newAssessment).Error; err != nil {
+	if err := config.DB.WithContext(c).Create(&newAssessment).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error: "Internal server error",
 		})
@@ -50,6 +55,18 @@ func CreateAssessment(c *gin.Context) {
 	})
 }
 
+var assessmentListSpec = query.Spec{
+	Table: "assessments",
+	Fields: map[string]query.Field{
+		"name":       {},
+		"role_id":    {},
+		"is_active":  {Type: query.Bool},
+		"created_at": {Type: query.Time},
+	},
+	DefaultSort:     "name",
+	DefaultPageSize: 100,
+}
+
 // @Summary Get all assessments
 // @Description Get all assessments
 // @Tags assessments
@@ -58,10 +75,16 @@ func CreateAssessment(c *gin.Context) {
 // @Success 200 {object} []entity.Assessment
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
-// @Router /api/assessments [get]
+// @Router /api/assessment [get]
 func GetAssessments(c *gin.Context) {
+	params, ok := parseList(c, assessmentListSpec)
+	if !ok {
+		return
+	}
+
 	var assessments []entity.Assessment
-	if err := config.DB.Find(&assessments).Error; err != nil {
+	page, err := assessmentListSpec.Find(config.DB, params, &assessments)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error: "Internal server error",
 		})
@@ -69,29 +92,39 @@ func GetAssessments(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Assessments retrieved successfully",
-		"data":    assessments,
+		"status":     "success",
+		"message":    "Assessments retrieved successfully",
+		"data":       assessments,
+		"pagination": page,
+	})
+}
+
+// preloadAssessmentQuestions loads the questions in order with their options
+func preloadAssessmentQuestions(db *gorm.DB) *gorm.DB {
+	return db.Preload("Details", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order, created_at")
+	}).Preload("Details.Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order, created_at")
 	})
 }
 
 // @Summary Get assessment by ID
-// @Description Get assessment by ID
+// @Description Get assessment by ID with its questions and options
 // @Tags assessments
 // @Produce json
 // @Security BearerAuth
-// @Param id path int true "Assessment ID"
+// @Param id path string true "Assessment ID"
 // @Success 200 {object} entity.Assessment
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
-// @Router /api/assessments/{id} [get]
+// @Router /api/assessment/{id} [get]
 func GetAssessment(c *gin.Context) {
 
 	var id = c.Param("id")
 
 	var assessment entity.Assessment
-	if err := config.DB.Where("id = ?", id).First(&assessment).Error; err != nil {
+	if err := preloadAssessmentQuestions(config.DB).Where("id = ?", id).First(&assessment).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, dto.ErrorResponse{
 				Error: "Assessment not found",
@@ -113,45 +146,36 @@ func GetAssessment(c *gin.Context) {
 
 // @Summary Update assessment
 // @Description Update assessment
-//
-//	@Tags assessments
-//	@Accept json
-//	@Produce json
-//	@Security BearerAuth
-//	@Param id path int true "Assessment ID"
-//	@Param assessment body dto.Assessment true "Assessment object"
-//	@Success 200 {object} entity.Assessment
-//	@Failure 400 {object} dto.ErrorResponse
-//	@Failure 401 {object} dto.ErrorResponse
-//	@Failure 404 {object} dto.ErrorResponse
-//	@Failure 500 {object} dto.ErrorResponse
-//	@Router /api/assessments/{id} [put]
+// @Tags assessments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Assessment ID"
+// @Param assessment body dto.Assessment true "Assessment object"
+// @Success 200 {object} entity.Assessment
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/assessment/{id} [put]
 func UpdateAssessment(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error: "Invalid assessment ID",
-		})
-		return
-	}
+	id := c.Param("id")
 
 	var assessment dto.Assessment
 	if err := c.ShouldBindJSON(&assessment); err != nil {
-
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  "error",
 			"message": "Invalid request body",
 			"data":    err.Error(),
 		})
+		return
 	}
 
 	var dbAssessment entity.Assessment
-	if err := config.DB.First(&dbAssessment, id).Error; err != nil {
+	if err := config.DB.Where("id = ?", id).First(&dbAssessment).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusOK, gin.H{
-				"status":  "success",
-				"message": "Invalid request body",
-				"data":    err.Error(),
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Error: "Assessment not found",
 			})
 			return
 		}
@@ -162,9 +186,13 @@ func UpdateAssessment(c *gin.Context) {
 	}
 
 	dbAssessment.Name = assessment.Name
+	dbAssessment.Description = assessment.Description
 	dbAssessment.RoleID = assessment.RoleID
+	if assessment.IsActive != nil {
+		dbAssessment.IsActive = *assessment.IsActive
+	}
 
-	if err := config.DB.Save(&dbAssessment).Error; err != nil {
+	if err := config.DB.WithContext(c).Save(&dbAssessment).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error: "Internal server error",
 		})
@@ -179,27 +207,21 @@ func UpdateAssessment(c *gin.Context) {
 }
 
 // @Summary Delete assessment
-// @Description Delete assessment
+// @Description Delete assessment, past runs are kept
 // @Tags assessments
 // @Produce json
 // @Security BearerAuth
-// @Param id path int true "Assessment ID"
+// @Param id path string true "Assessment ID"
 // @Success 204
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
-// @Router /api/assessments/{id} [delete]
+// @Router /api/assessment/{id} [delete]
 func DeleteAssessment(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error: "Invalid assessment ID",
-		})
-		return
-	}
+	id := c.Param("id")
 
 	var assessment entity.Assessment
-	if err := config.DB.First(&assessment, id).Error; err != nil {
+	if err := config.DB.Where("id = ?", id).First(&assessment).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, dto.ErrorResponse{
 				Error: "Assessment not found",
@@ -212,7 +234,7 @@ func DeleteAssessment(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Delete(&assessment).Error; err != nil {
+	if err := config.DB.WithContext(c).Delete(&assessment).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error: "Internal server error",
 		})
@@ -227,19 +249,13 @@ func DeleteAssessment(c *gin.Context) {
 // @Tags assessments
 // @Produce json
 // @Security BearerAuth
-// @Param role_id path int true "Role ID"
+// @Param role_id path string true "Role ID"
 // @Success 200 {object} []entity.Assessment
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
-// @Router /api/assessments/role/{role_id} [get]
+// @Router /api/assessment/role/{role_id} [get]
 func GetAssessmentsByRoleID(c *gin.Context) {
-	roleID, err := strconv.Atoi(c.Param("role_id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error: "Invalid role ID",
-		})
-		return
-	}
+	roleID := c.Param("role_id")
 
 	var assessments []entity.Assessment
 	if err := config.DB.Where("role_id = ?", roleID).Find(&assessments).Error; err != nil {
@@ -257,35 +273,92 @@ func GetAssessmentsByRoleID(c *gin.Context) {
 
 }
 
+// applyAssessmentQuestion validates input and copies it onto detail. Returns
+// the error message or "" when valid. hasOptions tells whether the question
+// already has options that are kept when input has none.
+func applyAssessmentQuestion(detail *entity.AssessmentDetail, input dto.AssessmentDetail, hasOptions bool) string {
+	detail.Name = strings.TrimSpace(input.Name)
+	if input.Type != "" || detail.Type == "" {
+		detail.Type = strings.ToLower(strings.TrimSpace(input.Type))
+	}
+	if detail.Type == "" {
+		detail.Type = entity.QuestionScale
+	}
+	if input.Weight != nil {
+		detail.Weight = *input.Weight
+	} else if detail.Weight == 0 {
+		detail.Weight = 1
+	}
+	if input.ScaleMin != nil {
+		detail.ScaleMin = *input.ScaleMin
+	}
+	if input.ScaleMax != nil {
+		detail.ScaleMax = *input.ScaleMax
+	}
+	if detail.ScaleMin == 0 && detail.ScaleMax == 0 {
+		detail.ScaleMin, detail.ScaleMax = 1, 5
+	}
+	detail.Required = input.Required
+	if input.SortOrder != nil {
+		detail.SortOrder = *input.SortOrder
+	}
+	if input.IsActive != nil {
+		detail.IsActive = *input.IsActive
+	}
+
+	if detail.Weight <= 0 {
+		return "weight must be greater than 0"
+	}
+	switch detail.Type {
+	case entity.QuestionScale:
+		if detail.ScaleMax <= detail.ScaleMin {
+			return "scale_max must be greater than scale_min"
+		}
+	case entity.QuestionYesNo:
+	case entity.QuestionChoice:
+		if len(input.Options) == 0 && !hasOptions {
+			return "choice questions need at least one option"
+		}
+	default:
+		return fmt.Sprintf("invalid type %q, use scale, yes_no or choice", detail.Type)
+	}
+	return ""
+}
+
+// assessmentOptions converts the option inputs in their given order
+func assessmentOptions(detailID string, inputs []dto.AssessmentOptionInput) []entity.AssessmentOption {
+	options := make([]entity.AssessmentOption, 0, len(inputs))
+	for i, in := range inputs {
+		options = append(options, entity.AssessmentOption{
+			AssessmentDetailID: detailID,
+			Label:              strings.TrimSpace(in.Label),
+			Score:              in.Score,
+			SortOrder:          i + 1,
+		})
+	}
+	return options
+}
+
 // assessment detail
-// @Summary Get assessment detail
-// @Description Get assessment detail
+// @Summary Get assessment questions
+// @Description Questions of an assessment in order, with the options of choice questions
 // @Tags assessments
 // @Produce json
 // @Security BearerAuth
-// @Param id path int true "Assessment ID"
-// @Success 200 {object} entity.AssessmentDetail
+// @Param id path string true "Assessment ID"
+// @Success 200 {object} []entity.AssessmentDetail
 // @Failure 401 {object} dto.ErrorResponse
-// @Failure 404 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
-// @Router /api/assessments/{id}/detail [get]
+// @Router /api/assessment/{id}/details [get]
 func GetAssessmentDetail(c *gin.Context) {
 
-	id, err := strconv.Atoi(c.Param("id"))
+	var id = c.Param("id")
+
+	var assessmentDetails []entity.AssessmentDetail
+	err := config.DB.Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order, created_at")
+	}).Where("assessment_id = ?", id).Order("sort_order, created_at").Find(&assessmentDetails).Error
 	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error: "Invalid assessment ID",
-		})
-		return
-	}
-	var assessmentDetail entity.AssessmentDetail
-	if err := config.DB.First(&assessmentDetail, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, dto.ErrorResponse{
-				Error: "Assessment detail not found",
-			})
-			return
-		}
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error: "Internal server error",
 		})
@@ -295,24 +368,24 @@ func GetAssessmentDetail(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Assessment detail retrieved successfully",
-		"data":    assessmentDetail,
+		"data":    assessmentDetails,
 	})
 }
 
-// @Summary Create assessment detail
-// @Description Create assessment detail
+// @Summary Create assessment question
+// @Description Add a typed question: scale (scale_min..scale_max), yes_no or choice (with options scored 0-100)
 // @Tags assessments
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param id path int true "Assessment ID"
+// @Param id path string true "Assessment ID"
 // @Param assessment_detail body dto.AssessmentDetail true "Assessment detail object"
 // @Success 201 {object} entity.AssessmentDetail
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
-// @Router /api/assessments/{id}/detail [post]
+// @Router /api/assessment/{id}/details [post]
 func CreateAssessmentDetail(c *gin.Context) {
 
 	var id = c.Param("id")
@@ -320,12 +393,13 @@ func CreateAssessmentDetail(c *gin.Context) {
 	var assessmentDetail dto.AssessmentDetail
 	if err := c.ShouldBindJSON(&assessmentDetail); err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error: "Invalid request body",
+			Error: "Invalid request body: " + err.Error(),
 		})
+		return
 	}
 
 	var dbAssessment entity.Assessment
-	if err := config.DB.First(&dbAssessment, id).Error; err != nil {
+	if err := config.DB.Where("id = ?", id).First(&dbAssessment).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, dto.ErrorResponse{
 				Error: "Assessment not found",
@@ -340,11 +414,24 @@ func CreateAssessmentDetail(c *gin.Context) {
 	}
 
 	newAssessmentDetail := entity.AssessmentDetail{
-		AssessmentID: id,
-		Name:         "",
+		AssessmentID: dbAssessment.ID,
+		IsActive:     true,
+	}
+	if msg := applyAssessmentQuestion(&newAssessmentDetail, assessmentDetail, false); msg != "" {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: msg,
+		})
+		return
+	}
+	if assessmentDetail.SortOrder == nil {
+		config.DB.Model(&entity.AssessmentDetail{}).Where("assessment_id = ?", dbAssessment.ID).
+			Select("COALESCE(MAX(sort_order), 0) + 1").Row().Scan(&newAssessmentDetail.SortOrder)
+	}
+	if newAssessmentDetail.Type == entity.QuestionChoice {
+		newAssessmentDetail.Options = assessmentOptions("", assessmentDetail.Options)
 	}
 
-	if err := config.DB.Create(&newAssessmentDetail).Error; err != nil {
+	if err := config.DB.WithContext(c).Create(&newAssessmentDetail).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error: "Internal server error",
 		})
@@ -359,40 +446,35 @@ func CreateAssessmentDetail(c *gin.Context) {
 }
 
 // assessment detail
-// @Summary Update assessment detail
-// @Description Update assessment detail
+// @Summary Update assessment question
+// @Description Update a question. Options given replace the existing ones; past runs keep their answers and scores.
 // @Tags assessments
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param id path int true "Assessment ID"
+// @Param id path string true "Assessment ID"
+// @Param detail_id path string true "Question ID"
 // @Param assessment_detail body dto.AssessmentDetail true "Assessment detail object"
 // @Success 200 {object} entity.AssessmentDetail
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
-// @Router /api/assessments/{id}/detail [put]
+// @Router /api/assessment/{id}/details/{detail_id} [put]
 func UpdateAssessmentDetail(c *gin.Context) {
 
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error: "Invalid assessment ID",
-		})
-		return
-	}
-
 	var assessmentDetail dto.AssessmentDetail
 	if err := c.ShouldBindJSON(&assessmentDetail); err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error: "Invalid request body",
+			Error: "Invalid request body: " + err.Error(),
 		})
-		return // <-- INI YANG KURANG
+		return
 	}
 
 	var dbAssessmentDetail entity.AssessmentDetail
-	if err := config.DB.First(&dbAssessmentDetail, id).Error; err != nil {
+	err := config.DB.Preload("Options").
+		Where("id = ? AND assessment_id = ?", c.Param("detail_id"), c.Param("id")).First(&dbAssessmentDetail).Error
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, dto.ErrorResponse{
 				Error: "Assessment detail not found",
@@ -405,7 +487,32 @@ func UpdateAssessmentDetail(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Save(&dbAssessmentDetail).Error; err != nil {
+	if msg := applyAssessmentQuestion(&dbAssessmentDetail, assessmentDetail, len(dbAssessmentDetail.Options) > 0); msg != "" {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: msg,
+		})
+		return
+	}
+
+	err = config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Options").Save(&dbAssessmentDetail).Error; err != nil {
+			return err
+		}
+		// Opsi lama diganti seluruhnya; jawaban lama menyimpan label dan skornya sendiri
+		if len(assessmentDetail.Options) == 0 && dbAssessmentDetail.Type == entity.QuestionChoice {
+			return nil
+		}
+		if err := tx.Where("assessment_detail_id = ?", dbAssessmentDetail.ID).Delete(&entity.AssessmentOption{}).Error; err != nil {
+			return err
+		}
+		dbAssessmentDetail.Options = nil
+		if dbAssessmentDetail.Type != entity.QuestionChoice {
+			return nil
+		}
+		dbAssessmentDetail.Options = assessmentOptions(dbAssessmentDetail.ID, assessmentDetail.Options)
+		return tx.Create(&dbAssessmentDetail.Options).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error: "Internal server error",
 		})
@@ -419,29 +526,22 @@ func UpdateAssessmentDetail(c *gin.Context) {
 	})
 }
 
-// @Summary Delete assessment detail
-// @Description Delete assessment detail
+// @Summary Delete assessment question
+// @Description Delete a question, past runs keep their answers
 // @Tags assessments
 // @Produce json
 // @Security BearerAuth
-// @Param id path int true "Assessment ID"
+// @Param id path string true "Assessment ID"
+// @Param detail_id path string true "Question ID"
 // @Success 204
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
-// @Router /api/assessments/{id}/detail [delete]
+// @Router /api/assessment/{id}/details/{detail_id} [delete]
 func DeleteAssessmentDetail(c *gin.Context) {
 
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error: "Invalid assessment ID",
-		})
-		return
-	}
-
 	var assessmentDetail entity.AssessmentDetail
-	if err := config.DB.First(&assessmentDetail, id).Error; err != nil {
+	if err := config.DB.Where("id = ? AND assessment_id = ?", c.Param("detail_id"), c.Param("id")).First(&assessmentDetail).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, dto.ErrorResponse{
 				Error: "Assessment detail not found",
@@ -453,7 +553,7 @@ func DeleteAssessmentDetail(c *gin.Context) {
 		})
 		return
 	}
-	if err := config.DB.Delete(&assessmentDetail).Error; err != nil {
+	if err := config.DB.WithContext(c).Delete(&assessmentDetail).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Error: "Internal server error",
 		})
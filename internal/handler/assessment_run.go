@@ -0,0 +1,431 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+	"customer-api/internal/query"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Skor assessment: setiap jawaban bernilai 0-100 (scale linear antara
+// scale_min dan scale_max, yes = 100, choice = skor opsi), lalu dirata-rata
+// dengan bobot pertanyaan. Pertanyaan opsional yang tidak dijawab tidak ikut
+// dihitung. Rating customer (0-5) adalah skor / 20.
+
+// Skala rating customer, lihat validasi rating di patch customer
+const maxCustomerRating = 5
+
+var assessmentRunListSpec = query.Spec{
+	Table: "assessment_runs",
+	Fields: map[string]query.Field{
+		"assessment_id": {},
+		"user_id":       {},
+		"score":         {Type: query.Number},
+		"rating":        {Type: query.Number},
+		"created_at":    {Type: query.Time},
+	},
+	DefaultSort: "-created_at",
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// scoreAnswer scores the answer of one question 0-100
+func scoreAnswer(q entity.AssessmentDetail, in dto.AssessmentAnswerInput) (entity.AssessmentAnswer, error) {
+	answer := entity.AssessmentAnswer{
+		AssessmentDetailID: q.ID,
+		Question:           q.Name,
+		Type:               q.Type,
+		Weight:             q.Weight,
+		SortOrder:          q.SortOrder,
+	}
+	switch q.Type {
+	case entity.QuestionScale:
+		if in.Value == nil {
+			return answer, fmt.Errorf("%s: value is required", q.Name)
+		}
+		v := *in.Value
+		if v < float64(q.ScaleMin) || v > float64(q.ScaleMax) {
+			return answer, fmt.Errorf("%s: value must be between %d and %d", q.Name, q.ScaleMin, q.ScaleMax)
+		}
+		answer.Value = &v
+		answer.Answer = strconv.FormatFloat(v, 'f', -1, 64)
+		answer.Score = (v - float64(q.ScaleMin)) / float64(q.ScaleMax-q.ScaleMin) * 100
+	case entity.QuestionYesNo:
+		if in.Yes == nil {
+			return answer, fmt.Errorf("%s: yes is required", q.Name)
+		}
+		v, text := 0.0, "no"
+		if *in.Yes {
+			v, text = 1, "yes"
+		}
+		answer.Value = &v
+		answer.Answer = text
+		answer.Score = v * 100
+	case entity.QuestionChoice:
+		for _, option := range q.Options {
+			if option.ID == in.OptionID {
+				id := option.ID
+				answer.OptionID = &id
+				answer.Answer = option.Label
+				answer.Score = option.Score
+				return answer, nil
+			}
+		}
+		return answer, fmt.Errorf("%s: option_id is not an option of this question", q.Name)
+	default:
+		return answer, fmt.Errorf("%s: unknown question type %q", q.Name, q.Type)
+	}
+	answer.Score = round2(answer.Score)
+	return answer, nil
+}
+
+// scoreAssessment scores the answers against the active questions of an
+// assessment. Returns the answers in question order, the weighted score
+// 0-100 and every problem found (unknown or duplicate question, invalid
+// value, required question left empty).
+func scoreAssessment(questions []entity.AssessmentDetail, inputs []dto.AssessmentAnswerInput) ([]entity.AssessmentAnswer, float64, []string) {
+	var issues []string
+	given := make(map[string]dto.AssessmentAnswerInput, len(inputs))
+	known := make(map[string]bool, len(questions))
+	for _, q := range questions {
+		known[q.ID] = true
+	}
+	for _, in := range inputs {
+		switch {
+		case !known[in.DetailID]:
+			issues = append(issues, fmt.Sprintf("%s is not an active question of this assessment", in.DetailID))
+		case given[in.DetailID].DetailID != "":
+			issues = append(issues, fmt.Sprintf("%s is answered more than once", in.DetailID))
+		default:
+			given[in.DetailID] = in
+		}
+	}
+
+	var answers []entity.AssessmentAnswer
+	var weighted, weights float64
+	for _, q := range questions {
+		in, ok := given[q.ID]
+		if !ok {
+			if q.Required {
+				issues = append(issues, fmt.Sprintf("%s: answer is required", q.Name))
+			}
+			continue
+		}
+		answer, err := scoreAnswer(q, in)
+		if err != nil {
+			issues = append(issues, err.Error())
+			continue
+		}
+		answers = append(answers, answer)
+		weighted += answer.Score * q.Weight
+		weights += q.Weight
+	}
+	if len(issues) == 0 && weights == 0 {
+		issues = append(issues, "answer at least one question")
+	}
+	if weights == 0 {
+		return answers, 0, issues
+	}
+	return answers, round2(weighted / weights), issues
+}
+
+// @Summary Submit assessment run
+// @Description Assess a customer: the answers are scored and stored with the run. update_rating copies
+// @Description the resulting rating (score / 20, 0-5) to the customer.
+// @Tags assessments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Assessment ID"
+// @Param request body dto.CreateAssessmentRunRequest true "Customer and answers"
+// @Success 201 {object} entity.AssessmentRun
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/assessment/{id}/runs [post]
+func CreateAssessmentRun(c *gin.Context) {
+	userID := c.GetString("user_id")
+	var req dto.CreateAssessmentRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var assessment entity.Assessment
+	err := config.DB.Preload("Details", func(db *gorm.DB) *gorm.DB {
+		return db.Where("is_active = ?", true).Order("sort_order, created_at")
+	}).Preload("Details.Options").Where("id = ?", c.Param("id")).First(&assessment).Error
+	if err != nil {
+		sendError(c, http.StatusNotFound, "Assessment not found")
+		return
+	}
+	if !assessment.IsActive {
+		sendError(c, http.StatusConflict, "Assessment is not active")
+		return
+	}
+	var customer entity.Customer
+	if err := config.DB.Where("id = ?", req.CustomerID).First(&customer).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Customer not found")
+		return
+	}
+
+	answers, score, issues := scoreAssessment(assessment.Details, req.Answers)
+	if len(issues) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "failed",
+			"message": "Invalid answers",
+			"data":    issues,
+		})
+		return
+	}
+
+	run := entity.AssessmentRun{
+		AssessmentID:  assessment.ID,
+		CustomerID:    customer.ID,
+		UserID:        userID,
+		Score:         score,
+		Rating:        round2(score / 100 * maxCustomerRating),
+		Notes:         req.Notes,
+		RatingApplied: req.UpdateRating,
+		Answers:       answers,
+	}
+	err = config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&run).Error; err != nil {
+			return err
+		}
+		if !req.UpdateRating {
+			return nil
+		}
+		var locked entity.Customer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", customer.ID).First(&locked).Error; err != nil {
+			return err
+		}
+		before := locked
+		locked.Rating = run.Rating
+		if err := tx.Omit(clause.Associations).Save(&locked).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, userID, "", &before, &locked)
+	})
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to save assessment run")
+		return
+	}
+
+	message := fmt.Sprintf("Assessment scored %.2f (rating %.2f)", run.Score, run.Rating)
+	if run.RatingApplied {
+		message += ", customer rating updated"
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": message,
+		"data":    run,
+	})
+}
+
+// @Summary Get customer assessment history
+// @Description Runs of every assessment for a customer, newest first
+// @Tags assessments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param assessment_id query string false "Filter by assessment"
+// @Param score[gte] query number false "Minimum score"
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 20, max 200)"
+// @Param sort query string false "Sort, e.g. -score"
+// @Success 200 {array} entity.AssessmentRun
+// @Failure 400 {object} dto.ErrorResponse
+// @Router /api/customers/{id}/assessments [get]
+func GetCustomerAssessments(c *gin.Context) {
+	params, ok := parseList(c, assessmentRunListSpec)
+	if !ok {
+		return
+	}
+	var runs []entity.AssessmentRun
+	db := config.DB.Where("customer_id = ?", c.Param("id"))
+	page, err := assessmentRunListSpec.Find(db, params, &runs, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Assessment", func(db *gorm.DB) *gorm.DB { return db.Unscoped() })
+	})
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch assessment runs")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"message":    "Assessment runs fetched successfully",
+		"data":       runs,
+		"pagination": page,
+	})
+}
+
+// findAssessmentRun loads a run of the customer with its answers in question order
+func findAssessmentRun(db *gorm.DB, customerID, id string) (entity.AssessmentRun, error) {
+	var run entity.AssessmentRun
+	err := db.Preload("Assessment", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Answers", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order") }).
+		Where("id = ? AND customer_id = ?", id, customerID).First(&run).Error
+	return run, err
+}
+
+// @Summary Get customer assessment run
+// @Description One run with every answer and its score
+// @Tags assessments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param run_id path string true "Run ID"
+// @Success 200 {object} entity.AssessmentRun
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/customers/{id}/assessments/{run_id} [get]
+func GetCustomerAssessment(c *gin.Context) {
+	run, err := findAssessmentRun(config.DB, c.Param("id"), c.Param("run_id"))
+	if err != nil {
+		sendError(c, http.StatusNotFound, "Assessment run not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Assessment run fetched successfully",
+		"data":    run,
+	})
+}
+
+func runSummary(run entity.AssessmentRun) dto.AssessmentRunSummary {
+	return dto.AssessmentRunSummary{
+		ID:        run.ID,
+		UserID:    run.UserID,
+		Score:     run.Score,
+		Rating:    run.Rating,
+		CreatedAt: run.CreatedAt,
+	}
+}
+
+// compareAssessmentRuns diffs two runs question by question, in the order of
+// the newer run followed by questions only the older run answered
+func compareAssessmentRuns(from, to entity.AssessmentRun) dto.AssessmentComparison {
+	result := dto.AssessmentComparison{
+		AssessmentID: to.AssessmentID,
+		CustomerID:   to.CustomerID,
+		From:         runSummary(from),
+		To:           runSummary(to),
+		ScoreDelta:   round2(to.Score - from.Score),
+		RatingDelta:  round2(to.Rating - from.Rating),
+		Questions:    []dto.AssessmentAnswerDiff{},
+	}
+	previous := make(map[string]entity.AssessmentAnswer, len(from.Answers))
+	for _, a := range from.Answers {
+		previous[a.AssessmentDetailID] = a
+	}
+	seen := make(map[string]bool, len(to.Answers))
+	for _, a := range to.Answers {
+		seen[a.AssessmentDetailID] = true
+		score := a.Score
+		diff := dto.AssessmentAnswerDiff{
+			DetailID: a.AssessmentDetailID,
+			Question: a.Question,
+			Weight:   a.Weight,
+			ToAnswer: a.Answer,
+			ToScore:  &score,
+		}
+		if old, ok := previous[a.AssessmentDetailID]; ok {
+			oldScore := old.Score
+			delta := round2(score - oldScore)
+			diff.FromAnswer = old.Answer
+			diff.FromScore = &oldScore
+			diff.Delta = &delta
+		}
+		result.Questions = append(result.Questions, diff)
+	}
+	for _, a := range from.Answers {
+		if seen[a.AssessmentDetailID] {
+			continue
+		}
+		score := a.Score
+		result.Questions = append(result.Questions, dto.AssessmentAnswerDiff{
+			DetailID:   a.AssessmentDetailID,
+			Question:   a.Question,
+			Weight:     a.Weight,
+			FromAnswer: a.Answer,
+			FromScore:  &score,
+		})
+	}
+	return result
+}
+
+// @Summary Compare customer assessment runs
+// @Description Score and per-question differences between two runs of the same assessment.
+// @Description Without from/to the two latest runs are compared (of assessment_id, or of the assessment run last).
+// @Tags assessments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param from query string false "Older run ID"
+// @Param to query string false "Newer run ID"
+// @Param assessment_id query string false "Assessment of the latest runs"
+// @Success 200 {object} dto.AssessmentComparison
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/customers/{id}/assessments/compare [get]
+func CompareCustomerAssessments(c *gin.Context) {
+	customerID := c.Param("id")
+	fromID, toID := c.Query("from"), c.Query("to")
+	if (fromID == "") != (toID == "") {
+		sendError(c, http.StatusBadRequest, "Give both from and to, or neither to compare the latest two runs")
+		return
+	}
+
+	if fromID == "" {
+		latest := config.DB.Model(&entity.AssessmentRun{}).Where("customer_id = ?", customerID)
+		if assessmentID := c.Query("assessment_id"); assessmentID != "" {
+			latest = latest.Where("assessment_id = ?", assessmentID)
+		} else {
+			var last []string
+			config.DB.Model(&entity.AssessmentRun{}).Where("customer_id = ?", customerID).
+				Order("created_at DESC").Limit(1).Pluck("assessment_id", &last)
+			if len(last) == 0 {
+				sendError(c, http.StatusNotFound, "Customer has no assessment runs")
+				return
+			}
+			latest = latest.Where("assessment_id = ?", last[0])
+		}
+		var ids []string
+		latest.Order("created_at DESC").Limit(2).Pluck("id", &ids)
+		if len(ids) < 2 {
+			sendError(c, http.StatusNotFound, "At least two runs of the assessment are needed to compare")
+			return
+		}
+		fromID, toID = ids[1], ids[0]
+	}
+
+	from, err := findAssessmentRun(config.DB, customerID, fromID)
+	if err != nil {
+		sendError(c, http.StatusNotFound, "Assessment run "+fromID+" not found")
+		return
+	}
+	to, err := findAssessmentRun(config.DB, customerID, toID)
+	if err != nil {
+		sendError(c, http.StatusNotFound, "Assessment run "+toID+" not found")
+		return
+	}
+	if from.AssessmentID != to.AssessmentID {
+		sendError(c, http.StatusBadRequest, "Runs belong to different assessments")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Assessment runs compared",
+		"data":    compareAssessmentRuns(from, to),
+	})
+}
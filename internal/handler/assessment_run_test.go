@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+)
+
+func TestScoreAssessmentWeightsAnswers(t *testing.T) {
+	questions := []entity.AssessmentDetail{
+		{ID: "q1", Name: "Kepuasan", Type: entity.QuestionScale, Weight: 2, ScaleMin: 1, ScaleMax: 5, Required: true},
+		{ID: "q2", Name: "Rekomendasi", Type: entity.QuestionYesNo, Weight: 1},
+		{ID: "q3", Name: "Frekuensi", Type: entity.QuestionChoice, Weight: 1, Options: []entity.AssessmentOption{
+			{ID: "o1", Label: "Sering", Score: 100},
+			{ID: "o2", Label: "Jarang", Score: 25},
+		}},
+	}
+	yes := true
+
+	// (75*2 + 100 + 25) / 4
+	answers, score, issues := scoreAssessment(questions, []dto.AssessmentAnswerInput{
+		{DetailID: "q3", OptionID: "o2"},
+		{DetailID: "q1", Value: floatToFloatPtr(4)},
+		{DetailID: "q2", Yes: &yes},
+	})
+	if len(issues) > 0 || score != 68.75 {
+		t.Fatalf("score = %v, issues %q; want 68.75", score, issues)
+	}
+	var got []string
+	for _, a := range answers {
+		got = append(got, a.AssessmentDetailID+"="+a.Answer)
+	}
+	if want := []string{"q1=4", "q2=yes", "q3=Jarang"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("answers = %v, want %v in question order", got, want)
+	}
+
+	// Pertanyaan opsional yang kosong tidak menurunkan skor
+	if _, score, issues := scoreAssessment(questions, []dto.AssessmentAnswerInput{{DetailID: "q1", Value: floatToFloatPtr(4)}}); len(issues) > 0 || score != 75 {
+		t.Errorf("optional questions left empty: score = %v, issues %q; want 75", score, issues)
+	}
+
+	_, _, issues = scoreAssessment(questions, []dto.AssessmentAnswerInput{
+		{DetailID: "q2", Yes: &yes},
+		{DetailID: "q2", Yes: &yes},
+		{DetailID: "q9", Value: floatToFloatPtr(1)},
+		{DetailID: "q3", OptionID: "o9"},
+	})
+	want := []string{
+		"q2 is answered more than once",
+		"q9 is not an active question of this assessment",
+		"Kepuasan: answer is required",
+		"Frekuensi: option_id is not an option of this question",
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("issues = %q, want %q", issues, want)
+	}
+}
+
+func TestCompareAssessmentRuns(t *testing.T) {
+	answer := func(id string, score float64, text string) entity.AssessmentAnswer {
+		return entity.AssessmentAnswer{AssessmentDetailID: id, Question: "Q " + id, Weight: 1, Answer: text, Score: score}
+	}
+	from := entity.AssessmentRun{ID: "run1", AssessmentID: "a1", CustomerID: "c1", Score: 50, Rating: 2.5,
+		Answers: []entity.AssessmentAnswer{answer("q1", 50, "3"), answer("q2", 100, "yes"), answer("old", 0, "no")}}
+	to := entity.AssessmentRun{ID: "run2", AssessmentID: "a1", CustomerID: "c1", Score: 62.5, Rating: 3.13,
+		Answers: []entity.AssessmentAnswer{answer("q2", 0, "no"), answer("q1", 75, "4"), answer("new", 100, "Sering")}}
+
+	result := compareAssessmentRuns(from, to)
+	if result.From.ID != "run1" || result.To.ID != "run2" || result.ScoreDelta != 12.5 || result.RatingDelta != 0.63 {
+		t.Fatalf("summary = %s -> %s, score %+v, rating %+v", result.From.ID, result.To.ID, result.ScoreDelta, result.RatingDelta)
+	}
+
+	// Urutan run baru, lalu pertanyaan yang hanya dijawab di run lama
+	type row struct {
+		id, from, to string
+		delta        *float64
+	}
+	var got []row
+	for _, q := range result.Questions {
+		got = append(got, row{q.DetailID, q.FromAnswer, q.ToAnswer, q.Delta})
+	}
+	want := []row{
+		{"q2", "yes", "no", floatToFloatPtr(-100)},
+		{"q1", "3", "4", floatToFloatPtr(25)},
+		{"new", "", "Sering", nil},
+		{"old", "no", "", nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("questions = %+v, want %+v", got, want)
+	}
+	if result.Questions[2].FromScore != nil || result.Questions[3].ToScore != nil {
+		t.Errorf("a question missing from one run must have no score for that run")
+	}
+}
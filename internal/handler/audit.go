@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+	"customer-api/internal/query"
+
+	"github.com/gin-gonic/gin"
+)
+
+var auditListSpec = query.Spec{
+	Table: "audit_logs",
+	Fields: map[string]query.Field{
+		"user_id":     {},
+		"entity_type": {},
+		"entity_id":   {},
+		"action":      {},
+		"ip":          {},
+		"request_id":  {},
+		"created_at":  {Type: query.Time},
+	},
+	DefaultSort: "-created_at",
+}
+
+// @Summary Get audit log
+// @Description Changes to any entity recorded by the audit callbacks: entity type (table name), primary key,
+// @Description before/after per field, acting user, IP and request ID. Newest first by default.
+// @Tags Audit
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query string false "Filter by acting user"
+// @Param entity_type query string false "Filter by table, e.g. roles, workflows, stages, assessments, teams, group_configs"
+// @Param entity_id query string false "Filter by primary key"
+// @Param action query string false "Filter by action" Enums(create, update, delete)
+// @Param request_id query string false "Filter by request ID (X-Request-ID)"
+// @Param created_at[gte] query string false "From date, e.g. 2025-01-01"
+// @Param created_at[lte] query string false "To date (inclusive), e.g. 2025-01-31"
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 20, max 200)"
+// @Success 200 {array} dto.AuditLogResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /api/audit [get]
+func GetAuditLogs(c *gin.Context) {
+	params, ok := parseList(c, auditListSpec)
+	if !ok {
+		return
+	}
+
+	var logs []entity.AuditLog
+	page, err := auditListSpec.Find(config.DB, params, &logs)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch audit log")
+		return
+	}
+
+	// Username dimuat sekali untuk satu halaman
+	var userIDs []string
+	for _, log := range logs {
+		if log.UserID != "" {
+			userIDs = append(userIDs, log.UserID)
+		}
+	}
+	usernames := make(map[string]string)
+	if len(userIDs) > 0 {
+		var users []entity.User
+		config.DB.Select("id", "username").Where("id IN ?", userIDs).Find(&users)
+		for _, user := range users {
+			usernames[user.ID] = user.Username
+		}
+	}
+
+	data := make([]dto.AuditLogResponse, 0, len(logs))
+	for _, log := range logs {
+		entry := dto.AuditLogResponse{
+			ID:         log.ID,
+			EntityType: log.EntityType,
+			EntityID:   log.EntityID,
+			Action:     log.Action,
+			Changes:    []dto.FieldChange{},
+			IP:         log.IP,
+			RequestID:  log.RequestID,
+			CreatedAt:  log.CreatedAt,
+		}
+		json.Unmarshal([]byte(log.Changes), &entry.Changes)
+		if log.UserID != "" {
+			entry.User = &dto.HistoryUser{ID: log.UserID, Username: usernames[log.UserID]}
+		}
+		data = append(data, entry)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"message":    "Audit log fetched successfully",
+		"data":       data,
+		"pagination": page,
+	})
+}
@@ -1,19 +1,20 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
-	"os"
-	"time"
 
 	"customer-api/internal/config"
 	"customer-api/internal/entity"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// errRegisterNoRole: role untuk user baru belum di-seed
+var errRegisterNoRole = errors.New("role for new users not found")
+
 type LoginInput struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
@@ -24,7 +25,6 @@ type RegisterInput struct {
 	Username string `json:"username" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
-	RoleID   string   `json:"role_id"` // Tambahkan field role_id (optional)
 }
 
 // @Summary User login
@@ -80,19 +80,16 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-	})
-
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	pair, _, err := issueTokens(config.DB, c, user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"token": tokenString,
+		"token":         pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
 		"user": gin.H{
 			"id":       user.ID,
 			"username": user.Username,
@@ -138,39 +135,43 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	// Set default role_id jika tidak disediakan
-	roleID := input.RoleID
-	if roleID == "" { // ✅ string kosong, bukan 0
-		// Cari role "User" dari database
-		var userRole entity.Role
-		if err := config.DB.Where("name = ?", "User").First(&userRole).Error; err != nil {
-			// Jika role User tidak ditemukan, gunakan role pertama yang ada
-			if err := config.DB.First(&userRole).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Tidak ada role yang tersedia"})
-				return
-			}
-		}
-		roleID = userRole.ID // ✅ sekarang string, bukan uint
-	} else {
-		// Validasi bahwa role_id yang diberikan ada di database
-		var role entity.Role
-		if err := config.DB.Where("id = ?", roleID).First(&role).Error; err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Role ID tidak valid"})
-			return
-		}
-	}
-
-	// Buat user baru
+	// Registrasi publik selalu mendapat role "User"; role lain diberikan admin
+	// lewat PUT /api/users/:id/role. User pertama menjadi Admin agar sistem bisa di-bootstrap.
+	// Tabel users dikunci selama hitung dan insert, kalau tidak dua registrasi
+	// pertama yang bersamaan sama-sama menjadi Admin.
 	user := entity.User{
 		Username: input.Username,
 		Email:    input.Email,
 		Password: string(hashedPassword),
-		RoleID:   roleID, // ✅ string ULID
 	}
+	err = config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+		roleName := "User"
+		var userCount int64
+		if err := tx.Model(&entity.User{}).Count(&userCount).Error; err != nil {
+			return err
+		}
+		if userCount == 0 {
+			roleName = "Admin"
+		}
 
-	result := config.DB.Create(&user)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mendaftarkan user: " + result.Error.Error()})
+		var userRole entity.Role
+		if err := tx.Where("role_name = ?", roleName).First(&userRole).Error; err != nil {
+			return errRegisterNoRole
+		}
+		user.RoleID = userRole.ID // ✅ string ULID
+
+		// Buat user baru
+		return tx.Create(&user).Error
+	})
+	if errors.Is(err, errRegisterNoRole) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Tidak ada role yang tersedia"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mendaftarkan user: " + err.Error()})
 		return
 	}
 
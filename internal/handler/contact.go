@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"time"
 	"customer-api/internal/config"
@@ -8,7 +9,7 @@ import (
 	"customer-api/internal/entity"
 
 	"github.com/gin-gonic/gin"
-	// Hapus import "gorm.io/gorm" karena tidak digunakan
+	"gorm.io/gorm"
 )
 
 // @Summary Create contact for customer
@@ -42,10 +43,19 @@ func CreateContact(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.CustomerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "customer_id is required"})
+		return
+	}
+
+	var customer entity.Customer
+	if err := config.DB.Where("id = ?", req.CustomerID).First(&customer).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
 
-	// Hapus validasi customer exists karena CustomerID tidak ada di request
 	contact := entity.Contact{
-		// CustomerID: customer.ID, // Akan diset sesuai kebutuhan
+		CustomerID:  customer.ID,
 		Name:        req.Name,
 		JobPosition: req.JobPosition,
 		Email:       req.Email,
@@ -62,8 +72,13 @@ func CreateContact(c *gin.Context) {
 		}
 	}
 
-	result := config.DB.Create(&contact)
-	if result.Error != nil {
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&contact).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, c.GetString("user_id"), "", nil, &contact)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create contact"})
 		return
 	}
@@ -103,6 +118,7 @@ func GetCustomerContacts(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path int true "Contact ID"
 // @Success 200 {object} entity.Contact
+// @Header 200 {string} ETag "Version of the contact, send it as If-Match on update/delete"
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
 // @Router /api/contacts/{id} [get]
@@ -110,12 +126,13 @@ func GetContact(c *gin.Context) {
 	id := c.Param("id")
 
 	var contact entity.Contact
-	result := config.DB.First(&contact, id)
+	result := config.DB.Where("id = ?", id).First(&contact)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
 		return
 	}
 
+	setETag(c, &contact)
 	c.JSON(http.StatusOK, contact)
 }
 
@@ -126,28 +143,55 @@ func GetContact(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Contact ID"
+// @Param If-Match header string true "ETag from the last GET"
 // @Param contact body entity.Contact true "Contact data"
 // @Success 200 {object} entity.Contact
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse "Modified by someone else, data holds the current contact"
+// @Failure 428 {object} dto.ErrorResponse
 // @Router /api/contacts/{id} [put]
 func UpdateContact(c *gin.Context) {
 	id := c.Param("id")
 
 	var contact entity.Contact
-	result := config.DB.First(&contact, id)
+	result := config.DB.Where("id = ?", id).First(&contact)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
 		return
 	}
+	if !checkIfMatch(c, &contact) {
+		return
+	}
+	before := contact
 
 	if err := c.ShouldBindJSON(&contact); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	contact.ID = before.ID
+	contact.CreatedAt = before.CreatedAt
+	contact.Version = before.Version
 
-	config.DB.Save(&contact)
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := lockVersion(tx, &entity.Contact{}, contact.ID, before.Version); err != nil {
+			return err
+		}
+		if err := tx.Save(&contact).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, c.GetString("user_id"), "", &before, &contact)
+	})
+	if errors.Is(err, errVersionConflict) {
+		sendVersionConflict(c, &entity.Contact{}, id)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update contact"})
+		return
+	}
+	setETag(c, &contact)
 	c.JSON(http.StatusOK, contact)
 }
 
@@ -158,21 +202,40 @@ func UpdateContact(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Contact ID"
+// @Param If-Match header string true "ETag from the last GET"
 // @Success 200 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse "Modified by someone else, data holds the current contact"
+// @Failure 428 {object} dto.ErrorResponse
 // @Router /api/contacts/{id} [delete]
 func DeleteContact(c *gin.Context) {
 	id := c.Param("id")
 
-	result := config.DB.Delete(&entity.Contact{}, id)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete contact"})
+	var contact entity.Contact
+	if err := config.DB.Where("id = ?", id).First(&contact).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+		return
+	}
+	if !checkIfMatch(c, &contact) {
 		return
 	}
 
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := lockVersion(tx, &entity.Contact{}, contact.ID, contact.Version); err != nil {
+			return err
+		}
+		if err := tx.Delete(&contact).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, c.GetString("user_id"), "", &contact, nil)
+	})
+	if errors.Is(err, errVersionConflict) {
+		sendVersionConflict(c, &entity.Contact{}, id)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete contact"})
 		return
 	}
 
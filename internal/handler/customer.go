@@ -4,42 +4,64 @@ import (
 	"customer-api/internal/config"
 	"customer-api/internal/dto"
 	"customer-api/internal/entity"
+	"customer-api/internal/query"
+	"errors"
 	"net/http"
 	"path/filepath"
 	"strings"
 	"time"
 	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"github.com/gin-gonic/gin"
-	"github.com/jung-kurt/gofpdf"
-	"github.com/xuri/excelize/v2"
-	"bytes"
 	// "strconv"
 	
 )
 
+var customerListSpec = query.Spec{
+	Table: "customers",
+	Fields: map[string]query.Field{
+		"name":               {},
+		"brand_name":         {},
+		"code":               {},
+		"account_manager_id": {},
+		"status":             {},
+		"category":           {},
+		"email":              {},
+		"rating":             {Type: query.Number},
+		"average_cost":       {Type: query.Number},
+		"created_at":         {Type: query.Time},
+		"updated_at":         {Type: query.Time},
+	},
+	DefaultSort: "-created_at",
+}
+
 // @Summary Get all customers
-// @Description Get list of all customers with optional status filter
+// @Description Get a page of customers. Filters: field=value, field[like]=, field[in]=a,b, field[gte]=/[lte]= on
+// @Description name, brand_name, code, account_manager_id, status, category, email, rating, average_cost, created_at, updated_at
 // @Tags Customers
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param status query string false "Filter by status" Enums(Active, Inactive, Blocked)
+// @Param status query string false "Filter by status" Enums(Draft, Active, Inactive, Blocked)
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 20, max 200)"
+// @Param sort query string false "Sort fields, prefix - for descending, e.g. -created_at,name"
+// @Param cursor query string false "Cursor pagination on id; empty for the first page, then next_cursor"
 // @Success 200 {object} dto.CustomersResponse
+// @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/customers [get]
 func GetCustomers(c *gin.Context) {
-	var customers []entity.Customer
-	status := c.Query("status")
-
-	db := config.DB
-	if status != "" {
-		db = db.Where("status = ?", status)
+	params, ok := parseList(c, customerListSpec)
+	if !ok {
+		return
 	}
 
-	result := db.Find(&customers)
-	if result.Error != nil {
+	var customers []entity.Customer
+	page, err := customerListSpec.Find(config.DB, params, &customers)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch customers"})
 		return
 	}
@@ -55,10 +77,11 @@ func GetCustomers(c *gin.Context) {
 	config.DB.Model(&entity.Customer{}).Select("COALESCE(AVG(average_cost), 0)").Row().Scan(&avgCost)
 
 	var blockedCustomers int64
-	config.DB.Model(&entity.Customer{}).Where("status = ?", "blocked").Count(&blockedCustomers)
+	config.DB.Model(&entity.Customer{}).Where("status = ?", entity.CustomerStatusBlocked).Count(&blockedCustomers)
 
 	c.JSON(http.StatusOK, gin.H{
-		"customers": customers,
+		"customers":  customers,
+		"pagination": page,
 		"stats": gin.H{
 			"total_customers":   totalCustomers,
 			"new_customers":     newCustomers,
@@ -99,174 +122,15 @@ func CreateCustomer(c *gin.Context) {
 		return
 	}
 
-	// Start transaction
-	tx := config.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Di dalam fungsi CreateCustomer, tambahkan setelah Logo assignment:
-	// Create customer entity
-	customer := entity.Customer{
-		Name:             *req.Name,
-		BrandName:        *req.BrandName,
-		Code:             *req.Code,
-		AccountManagerId: *req.AccountManagerId,
-		Status:           "Active", // Default status
-	}
-	
-
-	// Set logo if provided
-	if req.Logo != "" {
-		customer.Logo = req.Logo
-	}
-
-	// Set logo_small if provided
-	if req.LogoSmall != "" {
-		customer.LogoSmall = req.LogoSmall
-	}
-
-	if err := tx.Create(&customer).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create customer: " + err.Error()})
-		return
-	}
-
-	// Create addresses
-	// Create addresses
-	for _, addrReq := range req.Addresses {
-		address := entity.Address{
-			CustomerID: customer.ID,
-			// SupplierID field removed as it doesn't exist in entity.Address
-			Name:    addrReq.Name,
-			Address: addrReq.Address,
-			Main:    addrReq.IsMain,
-			Active:  addrReq.Active,
-		}
-		if err := tx.Create(&address).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create address: " + err.Error()})
-			return
-		}
-	}
-
-	// Create social media
-	for _, socialReq := range req.Socials {
-		sosmed := entity.Sosmed{
-			CustomerID: customer.ID,
-			Name:       socialReq.Platform, // Atau buat field Name di DTO
-			Platform:   socialReq.Platform,
-			Handle:     socialReq.Handle,
-			Active:     socialReq.Active,
-		}
-		if err := tx.Create(&sosmed).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create social media: " + err.Error()})
-			return
-		}
-	}
-
-	// Create contacts
-	for _, contactReq := range req.Contacts {
-		contact := entity.Contact{
-			CustomerID:  customer.ID,
-			Name:        contactReq.Name,
-			JobPosition: contactReq.JobPosition,
-			Email:       contactReq.Email,
-			Phone:       contactReq.Phone,
-			Mobile:      contactReq.Mobile,
-			Main:        contactReq.IsMain,
-			Active:      contactReq.Active,
-		}
-
-		// Parse birthdate if provided
-		if contactReq.Birthdate != "" {
-			if birthdate, err := time.Parse("2006-01-02", contactReq.Birthdate); err == nil {
-				contact.Birthdate = &birthdate
-			}
-		}
-
-		if err := tx.Create(&contact).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create contact: " + err.Error()})
-			return
-		}
-	}
-
-	// Create structures with hierarchy
-	tempKeyMap := make(map[string]string)
-	for _, structReq := range req.Structures {
-		structure := entity.Structure{
-			CustomerID: customer.ID,
-			Name:       structReq.Name,
-			Level:      structReq.Level,
-			Address:    structReq.Address,
-			Active:     structReq.Active,
-		}
-
-		// Set parent if exists
-		if structReq.ParentKey != nil {
-			if parentID, exists := tempKeyMap[*structReq.ParentKey]; exists {
-				structure.ParentID = &parentID
-			}
-		}
-
-		if err := tx.Create(&structure).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create structure: " + err.Error()})
-			return
-		}
-
-		// Store temp key mapping
-		tempKeyMap[structReq.TempKey] = structure.ID
-	}
-
-	// Create others
-	for _, otherReq := range req.Others {
-		other := entity.Other{
-			CustomerID: customer.ID,
-			Key:        otherReq.Key,
-			Value:      otherReq.Value,
-			Active:     otherReq.Active,
-		}
-		if err := tx.Create(&other).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create other attribute: " + err.Error()})
-			return
-		}
-	}
-
-	// Handle groups (industry and parent group)
-	// Note: This assumes groups already exist in the database
-	// Hapus: for _, group := range req.Groups {
-	if req.Groups.IndustryID != "" && req.Groups.IndustryActive {
-		// Find industry group and associate
-		var industryGroup entity.Group
-		if err := tx.Where("id = ?", req.Groups.IndustryID).First(&industryGroup).Error; err == nil {
-			tx.Model(&customer).Association("Groups").Append(&industryGroup)
-		}
-	}
-
-	if req.Groups.ParentGroupID != "" && req.Groups.ParentGroupActive {
-		// Find parent group and associate
-		var parentGroup entity.Group
-		if err := tx.Where("id = ?", req.Groups.ParentGroupID).First(&parentGroup).Error; err == nil {
-			tx.Model(&customer).Association("Groups").Append(&parentGroup)
-		}
-	}
-	// Hapus: }
-
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction: " + err.Error()})
+	customer, err := createCustomerRecords(config.DB, req, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Load customer with all relations for response
 	var createdCustomer entity.Customer
-	config.DB.Preload("Addresses").Preload("Sosmeds").Preload("Contacts").Preload("Structures").Preload("Groups").Preload("Others").First(&createdCustomer, customer.ID)
+	config.DB.Preload("Addresses").Preload("Sosmeds").Preload("Contacts").Preload("Structures").Preload("Groups").Preload("Others").Where("id = ?", customer.ID).First(&createdCustomer)
 
 	// Mapping manual untuk response
 	response := dto.CustomerResponse{
@@ -323,18 +187,195 @@ func CreateCustomer(c *gin.Context) {
 		})
 	}
 
-	// Insert HistoryCustomer
-	history := entity.HistoryCustomer{
-		CustomerID: customer.ID,
-		UserID:     userID,
-		Status:     "Created",
-		Notes:     "Created new customer",
+	c.JSON(http.StatusCreated, response)
+}
+
+// stringValue dereferences an optional request field
+func stringValue(v *string) string {
+	if v == nil {
+		return ""
 	}
-	config.DB.Create(&history)
+	return *v
+}
 
+// toCustomerResponse maps the customer fields without relations
+func toCustomerResponse(customer entity.Customer) dto.CustomerResponse {
+	return dto.CustomerResponse{
+		ID:               customer.ID,
+		Name:             customer.Name,
+		BrandName:        customer.BrandName,
+		Code:             customer.Code,
+		AccountManagerId: customer.AccountManagerId,
+		Logo:             customer.Logo,
+		LogoSmall:        customer.LogoSmall,
+		Status:           customer.Status,
+		Category:         customer.Category,
+		Rating:           customer.Rating,
+		AverageCost:      customer.AverageCost,
+		CreatedAt:        customer.CreatedAt,
+		UpdatedAt:        customer.UpdatedAt,
+	}
+}
 
+// createCustomerRecords creates a customer with its addresses, social media,
+// contacts, structures, other attributes, groups and a "Created" history
+// entry per record in one transaction. Used by CreateCustomer and the bulk import.
+func createCustomerRecords(db *gorm.DB, req dto.CreateCustomerRequest, userID string) (entity.Customer, error) {
+	customer := entity.Customer{
+		Name:             stringValue(req.Name),
+		BrandName:        stringValue(req.BrandName),
+		Code:             stringValue(req.Code),
+		AccountManagerId: stringValue(req.AccountManagerId),
+		Status:           entity.CustomerStatusActive, // Default status
+	}
+	// Customer baru boleh dimulai sebagai Draft, status lain lewat state machine
+	if status, _ := normalizeCustomerStatus(req.StatusName); status == entity.CustomerStatusDraft {
+		customer.Status = status
+	}
 
-	c.JSON(http.StatusCreated, response)
+	// Set logo if provided
+	if req.Logo != "" {
+		customer.Logo = req.Logo
+	}
+
+	// Set logo_small if provided
+	if req.LogoSmall != "" {
+		customer.LogoSmall = req.LogoSmall
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&customer).Error; err != nil {
+			return fmt.Errorf("Failed to create customer: %w", err)
+		}
+		if err := recordCustomerChange(tx, userID, "", nil, &customer); err != nil {
+			return fmt.Errorf("Failed to create customer history: %w", err)
+		}
+
+		// Create addresses
+		for _, addrReq := range req.Addresses {
+			address := entity.Address{
+				CustomerID: customer.ID,
+				Name:       addrReq.Name,
+				Address:    addrReq.Address,
+				Main:       addrReq.IsMain,
+				Active:     addrReq.Active,
+			}
+			if err := tx.Create(&address).Error; err != nil {
+				return fmt.Errorf("Failed to create address: %w", err)
+			}
+			if err := recordCustomerChange(tx, userID, "", nil, &address); err != nil {
+				return fmt.Errorf("Failed to create customer history: %w", err)
+			}
+		}
+
+		// Create social media
+		for _, socialReq := range req.Socials {
+			sosmed := entity.Sosmed{
+				CustomerID: customer.ID,
+				Name:       socialReq.Platform, // Atau buat field Name di DTO
+				Platform:   socialReq.Platform,
+				Handle:     socialReq.Handle,
+				Active:     socialReq.Active,
+			}
+			if err := tx.Create(&sosmed).Error; err != nil {
+				return fmt.Errorf("Failed to create social media: %w", err)
+			}
+			if err := recordCustomerChange(tx, userID, "", nil, &sosmed); err != nil {
+				return fmt.Errorf("Failed to create customer history: %w", err)
+			}
+		}
+
+		// Create contacts
+		for _, contactReq := range req.Contacts {
+			contact := entity.Contact{
+				CustomerID:  customer.ID,
+				Name:        contactReq.Name,
+				JobPosition: contactReq.JobPosition,
+				Email:       contactReq.Email,
+				Phone:       contactReq.Phone,
+				Mobile:      contactReq.Mobile,
+				Main:        contactReq.IsMain,
+				Active:      contactReq.Active,
+			}
+
+			// Parse birthdate if provided
+			if contactReq.Birthdate != "" {
+				if birthdate, err := time.Parse("2006-01-02", contactReq.Birthdate); err == nil {
+					contact.Birthdate = &birthdate
+				}
+			}
+
+			if err := tx.Create(&contact).Error; err != nil {
+				return fmt.Errorf("Failed to create contact: %w", err)
+			}
+			if err := recordCustomerChange(tx, userID, "", nil, &contact); err != nil {
+				return fmt.Errorf("Failed to create customer history: %w", err)
+			}
+		}
+
+		// Create structures with hierarchy
+		tempKeyMap := make(map[string]string)
+		for _, structReq := range req.Structures {
+			structure := entity.Structure{
+				CustomerID: customer.ID,
+				Name:       structReq.Name,
+				Level:      structReq.Level,
+				Address:    structReq.Address,
+				Active:     structReq.Active,
+			}
+
+			// Set parent if exists
+			if structReq.ParentKey != nil {
+				if parentID, exists := tempKeyMap[*structReq.ParentKey]; exists {
+					structure.ParentID = &parentID
+				}
+			}
+
+			if err := tx.Create(&structure).Error; err != nil {
+				return fmt.Errorf("Failed to create structure: %w", err)
+			}
+			if err := recordCustomerChange(tx, userID, "", nil, &structure); err != nil {
+				return fmt.Errorf("Failed to create customer history: %w", err)
+			}
+
+			// Store temp key mapping
+			tempKeyMap[structReq.TempKey] = structure.ID
+		}
+
+		// Create others
+		for _, otherReq := range req.Others {
+			other := entity.Other{
+				CustomerID: customer.ID,
+				Key:        otherReq.Key,
+				Value:      otherReq.Value,
+				Active:     otherReq.Active,
+			}
+			if err := tx.Create(&other).Error; err != nil {
+				return fmt.Errorf("Failed to create other attribute: %w", err)
+			}
+			if err := recordCustomerChange(tx, userID, "", nil, &other); err != nil {
+				return fmt.Errorf("Failed to create customer history: %w", err)
+			}
+		}
+
+		// Handle groups (industry and parent group)
+		// Note: This assumes groups already exist in the database
+		if req.Groups.IndustryID != "" && req.Groups.IndustryActive {
+			var industryGroup entity.Group
+			if err := tx.Where("id = ?", req.Groups.IndustryID).First(&industryGroup).Error; err == nil {
+				tx.Model(&customer).Association("Groups").Append(&industryGroup)
+			}
+		}
+
+		if req.Groups.ParentGroupID != "" && req.Groups.ParentGroupActive {
+			var parentGroup entity.Group
+			if err := tx.Where("id = ?", req.Groups.ParentGroupID).First(&parentGroup).Error; err == nil {
+				tx.Model(&customer).Association("Groups").Append(&parentGroup)
+			}
+		}
+		return nil
+	})
+	return customer, err
 }
 
 // @Summary Get customer by ID
@@ -345,6 +386,7 @@ func CreateCustomer(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path int true "Customer ID"
 // @Success 200 {object} dto.Customer
+// @Header 200 {string} ETag "Version of the customer, send it as If-Match on update/delete"
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
 // @Router /api/customers/{id} [get]
@@ -352,12 +394,13 @@ func GetCustomer(c *gin.Context) {
 	id := c.Param("id")
 
 	var customer entity.Customer
-	result := config.DB.First(&customer, id)
+	result := config.DB.Where("id = ?", id).First(&customer)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
 		return
 	}
 
+	setETag(c, &customer)
 	c.JSON(http.StatusOK, customer)
 }
 
@@ -378,28 +421,46 @@ func UpdateCustomer(c *gin.Context) {
 	}
 	
 	var customer entity.Customer
-	result := config.DB.First(&customer, id)
+	result := config.DB.Where("id = ?", id).First(&customer)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
 		return
 	}
+	if !checkIfMatch(c, &customer) {
+		return
+	}
+	before := customer
 
 	if err := c.ShouldBindJSON(&customer); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	customer.ID = before.ID
+	customer.CreatedAt = before.CreatedAt
+	customer.Version = before.Version
+	// Status hanya berubah lewat POST /customers/:id/status
+	customer.Status = before.Status
 
-	config.DB.Save(&customer)
-
-	// Insert HistoryCustomer
-	history := entity.HistoryCustomer{
-		CustomerID: customer.ID,
-		UserID:     userID,
-		Status:     "Updated",
-		Notes:     "Updated customer",
+	// Data turunan diubah lewat endpoint masing-masing agar tercatat di history
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := lockVersion(tx, &entity.Customer{}, customer.ID, before.Version); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Save(&customer).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, userID, "", &before, &customer)
+	})
+	if errors.Is(err, errVersionConflict) {
+		sendVersionConflict(c, &entity.Customer{}, id)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update customer"})
+		return
 	}
-	config.DB.Create(&history)
 
+	setETag(c, &customer)
 	c.JSON(http.StatusOK, customer)
 }
 
@@ -420,25 +481,78 @@ func DeleteCustomer(c *gin.Context) {
 		return
 	}
 
-	result := config.DB.Delete(&entity.Customer{}, id)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete customer"})
+	var customer entity.Customer
+	if err := config.DB.Where("id = ?", id).First(&customer).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+	if !checkIfMatch(c, &customer) {
 		return
 	}
 
-	// Insert HistoryCustomer
-	history := entity.HistoryCustomer{
-		CustomerID: id,
-		UserID:     userID,
-		Status:     "Deleted",
-		Notes:     "Deleted customer",
+	if id := pendingApprovalID(config.DB, entity.ApprovalCustomerDelete, customer.ID); id != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Deletion of this customer is already waiting for approval " + id})
+		return
+	}
+	approval := entity.ApprovalRequest{
+		Action:      entity.ApprovalCustomerDelete,
+		EntityID:    customer.ID,
+		CustomerID:  customer.ID,
+		Summary:     "Delete customer " + customer.Name,
+		Value:       customerOutstanding(config.DB, customer.ID),
+		RequestedBy: userID,
+	}
+	held, err := requestApproval(config.DB.WithContext(c), &approval, customerDeletePayload{Version: customer.Version})
+	if errors.Is(err, errApprovalPending) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Deletion of this customer is already waiting for approval " +
+			pendingApprovalID(config.DB, entity.ApprovalCustomerDelete, customer.ID)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request approval"})
+		return
+	}
+	if held {
+		sendApprovalRequired(c, &approval)
+		return
 	}
 
-	config.DB.Create(&history)
+	err = config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		return softDeleteCustomer(tx, userID, &customer, customer.Version)
+	})
+	if errors.Is(err, errVersionConflict) {
+		sendVersionConflict(c, &entity.Customer{}, id)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete customer"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Customer deleted successfully"})
 }
 
+// softDeleteCustomer deletes customer with its child data on behalf of userID.
+// Returns errVersionConflict when the customer is no longer at version.
+func softDeleteCustomer(tx *gorm.DB, userID string, customer *entity.Customer, version int) error {
+	if err := lockVersion(tx, &entity.Customer{}, customer.ID, version); err != nil {
+		return err
+	}
+	// Data turunan ikut dihapus dengan deleted_at yang sama persis, sehingga
+	// restore customer dari trash bisa mengembalikannya bersama-sama
+	deletedAt := time.Now().Truncate(time.Microsecond)
+	tx = tx.Session(&gorm.Session{NowFunc: func() time.Time { return deletedAt }})
+	for _, child := range customerChildTables {
+		if err := tx.Where("customer_id = ?", customer.ID).Delete(child.model).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Delete(customer).Error; err != nil {
+		return err
+	}
+	return recordCustomerChange(tx, userID, "", customer, nil)
+}
+
 func UploadCustomerLogo(c *gin.Context) {
 	id := c.Param("id")
 
@@ -457,7 +571,7 @@ func UploadCustomerLogo(c *gin.Context) {
 	}
 	// Check if customer exists
 	var customer entity.Customer
-	result := config.DB.First(&customer, id)
+	result := config.DB.Where("id = ?", id).First(&customer)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
 		return
@@ -487,165 +601,27 @@ func UploadCustomerLogo(c *gin.Context) {
 		return
 	}
 
-	// Update customer logo path
-	customer.Logo = logoPath
-	config.DB.Save(&customer)
-
-	// Insert HistoryCustomer
-	history := entity.HistoryCustomer{
-		CustomerID: customer.ID,
-		UserID:     userID,
-		Status:     "Logo Uploaded",
-		Notes:     "Uploaded logo for customer",
-	}
-	config.DB.Create(&history)
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":   "Logo uploaded successfully",
-		"logo_path": logoPath,
-		"customer":  customer,
-	})
-}
-
-
-// @Summary Update block Customer status
-// @Description Update the status of a customer to Blocked
-// @Tags Customers
-// @Accept json
-// @Produce json
-// @Security BearerAuth
-// @Param id path int true "Customer ID"
-// @Success 200 {object} dto.Customer
-// @Failure 401 {object} dto.ErrorResponse
-// @Failure 404 {object} dto.ErrorResponse
-// @Router /api/customers/status/{id} [post]
-func UpdateCustomerStatus(c *gin.Context) {
-
-	userIDInterface, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
-		return
-	}
-
-	// Type assertion ke string
-	userID, ok := userIDInterface.(string)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID in context has invalid type"})
-		return
-	}
-
-	id := c.Param("id")
-	status := c.PostForm("status")   // ambil status dari form
-	reason := c.PostForm("reason")   // alasan perubahan status
-	notes := c.PostForm("notes")     // catatan untuk dokumen
-
-	// Validasi status
-	if status != "active" && status != "blocked" && status != "draft" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
-		return
-	}
-
-	// Cari customer
-	var customer entity.Customer
-	if err := config.DB.Where("id = ?", id).First(&customer).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
-		return
-	}
-
-
-	// update status customer
-	customer.Status = status
-	config.DB.Save(&customer)
-
-	// Simpan reason ke StatusReasons
-	statusReason := entity.StatusReasons{
-		CustomerID: customer.ID,
-		Reason:     reason,
-		Status:     status,
-	}
-	config.DB.Create(&statusReason)
-
-	// Handle file upload
-	file, err := c.FormFile("file")
-	var document entity.Document
-	if err == nil { // kalau ada file
-		// Simpan file ke folder uploads/
-		filePath := fmt.Sprintf("uploads/documents/%d_%s", customer.ID, file.Filename)
-		if err := c.SaveUploadedFile(file, filePath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
-			return
+	// Hanya kolom logo yang diubah, pada baris terbaru yang dikunci
+	err = config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&customer).Error; err != nil {
+			return err
 		}
-
-		// Simpan record document
-		document = entity.Document{
-			CustomerID: customer.ID,
-			UserID:		userID,
-			Notes:      notes,
-			Type:       "StatusChange",
-			URLFile:    filePath,
+		before := customer
+		if err := tx.Model(&customer).Update("logo", logoPath).Error; err != nil {
+			return err
 		}
-		config.DB.Create(&document)
-	}
-
-	// Insert HistoryCustomer
-	history := entity.HistoryCustomer{
-		CustomerID: customer.ID,
-		UserID:     userID,
-		Status:     "Status Changed",
-		Notes:     "Changed status to " + status,
-	}
-	config.DB.Create(&history)
-
-	// Response
-	c.JSON(http.StatusOK, gin.H{
-		"message":       "Customer status updated to " + status,
-		"customer":      customer,
-		"status_reason": statusReason,
-		"document":      document,
+		customer.Logo = logoPath
+		return recordCustomerChange(tx, userID, "Logo Uploaded", &before, &customer)
 	})
-}
-
-
-// @Summary Get Customer Status Reason And Document
-// @Description Get the status reason and document for a customer
-// @Tags Customers
-// @Accept json
-// @Produce json
-// @Security BearerAuth
-// @Param id path int true "Customer ID"
-// @Success 200 {object} dto.CustomerStatusResponse
-// @Failure 401 {object} dto.ErrorResponse
-// @Failure 404 {object} dto.ErrorResponse
-// @Router /api/customers/status/{id} [get]
-func GetCustomerStatus(c *gin.Context) {
-	id := c.Param("id")
-
-	// Cari customer
-	var customer entity.Customer
-	if err := config.DB.First(&customer, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
-		return
-	}
-
-	// Ambil status reasons
-	var statusReasons []entity.StatusReasons
-	if err := config.DB.Where("customer_id = ? AND is_active = ?", customer.ID, true).Find(&statusReasons).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status reasons"})
-		return
-	}
-
-	// Ambil documents terkait status perubahan
-	var documents []entity.Document
-	if err := config.DB.Where("customer_id = ? AND is_active = ?", customer.ID, true).Find(&documents).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch documents"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update customer logo"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":       "Success Get Customer status",
-		"customer":      customer,
-		"StatusReasons": statusReasons,
-		"document":      documents,
+		"message":   "Logo uploaded successfully",
+		"logo_path": logoPath,
+		"customer":  customer,
 	})
 }
 
@@ -653,7 +629,7 @@ func GetCustomerStatus(c *gin.Context) {
 // @Summary Get customer statistics
 // @Description Get statistics about customers including total count, new customers in the last year, average cost, and blocked customers
 // @Tags Customers
-// @Param status query string false "Filter by status" Enums(Active, Inactive, Blocked)
+// @Param status query string false "Filter by status" Enums(Draft, Active, Inactive, Blocked)
 // @Accept json
 // @Produce json
 // @Security BearerAuth
@@ -663,6 +639,9 @@ func GetCustomerStatus(c *gin.Context) {
 // @Router /api/customers/stats [get]
 func GetCustomerStats(c *gin.Context) {
 	status := c.Query("status")
+	if canonical, ok := normalizeCustomerStatus(status); ok {
+		status = canonical
+	}
 
 	// Helper: apply filter & range tahun
 	queryWithFilter := func(base *gorm.DB, status string, start, end *time.Time) *gorm.DB {
@@ -741,103 +720,6 @@ func GetCustomerStats(c *gin.Context) {
 }
 
 
-// ExportCustomers handles customer export to Excel or PDF
-func ExportCustomers(c *gin.Context) {
-	exportType := c.Query("type")
-
-	// validasi export type
-	if exportType != "excel" && exportType != "pdf" {
-		sendError(c, http.StatusBadRequest, "Invalid export type (must be 'excel' or 'pdf')")
-		return
-	}
-
-	// ambil data customer
-	var customers []entity.Customer
-	if err := config.DB.Find(&customers).Error; err != nil {
-		sendError(c, http.StatusInternalServerError, "Failed to fetch customers")
-		return
-	}
-
-	switch exportType {
-	case "excel":
-		file, err := createExcelFile(customers)
-		if err != nil {
-			sendError(c, http.StatusInternalServerError, "Failed to create excel file: "+err.Error())
-			return
-		}
-		sendFile(c, file, "customers.xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
-
-	case "pdf":
-		file, err := createPDFFile(customers)
-		if err != nil {
-			sendError(c, http.StatusInternalServerError, "Failed to create pdf file: "+err.Error())
-			return
-		}
-		sendFile(c, file, "customers.pdf", "application/pdf")
-	}
-}
-
-// helper untuk buat Excel file
-func createExcelFile(customers []entity.Customer) ([]byte, error) {
-	f := excelize.NewFile()
-	sheet := "Customers"
-	f.NewSheet(sheet)
-
-	// header
-	headers := []string{"ID", "Name", "Brand Name", "Code", "Status"}
-	for i, h := range headers {
-		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
-		f.SetCellValue(sheet, cell, h)
-	}
-
-	// data
-	for row, cust := range customers {
-		values := []interface{}{cust.ID, cust.Name, cust.BrandName, cust.Code, cust.Status}
-		for col, val := range values {
-			cell, _ := excelize.CoordinatesToCellName(col+1, row+2)
-			f.SetCellValue(sheet, cell, val)
-		}
-	}
-
-	var buf bytes.Buffer
-	if err := f.Write(&buf); err != nil {
-		return nil, err
-	}
-	return buf.Bytes(), nil
-}
-
-// helper untuk buat PDF file
-func createPDFFile(customers []entity.Customer) ([]byte, error) {
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.AddPage()
-	pdf.SetFont("Arial", "B", 12)
-
-	// header
-	headers := []string{"ID", "Name", "Brand Name", "Code", "Status"}
-	for _, h := range headers {
-		pdf.CellFormat(40, 10, h, "1", 0, "C", false, 0, "")
-	}
-	pdf.Ln(-1)
-
-	// data
-	pdf.SetFont("Arial", "", 10)
-	for _, cust := range customers {
-		pdf.CellFormat(40, 10, cust.ID, "1", 0, "", false, 0, "")
-		pdf.CellFormat(40, 10, cust.Name, "1", 0, "", false, 0, "")
-		pdf.CellFormat(40, 10, cust.BrandName, "1", 0, "", false, 0, "")
-		pdf.CellFormat(40, 10, cust.Code, "1", 0, "", false, 0, "")
-		pdf.CellFormat(40, 10, cust.Status, "1", 0, "", false, 0, "")
-		pdf.Ln(-1)
-	}
-
-	var buf bytes.Buffer
-	err := pdf.Output(&buf)
-	if err != nil {
-		return nil, err
-	}
-	return buf.Bytes(), nil
-}
-
 // helper untuk kirim response error
 func sendError(c *gin.Context, code int, message string) {
 	c.JSON(code, gin.H{
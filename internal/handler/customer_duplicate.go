@@ -0,0 +1,716 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+	"customer-api/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultDuplicateScore = 0.7
+	defaultDuplicateLimit = 50
+	// Kelompok kandidat yang terlalu besar (mis. kata umum) tidak dibandingkan
+	maxDuplicateBlockSize = 300
+)
+
+// Bobot tiap field; field yang kosong di salah satu customer tidak dihitung
+var duplicateWeights = map[string]float64{
+	"name":         0.4,
+	"code":         0.2,
+	"email_domain": 0.15,
+	"phone":        0.15,
+	"main_address": 0.1,
+}
+
+var companyLegalForms = map[string]bool{
+	"pt": true, "cv": true, "ud": true, "tbk": true, "persero": true, "pma": true,
+	"inc": true, "ltd": true, "llc": true, "co": true, "corp": true, "company": true, "limited": true,
+}
+
+var freeEmailDomains = map[string]bool{
+	"gmail.com": true, "yahoo.com": true, "yahoo.co.id": true, "hotmail.com": true,
+	"outlook.com": true, "live.com": true, "icloud.com": true, "ymail.com": true,
+}
+
+// duplicateProfile holds the normalized values compared between customers
+type duplicateProfile struct {
+	customer entity.Customer
+	name     string
+	code     string
+	address  string
+	domains  map[string]bool
+	phones   map[string]bool
+}
+
+// normalizeCompanyName lowercases the name and drops punctuation and legal
+// forms, so "PT. Maju Jaya, Tbk" becomes "maju jaya"
+func normalizeCompanyName(name string) string {
+	var words []string
+	for _, word := range searchTerms(name) {
+		if !companyLegalForms[word] {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func normalizeCustomerCode(code string) string {
+	return strings.Join(searchTerms(code), "")
+}
+
+// emailDomain returns the company domain of an email, ignoring free mail providers
+func emailDomain(email string) string {
+	_, domain, ok := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	if !ok || domain == "" || freeEmailDomains[domain] {
+		return ""
+	}
+	return domain
+}
+
+// normalizePhone keeps the digits and writes +62 numbers with a leading 0
+func normalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if strings.HasPrefix(digits, "62") {
+		digits = "0" + digits[2:]
+	}
+	if len(digits) < 7 {
+		return ""
+	}
+	return digits
+}
+
+func newDuplicateProfile(customer entity.Customer) *duplicateProfile {
+	p := &duplicateProfile{
+		customer: customer,
+		name:     normalizeCompanyName(customer.Name),
+		code:     normalizeCustomerCode(customer.Code),
+		domains:  make(map[string]bool),
+		phones:   make(map[string]bool),
+	}
+	// Alamat dimuat dengan main lebih dulu
+	if len(customer.Addresses) > 0 {
+		p.address = strings.Join(searchTerms(customer.Addresses[0].Address), " ")
+	}
+	emails := []string{customer.Email}
+	phones := []string{customer.Phone}
+	for _, contact := range customer.Contacts {
+		emails = append(emails, contact.Email)
+		phones = append(phones, contact.Phone, contact.Mobile)
+	}
+	for _, email := range emails {
+		if domain := emailDomain(email); domain != "" {
+			p.domains[domain] = true
+		}
+	}
+	for _, phone := range phones {
+		if digits := normalizePhone(phone); digits != "" {
+			p.phones[digits] = true
+		}
+	}
+	return p
+}
+
+// blockingKeys groups customers that are worth comparing; two customers are
+// only scored when they share at least one key
+func (p *duplicateProfile) blockingKeys() []string {
+	var keys []string
+	prefixes := make(map[string]bool)
+	for _, word := range strings.Fields(p.name) {
+		runes := []rune(word)
+		if len(runes) < 3 {
+			continue
+		}
+		// Awalan kata supaya nama yang salah ketik tetap satu kelompok
+		prefix := string(runes[:min(4, len(runes))])
+		if !prefixes[prefix] {
+			prefixes[prefix] = true
+			keys = append(keys, "n:"+prefix)
+		}
+	}
+	if p.code != "" {
+		keys = append(keys, "c:"+p.code)
+	}
+	for domain := range p.domains {
+		keys = append(keys, "d:"+domain)
+	}
+	for phone := range p.phones {
+		keys = append(keys, "p:"+phone)
+	}
+	return keys
+}
+
+func sharesKey(a, b map[string]bool) bool {
+	for key := range a {
+		if b[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// scoreDuplicate returns the weighted similarity of two customers and the
+// similarity of every field both have a value for
+func scoreDuplicate(a, b *duplicateProfile) (float64, map[string]float64) {
+	matches := make(map[string]float64)
+	if a.name != "" && b.name != "" {
+		matches["name"] = trigramSimilarity(a.name, b.name)
+		if a.name == b.name {
+			matches["name"] = 1
+		}
+	}
+	if a.code != "" && b.code != "" {
+		matches["code"] = trigramSimilarity(a.code, b.code)
+		if a.code == b.code {
+			matches["code"] = 1
+		}
+	}
+	if len(a.domains) > 0 && len(b.domains) > 0 {
+		matches["email_domain"] = 0
+		if sharesKey(a.domains, b.domains) {
+			matches["email_domain"] = 1
+		}
+	}
+	if len(a.phones) > 0 && len(b.phones) > 0 {
+		matches["phone"] = 0
+		if sharesKey(a.phones, b.phones) {
+			matches["phone"] = 1
+		}
+	}
+	if a.address != "" && b.address != "" {
+		matches["main_address"] = trigramSimilarity(a.address, b.address)
+	}
+
+	total, weights := 0.0, 0.0
+	for field, similarity := range matches {
+		matches[field] = roundScore(similarity)
+		total += duplicateWeights[field] * similarity
+		weights += duplicateWeights[field]
+	}
+	if weights == 0 {
+		return 0, matches
+	}
+	return roundScore(total / weights), matches
+}
+
+// loadDuplicateProfiles loads every customer with the data used for scoring
+func loadDuplicateProfiles() ([]*duplicateProfile, error) {
+	var customers []entity.Customer
+	err := config.DB.
+		Preload("Addresses", func(tx *gorm.DB) *gorm.DB { return tx.Order("main DESC, created_at") }).
+		Preload("Contacts").
+		Order("created_at, id").
+		Find(&customers).Error
+	if err != nil {
+		return nil, err
+	}
+	profiles := make([]*duplicateProfile, 0, len(customers))
+	for _, customer := range customers {
+		profiles = append(profiles, newDuplicateProfile(customer))
+	}
+	return profiles, nil
+}
+
+// findDuplicates scores candidate pairs; when target is set only pairs with
+// that customer are returned. The older customer of a pair comes first.
+func findDuplicates(profiles []*duplicateProfile, target string, minScore float64) []dto.CustomerDuplicate {
+	index := make(map[*duplicateProfile]int, len(profiles))
+	blocks := make(map[string][]*duplicateProfile)
+	for i, p := range profiles {
+		index[p] = i
+		for _, key := range p.blockingKeys() {
+			blocks[key] = append(blocks[key], p)
+		}
+	}
+
+	seen := make(map[[2]int]bool)
+	duplicates := make([]dto.CustomerDuplicate, 0)
+	for _, block := range blocks {
+		if len(block) < 2 || len(block) > maxDuplicateBlockSize {
+			continue
+		}
+		for i := 0; i < len(block); i++ {
+			for j := i + 1; j < len(block); j++ {
+				a, b := block[i], block[j]
+				if index[a] > index[b] {
+					a, b = b, a
+				}
+				if target != "" && a.customer.ID != target && b.customer.ID != target {
+					continue
+				}
+				pair := [2]int{index[a], index[b]}
+				if seen[pair] {
+					continue
+				}
+				seen[pair] = true
+
+				score, matches := scoreDuplicate(a, b)
+				if score < minScore {
+					continue
+				}
+				duplicates = append(duplicates, dto.CustomerDuplicate{
+					Customer:  toCustomerResponse(a.customer),
+					Duplicate: toCustomerResponse(b.customer),
+					Score:     score,
+					Matches:   matches,
+				})
+			}
+		}
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Score != duplicates[j].Score {
+			return duplicates[i].Score > duplicates[j].Score
+		}
+		return duplicates[i].Customer.ID+duplicates[i].Duplicate.ID < duplicates[j].Customer.ID+duplicates[j].Duplicate.ID
+	})
+	return duplicates
+}
+
+// parseDuplicateParams reads min_score and limit, answering 400 itself
+func parseDuplicateParams(c *gin.Context) (float64, int, bool) {
+	minScore, limit := defaultDuplicateScore, defaultDuplicateLimit
+	if v := c.Query("min_score"); v != "" {
+		score, err := strconv.ParseFloat(v, 64)
+		if err != nil || score < 0 || score > 1 {
+			sendError(c, http.StatusBadRequest, "min_score must be a number between 0 and 1")
+			return 0, 0, false
+		}
+		minScore = score
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			sendError(c, http.StatusBadRequest, "limit must be a positive integer")
+			return 0, 0, false
+		}
+		limit = n
+	}
+	return minScore, limit, true
+}
+
+// @Summary Find duplicate customers
+// @Description List pairs of customers that probably describe the same company, scored on normalized name, code,
+// @Description email domain, phone and main address. The older customer of each pair is listed first.
+// @Tags Customers
+// @Produce json
+// @Security BearerAuth
+// @Param min_score query number false "Minimum score 0-1 (default 0.7)"
+// @Param limit query int false "Max pairs (default 50)"
+// @Success 200 {array} dto.CustomerDuplicate
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/customers/duplicates [get]
+func GetCustomerDuplicates(c *gin.Context) {
+	minScore, limit, ok := parseDuplicateParams(c)
+	if !ok {
+		return
+	}
+	profiles, err := loadDuplicateProfiles()
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch customers")
+		return
+	}
+
+	duplicates := findDuplicates(profiles, "", minScore)
+	if len(duplicates) > limit {
+		duplicates = duplicates[:limit]
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Duplicate customers fetched successfully",
+		"data":    duplicates,
+	})
+}
+
+// @Summary Find duplicates of a customer
+// @Description List customers that probably describe the same company as the given customer
+// @Tags Customers
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param min_score query number false "Minimum score 0-1 (default 0.7)"
+// @Param limit query int false "Max pairs (default 50)"
+// @Success 200 {array} dto.CustomerDuplicate
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/customers/{id}/duplicates [get]
+func GetCustomerDuplicatesByID(c *gin.Context) {
+	id := c.Param("id")
+	minScore, limit, ok := parseDuplicateParams(c)
+	if !ok {
+		return
+	}
+
+	var count int64
+	config.DB.Model(&entity.Customer{}).Where("id = ?", id).Count(&count)
+	if count == 0 {
+		sendError(c, http.StatusNotFound, "Customer not found")
+		return
+	}
+	profiles, err := loadDuplicateProfiles()
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch customers")
+		return
+	}
+
+	duplicates := findDuplicates(profiles, id, minScore)
+	if len(duplicates) > limit {
+		duplicates = duplicates[:limit]
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Duplicate customers fetched successfully",
+		"data":    duplicates,
+	})
+}
+
+// Data milik customer yang dipindah ke customer yang dipertahankan saat merge.
+// Baris yang sudah di-trash dan history tetap milik duplikat.
+var customerMergeTables = []struct {
+	name  string
+	model interface{}
+}{
+	{"addresses", &entity.Address{}},
+	{"contacts", &entity.Contact{}},
+	{"sosmeds", &entity.Sosmed{}},
+	{"structures", &entity.Structure{}},
+	{"others", &entity.Other{}},
+	{"activities", &entity.Activity{}},
+	{"events", &entity.Event{}},
+	{"documents", &entity.Document{}},
+	{"invoices", &entity.Invoice{}},
+	{"payments", &entity.Payment{}},
+	{"pipeline_items", &entity.PipelineItem{}},
+	{"approval_requests", &entity.ApprovalRequest{}},
+	{"assessment_runs", &entity.AssessmentRun{}},
+	{"customer_status_changes", &entity.CustomerStatusChange{}},
+}
+
+// fillEmptyCustomerFields copies values of the duplicate into empty fields of the survivor
+func fillEmptyCustomerFields(survivor, duplicate entity.Customer) map[string]interface{} {
+	updates := make(map[string]interface{})
+	fields := []struct {
+		column           string
+		current, replace string
+	}{
+		{"brand_name", survivor.BrandName, duplicate.BrandName},
+		{"account_manager_id", survivor.AccountManagerId, duplicate.AccountManagerId},
+		{"email", survivor.Email, duplicate.Email},
+		{"phone", survivor.Phone, duplicate.Phone},
+		{"website", survivor.Website, duplicate.Website},
+		{"description", survivor.Description, duplicate.Description},
+		{"logo", survivor.Logo, duplicate.Logo},
+		{"logo_small", survivor.LogoSmall, duplicate.LogoSmall},
+		{"category", survivor.Category, duplicate.Category},
+	}
+	for _, f := range fields {
+		if f.current == "" && f.replace != "" {
+			updates[f.column] = f.replace
+		}
+	}
+	return updates
+}
+
+// @Summary Merge customers
+// @Description Move every address, contact, social media, structure, other attribute, activity, event, group, document,
+// @Description invoice and payment of the duplicate to this customer in one transaction, then delete the duplicate.
+// @Description Trashed rows and the history stay with the duplicate.
+// @Description The merge is recorded in the history of both customers. If-Match carries the ETag of this customer,
+// @Description duplicate_version the one of the duplicate. Deleting the duplicate goes through the customer_delete approval (202).
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID to keep"
+// @Param request body dto.MergeCustomerRequest true "Duplicate to merge"
+// @Success 200 {object} dto.MergeCustomerResponse
+// @Success 202 {object} dto.Response
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/customers/{id}/merge [post]
+func MergeCustomer(c *gin.Context) {
+	// Merge menghapus duplikat, route hanya menuntut customers:write
+	middleware.RequirePermission(config.PermissionName("customers", config.ActionDelete))(c)
+	if c.IsAborted() {
+		return
+	}
+	survivorID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var req dto.MergeCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.DuplicateID == survivorID {
+		sendError(c, http.StatusBadRequest, "A customer cannot be merged into itself")
+		return
+	}
+
+	var survivor, duplicate entity.Customer
+	if err := config.DB.Where("id = ?", survivorID).First(&survivor).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Customer not found")
+		return
+	}
+	if err := config.DB.Where("id = ?", req.DuplicateID).First(&duplicate).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Duplicate customer not found")
+		return
+	}
+	if !checkIfMatch(c, &survivor) {
+		return
+	}
+	if req.DuplicateVersion != duplicate.Version {
+		sendPreconditionFailed(c, &duplicate)
+		return
+	}
+
+	// Merge menghapus duplikat, jadi ikut approval customer_delete
+	if id := pendingApprovalID(config.DB, entity.ApprovalCustomerDelete, duplicate.ID); id != "" {
+		sendError(c, http.StatusConflict, "Deletion of the duplicate is already waiting for approval "+id)
+		return
+	}
+	payload := customerDeletePayload{
+		Version:         duplicate.Version,
+		MergeInto:       survivor.ID,
+		MergeVersion:    survivor.Version,
+		FillEmptyFields: req.FillEmptyFields,
+	}
+	approval := entity.ApprovalRequest{
+		Action:      entity.ApprovalCustomerDelete,
+		EntityID:    duplicate.ID,
+		CustomerID:  duplicate.ID,
+		Summary:     fmt.Sprintf("Merge customer %s into %s", duplicate.Name, survivor.Name),
+		Value:       customerOutstanding(config.DB, duplicate.ID),
+		RequestedBy: userID,
+	}
+	held, err := requestApproval(config.DB.WithContext(c), &approval, payload)
+	if errors.Is(err, errApprovalPending) {
+		sendError(c, http.StatusConflict, "Deletion of the duplicate is already waiting for approval "+
+			pendingApprovalID(config.DB, entity.ApprovalCustomerDelete, duplicate.ID))
+		return
+	}
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to request approval")
+		return
+	}
+	if held {
+		sendApprovalRequired(c, &approval)
+		return
+	}
+
+	var moved map[string]int64
+	err = config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		moved, err = mergeCustomers(tx, userID, duplicate.ID, "", payload, &survivor)
+		return err
+	})
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		sendError(c, http.StatusNotFound, "Customer not found")
+		return
+	case errors.Is(err, errVersionConflict):
+		sendError(c, http.StatusPreconditionFailed, "One of the customers was modified by another request, reload both and retry")
+		return
+	case err != nil:
+		sendError(c, http.StatusInternalServerError, "Failed to merge customers: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Customers merged successfully",
+		"data": dto.MergeCustomerResponse{
+			Customer: toCustomerResponse(survivor),
+			MergedID: req.DuplicateID,
+			Moved:    moved,
+		},
+	})
+}
+
+// mergeCustomers moves the data of duplicateID to payload.MergeInto and
+// deletes the duplicate on behalf of userID. Both customers must still be at
+// the versions of payload, otherwise it fails with errVersionConflict.
+// survivor receives the merged customer. approvalID is the approval request
+// applying the merge, empty for a direct merge.
+func mergeCustomers(tx *gorm.DB, userID, duplicateID, approvalID string, payload customerDeletePayload, survivor *entity.Customer) (map[string]int64, error) {
+	// Kunci kedua customer dengan urutan tetap agar merge bersamaan tidak deadlock
+	versions := map[string]int{payload.MergeInto: payload.MergeVersion, duplicateID: payload.Version}
+	ids := []string{payload.MergeInto, duplicateID}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := lockVersion(tx, &entity.Customer{}, id, versions[id]); err != nil {
+			return nil, err
+		}
+	}
+	var duplicate entity.Customer
+	if err := tx.Where("id = ?", payload.MergeInto).First(survivor).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("id = ?", duplicateID).First(&duplicate).Error; err != nil {
+		return nil, err
+	}
+
+	moved := make(map[string]int64)
+	// Main address/contact customer yang dipertahankan tetap menjadi main
+	for _, model := range []interface{}{&entity.Address{}, &entity.Contact{}} {
+		var mains int64
+		if err := tx.Model(model).Where("customer_id = ? AND main = ?", survivor.ID, true).Count(&mains).Error; err != nil {
+			return nil, err
+		}
+		if mains > 0 {
+			if err := tx.Model(model).Where("customer_id = ? AND main = ?", duplicate.ID, true).Update("main", false).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+	// Atribut Other dengan key yang sudah ada tetap dipindah tapi dinonaktifkan
+	existingKeys := tx.Model(&entity.Other{}).Select("key").Where("customer_id = ? AND active = ?", survivor.ID, true)
+	if err := tx.Model(&entity.Other{}).Where("customer_id = ? AND key IN (?)", duplicate.ID, existingKeys).Update("active", false).Error; err != nil {
+		return nil, err
+	}
+
+	// Approval hapus/blokir duplikat yang masih pending ikut dibatalkan; kalau
+	// dipindah ke customer ini entity_id-nya tetap menunjuk duplikat
+	if err := cancelMergedApprovals(tx, userID, duplicate.ID, approvalID, survivor.ID); err != nil {
+		return nil, err
+	}
+
+	// Perubahan status duplikat yang belum berlaku tidak boleh mengenai customer ini
+	if err := tx.Model(&entity.CustomerStatusChange{}).
+		Where("customer_id = ? AND state IN ?", duplicate.ID, []string{entity.StatusChangePending, entity.StatusChangeScheduled}).
+		Updates(map[string]interface{}{
+			"state":         entity.StatusChangeCancelled,
+			"decided_by":    userID,
+			"decided_at":    time.Now(),
+			"decision_note": "Cancelled by merge into " + survivor.ID,
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	for _, table := range customerMergeTables {
+		result := tx.Model(table.model).Where("customer_id = ?", duplicate.ID).Update("customer_id", survivor.ID)
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to move %s: %w", table.name, result.Error)
+		}
+		moved[table.name] = result.RowsAffected
+	}
+
+	result := tx.Exec(`INSERT INTO customer_groups (customer_id, group_id)
+		SELECT ?, group_id FROM customer_groups WHERE customer_id = ?
+		ON CONFLICT DO NOTHING`, survivor.ID, duplicate.ID)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to move groups: %w", result.Error)
+	}
+	moved["groups"] = result.RowsAffected
+	if err := tx.Exec(`DELETE FROM customer_groups WHERE customer_id = ?`, duplicate.ID).Error; err != nil {
+		return nil, err
+	}
+
+	before := *survivor
+	if payload.FillEmptyFields {
+		if updates := fillEmptyCustomerFields(*survivor, duplicate); len(updates) > 0 {
+			if err := tx.Model(survivor).Updates(updates).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := tx.Delete(&duplicate).Error; err != nil {
+		return nil, err
+	}
+
+	history := []entity.HistoryCustomer{
+		{
+			CustomerID: survivor.ID,
+			UserID:     userID,
+			Status:     "Merged",
+			Notes:      fmt.Sprintf("Merged customer %s (%s, %s) into this customer", duplicate.Name, duplicate.Code, duplicate.ID),
+			EntityType: "customer",
+			EntityID:   duplicate.ID,
+			Action:     HistoryMerge,
+		},
+		{
+			CustomerID: duplicate.ID,
+			UserID:     userID,
+			Status:     "Merged",
+			Notes:      fmt.Sprintf("Merged into customer %s (%s, %s)", survivor.Name, survivor.Code, survivor.ID),
+			EntityType: "customer",
+			EntityID:   duplicate.ID,
+			Action:     HistoryMerge,
+		},
+	}
+	for i := range history {
+		if err := tx.Create(&history[i]).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Where("id = ?", survivor.ID).First(survivor).Error; err != nil {
+		return nil, err
+	}
+	// Field kosong yang diisi dari duplikat
+	return moved, recordCustomerChange(tx, userID, "Merged", &before, survivor)
+}
+
+// cancelMergedApprovals cancels the pending customer_delete and
+// customer_block requests of the duplicate, except approvalID
+func cancelMergedApprovals(tx *gorm.DB, userID, duplicateID, approvalID, survivorID string) error {
+	changes := tx.Model(&entity.CustomerStatusChange{}).Select("id").Where("customer_id = ?", duplicateID)
+	var requests []entity.ApprovalRequest
+	err := tx.Where("status = ? AND id <> ?", entity.ApprovalPending, approvalID).
+		Where(tx.Where("entity_type = ? AND entity_id = ?", "customers", duplicateID).
+			Or("entity_type = ? AND entity_id IN (?)", "customer_status_changes", changes)).
+		Find(&requests).Error
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, request := range requests {
+		action := entity.ApprovalAction{
+			ApprovalRequestID: request.ID,
+			StepID:            request.CurrentStepID,
+			Level:             request.CurrentLevel,
+			UserID:            userID,
+			Decision:          entity.ApprovalDecisionCancel,
+			Note:              "Cancelled by merge into " + survivorID,
+		}
+		if err := tx.Create(&action).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&entity.ApprovalRequest{}).Where("id = ?", request.ID).Updates(map[string]interface{}{
+			"status":      entity.ApprovalCancelled,
+			"decided_at":  now,
+			"step_due_at": nil,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/entity"
+	"customer-api/internal/query"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type customerExportColumn struct {
+	header   string
+	relation string // relation to preload, empty for customer fields
+	value    func(customer *entity.Customer) interface{}
+}
+
+var defaultCustomerExportColumns = []string{"id", "name", "brand_name", "code", "status"}
+
+var customerExportColumns = map[string]customerExportColumn{
+	"id":                 {header: "ID", value: func(c *entity.Customer) interface{} { return c.ID }},
+	"name":               {header: "Name", value: func(c *entity.Customer) interface{} { return c.Name }},
+	"brand_name":         {header: "Brand Name", value: func(c *entity.Customer) interface{} { return c.BrandName }},
+	"code":               {header: "Code", value: func(c *entity.Customer) interface{} { return c.Code }},
+	"account_manager_id": {header: "Account Manager ID", value: func(c *entity.Customer) interface{} { return c.AccountManagerId }},
+	"email":              {header: "Email", value: func(c *entity.Customer) interface{} { return c.Email }},
+	"phone":              {header: "Phone", value: func(c *entity.Customer) interface{} { return c.Phone }},
+	"website":            {header: "Website", value: func(c *entity.Customer) interface{} { return c.Website }},
+	"description":        {header: "Description", value: func(c *entity.Customer) interface{} { return c.Description }},
+	"status":             {header: "Status", value: func(c *entity.Customer) interface{} { return c.Status }},
+	"category":           {header: "Category", value: func(c *entity.Customer) interface{} { return c.Category }},
+	"rating":             {header: "Rating", value: func(c *entity.Customer) interface{} { return c.Rating }},
+	"average_cost":       {header: "Average Cost", value: func(c *entity.Customer) interface{} { return c.AverageCost }},
+	"created_at":         {header: "Created At", value: func(c *entity.Customer) interface{} { return c.CreatedAt }},
+	"updated_at":         {header: "Updated At", value: func(c *entity.Customer) interface{} { return c.UpdatedAt }},
+
+	// Alamat dan kontak dimuat dengan urutan main lebih dulu
+	"main_address": {header: "Main Address", relation: "Addresses", value: func(c *entity.Customer) interface{} {
+		if len(c.Addresses) == 0 {
+			return ""
+		}
+		return c.Addresses[0].Address
+	}},
+	"main_address_city": {header: "City", relation: "Addresses", value: func(c *entity.Customer) interface{} {
+		if len(c.Addresses) == 0 {
+			return ""
+		}
+		return c.Addresses[0].City
+	}},
+	"addresses": {header: "Addresses", relation: "Addresses", value: func(c *entity.Customer) interface{} {
+		values := make([]string, 0, len(c.Addresses))
+		for _, a := range c.Addresses {
+			values = append(values, a.Name+": "+a.Address)
+		}
+		return strings.Join(values, " | ")
+	}},
+	"main_contact": {header: "Main Contact", relation: "Contacts", value: func(c *entity.Customer) interface{} {
+		if len(c.Contacts) == 0 {
+			return ""
+		}
+		return c.Contacts[0].Name
+	}},
+	"main_contact_position": {header: "Main Contact Position", relation: "Contacts", value: func(c *entity.Customer) interface{} {
+		if len(c.Contacts) == 0 {
+			return ""
+		}
+		return c.Contacts[0].JobPosition
+	}},
+	"main_contact_email": {header: "Main Contact Email", relation: "Contacts", value: func(c *entity.Customer) interface{} {
+		if len(c.Contacts) == 0 {
+			return ""
+		}
+		return c.Contacts[0].Email
+	}},
+	"main_contact_phone": {header: "Main Contact Phone", relation: "Contacts", value: func(c *entity.Customer) interface{} {
+		if len(c.Contacts) == 0 {
+			return ""
+		}
+		return c.Contacts[0].Phone
+	}},
+	"main_contact_mobile": {header: "Main Contact Mobile", relation: "Contacts", value: func(c *entity.Customer) interface{} {
+		if len(c.Contacts) == 0 {
+			return ""
+		}
+		return c.Contacts[0].Mobile
+	}},
+	"contacts": {header: "Contacts", relation: "Contacts", value: func(c *entity.Customer) interface{} {
+		values := make([]string, 0, len(c.Contacts))
+		for _, contact := range c.Contacts {
+			value := contact.Name
+			if contact.Email != "" {
+				value += " <" + contact.Email + ">"
+			}
+			values = append(values, value)
+		}
+		return strings.Join(values, " | ")
+	}},
+	"sosmeds": {header: "Social Media", relation: "Sosmeds", value: func(c *entity.Customer) interface{} {
+		values := make([]string, 0, len(c.Sosmeds))
+		for _, s := range c.Sosmeds {
+			values = append(values, s.Platform+": "+s.Handle)
+		}
+		return strings.Join(values, " | ")
+	}},
+	"groups": {header: "Groups", relation: "Groups", value: func(c *entity.Customer) interface{} {
+		values := make([]string, 0, len(c.Groups))
+		for _, g := range c.Groups {
+			values = append(values, g.NameGroup)
+		}
+		return strings.Join(values, ", ")
+	}},
+}
+
+// customerExportColumnKeys lists the selectable columns for error messages
+func customerExportColumnKeys() []string {
+	keys := make([]string, 0, len(customerExportColumns)+1)
+	for key := range customerExportColumns {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return append(keys, "other.<key>")
+}
+
+// resolveCustomerExportColumns parses "name,code,other.company_size". Columns
+// other.<key> export the value of the customer's Other attribute with that key.
+func resolveCustomerExportColumns(raw string) ([]string, []customerExportColumn, error) {
+	keys := defaultCustomerExportColumns
+	if strings.TrimSpace(raw) != "" {
+		keys = nil
+		for _, key := range strings.Split(raw, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	var selected []string
+	var columns []customerExportColumn
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if otherKey, ok := strings.CutPrefix(key, "other."); ok && otherKey != "" {
+			selected = append(selected, key)
+			columns = append(columns, customerExportColumn{header: otherKey, relation: "Others", value: func(c *entity.Customer) interface{} {
+				for _, other := range c.Others {
+					if other.Key == otherKey && other.Active {
+						return stringValue(other.Value)
+					}
+				}
+				return ""
+			}})
+			continue
+		}
+		column, ok := customerExportColumns[key]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown column %q, available: %s", key, strings.Join(customerExportColumnKeys(), ", "))
+		}
+		selected = append(selected, key)
+		columns = append(columns, column)
+	}
+	return selected, columns, nil
+}
+
+// writeCustomerExport streams the filtered customers to w in batches and
+// returns the number of exported customers
+func writeCustomerExport(db *gorm.DB, params query.Params, format string, keys []string, columns []customerExportColumn, w io.Writer, progress func(done int)) (int, error) {
+	headers := make([]string, len(columns))
+	relations := make(map[string]bool)
+	for i, column := range columns {
+		headers[i] = column.header
+		if column.relation != "" {
+			relations[column.relation] = true
+		}
+	}
+
+	out, err := newExportWriter(format, w, "Customers", keys, headers)
+	if err != nil {
+		return 0, err
+	}
+
+	base := exportQuery(db, customerListSpec, params, &entity.Customer{})
+	for relation := range relations {
+		switch relation {
+		case "Addresses", "Contacts":
+			base = base.Preload(relation, func(tx *gorm.DB) *gorm.DB {
+				return tx.Order("main DESC, created_at")
+			})
+		default:
+			base = base.Preload(relation)
+		}
+	}
+
+	return writeExportBatches(out, base, func(batch *gorm.DB) ([][]interface{}, error) {
+		var customers []entity.Customer
+		if err := batch.Find(&customers).Error; err != nil {
+			return nil, err
+		}
+		rows := make([][]interface{}, 0, len(customers))
+		for i := range customers {
+			row := make([]interface{}, len(columns))
+			for j, column := range columns {
+				row[j] = column.value(&customers[i])
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}, progress)
+}
+
+// @Summary Export customers
+// @Description Export customers as Excel, PDF, CSV or JSON lines. Accepts the same filters and sort as GET /customers
+// @Description (page and page_size are ignored). CSV and JSON lines are streamed row by row.
+// @Tags Customers
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/pdf
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Param type query string true "Export format" Enums(excel, pdf, csv, jsonl)
+// @Param columns query string false "Comma separated columns, e.g. name,code,main_contact_email,groups,other.company_size (default id,name,brand_name,code,status)"
+// @Param status query string false "Filter by status"
+// @Param sort query string false "Sort, e.g. -created_at,name"
+// @Success 200 {file} file
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/customers/export [get]
+func ExportCustomers(c *gin.Context) {
+	exportType := c.Query("type")
+	format, ok := exportFormats[exportType]
+	if !ok {
+		sendError(c, http.StatusBadRequest, "Invalid export type (must be 'excel', 'pdf', 'csv' or 'jsonl')")
+		return
+	}
+
+	params, ok := parseList(c, customerListSpec)
+	if !ok {
+		return
+	}
+	keys, columns, err := resolveCustomerExportColumns(c.Query("columns"))
+	if err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filename := "customers-" + time.Now().Format("20060102") + format.extension
+	c.Header("Content-Type", format.contentType)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+
+	_, err = writeCustomerExport(config.DB, params, exportType, keys, columns, c.Writer, func(int) { c.Writer.Flush() })
+	if err != nil {
+		// Setelah data mulai terkirim status dan header tidak bisa diubah lagi
+		if c.Writer.Written() {
+			log.Printf("customer export interrupted: %v", err)
+			c.Abort()
+			return
+		}
+		c.Header("Content-Type", "")
+		c.Header("Content-Disposition", "")
+		sendError(c, http.StatusInternalServerError, "Failed to export customers: "+err.Error())
+	}
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"customer-api/internal/audit"
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// History actions
+const (
+	HistoryCreate  = "create"
+	HistoryUpdate  = "update"
+	HistoryDelete  = "delete"
+	HistoryMerge   = "merge"
+	HistoryRestore = "restore"
+)
+
+var historyStatuses = map[string]string{
+	HistoryCreate:  "Created",
+	HistoryUpdate:  "Updated",
+	HistoryDelete:  "Deleted",
+	HistoryMerge:   "Merged",
+	HistoryRestore: "Restored",
+}
+
+// historySubject returns the entity type, id, owning customer and a readable
+// label of a customer or child record
+func historySubject(record interface{}) (entityType, entityID, customerID, label string, err error) {
+	switch r := record.(type) {
+	case *entity.Customer:
+		return "customer", r.ID, r.ID, r.Name, nil
+	case *entity.Address:
+		return "address", r.ID, r.CustomerID, r.Name, nil
+	case *entity.Contact:
+		return "contact", r.ID, r.CustomerID, r.Name, nil
+	case *entity.Sosmed:
+		return "sosmed", r.ID, r.CustomerID, r.Platform + " " + r.Handle, nil
+	case *entity.Structure:
+		return "structure", r.ID, r.CustomerID, r.Name, nil
+	case *entity.Other:
+		return "other", r.ID, r.CustomerID, r.Key, nil
+	}
+	return "", "", "", "", fmt.Errorf("history: unsupported record %T", record)
+}
+
+// historySummary builds the readable note, e.g. "Updated contact Budi: email, phone"
+func historySummary(action, entityType, label string, changes []dto.FieldChange) string {
+	summary := historyStatuses[action] + " " + entityType
+	if label = strings.TrimSpace(label); label != "" {
+		summary += " " + label
+	}
+	if action == HistoryUpdate {
+		names := make([]string, len(changes))
+		for i, change := range changes {
+			names[i] = change.Field
+		}
+		summary += ": " + strings.Join(names, ", ")
+	}
+	return summary
+}
+
+// recordCustomerChange writes the history entry for a customer or one of its
+// child records (pointers to the entity). before is nil for created records
+// and after for deleted ones; an update that changes no field is not recorded.
+// status overrides the default label such as "Updated".
+func recordCustomerChange(tx *gorm.DB, userID, status string, before, after interface{}) error {
+	action := HistoryUpdate
+	switch {
+	case before == nil:
+		action = HistoryCreate
+	case after == nil:
+		action = HistoryDelete
+	}
+	return recordCustomerAction(tx, userID, action, status, before, after)
+}
+
+// recordCustomerAction is recordCustomerChange with an explicit action, for
+// changes that are not a plain create/update/delete such as a restore
+func recordCustomerAction(tx *gorm.DB, userID, action, status string, before, after interface{}) error {
+	record := after
+	if record == nil {
+		record = before
+	}
+
+	entityType, entityID, customerID, label, err := historySubject(record)
+	if err != nil {
+		return err
+	}
+	changes := audit.Diff(before, after)
+	if action == HistoryUpdate && len(changes) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	if status == "" {
+		status = historyStatuses[action]
+	}
+
+	history := entity.HistoryCustomer{
+		CustomerID: customerID,
+		UserID:     userID,
+		Status:     status,
+		Notes:      historySummary(action, entityType, label, changes),
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Changes:    string(data),
+	}
+	return tx.Create(&history).Error
+}
+
+func toCustomerHistoryEntry(h entity.HistoryCustomer) dto.CustomerHistoryEntry {
+	entry := dto.CustomerHistoryEntry{
+		ID:         h.ID,
+		Action:     h.Action,
+		Status:     h.Status,
+		EntityType: h.EntityType,
+		EntityID:   h.EntityID,
+		Summary:    h.Notes,
+		Changes:    []dto.FieldChange{},
+		User:       dto.HistoryUser{ID: h.UserID, Username: h.User.Username},
+		CreatedAt:  h.CreatedAt,
+	}
+	if h.Changes != "" {
+		json.Unmarshal([]byte(h.Changes), &entry.Changes)
+	}
+	return entry
+}
+
+// @Summary Get customer history
+// @Description Timeline of changes to a customer and its addresses, contacts, social media, structures and other attributes,
+// @Description with the before/after value of every changed field and the acting user. Newest first by default.
+// @Tags Customers
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param entity_type query string false "Filter by record type" Enums(customer, address, contact, sosmed, structure, other)
+// @Param action query string false "Filter by action" Enums(create, update, delete, merge, restore)
+// @Param user_id query string false "Filter by acting user"
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 20, max 200)"
+// @Param sort query string false "Sort, e.g. created_at for oldest first"
+// @Success 200 {array} dto.CustomerHistoryEntry
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/customers/{id}/history [get]
+func GetCustomerHistory(c *gin.Context) {
+	// Riwayat customer yang sudah dihapus tetap bisa dilihat
+	var customer entity.Customer
+	if err := config.DB.Unscoped().Where("id = ?", c.Param("id")).First(&customer).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Customer not found")
+		return
+	}
+
+	params, ok := parseList(c, historyListSpec)
+	if !ok {
+		return
+	}
+
+	var history []entity.HistoryCustomer
+	page, err := historyListSpec.Find(config.DB.Where("customer_id = ?", customer.ID), params, &history, func(tx *gorm.DB) *gorm.DB {
+		return tx.Preload("User")
+	})
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch customer history")
+		return
+	}
+
+	data := make([]dto.CustomerHistoryEntry, 0, len(history))
+	for _, h := range history {
+		data = append(data, toCustomerHistoryEntry(h))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"message":    "Customer history fetched successfully",
+		"data":       data,
+		"pagination": page,
+	})
+}
@@ -0,0 +1,479 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	maxImportFileSize = 10 << 20
+	maxImportRows     = 5000
+
+	ImportStatusValid   = "valid"
+	ImportStatusCreated = "created"
+	ImportStatusFailed  = "failed"
+	ImportStatusSkipped = "skipped"
+)
+
+// Satu baris = satu customer beserta (opsional) satu alamat, satu kontak dan
+// satu sosmed. Baris lain dengan code yang sama menambah alamat/kontak/sosmed
+// ke customer tersebut; alamat dan kontak pertama menjadi main.
+var customerImportColumns = []string{
+	"code", "name", "brand_name", "account_manager_id",
+	"address_name", "address",
+	"contact_name", "contact_job_position", "contact_email", "contact_phone", "contact_mobile", "contact_birthdate",
+	"sosmed_platform", "sosmed_handle",
+}
+
+type importRow struct {
+	values map[string]string
+	result dto.CustomerImportRow
+}
+
+func (r *importRow) get(column string) string {
+	return strings.TrimSpace(r.values[column])
+}
+
+func (r *importRow) fail(format string, args ...interface{}) {
+	r.result.Status = ImportStatusFailed
+	r.result.Errors = append(r.result.Errors, fmt.Sprintf(format, args...))
+}
+
+// importGroup is every row of one customer code
+type importGroup struct {
+	code string
+	rows []*importRow
+}
+
+func (g *importGroup) failed() bool {
+	for _, row := range g.rows {
+		if row.result.Status == ImportStatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeImportHeader turns "Contact Email*" into "contact_email"
+func normalizeImportHeader(header string) string {
+	header = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header, "\ufeff")))
+	header = strings.TrimSuffix(header, "*")
+	return strings.Join(strings.Fields(header), "_")
+}
+
+// readImportFile returns all rows (header first) of an .xlsx or .csv upload
+func readImportFile(r io.Reader, filename string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return f.GetRows(f.GetSheetName(0))
+
+	case ".csv":
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.FieldsPerRecord = -1
+		// Excel dengan locale Indonesia menyimpan CSV dengan pemisah ';'
+		firstLine, _, _ := strings.Cut(string(data), "\n")
+		if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+			reader.Comma = ';'
+		}
+		return reader.ReadAll()
+	}
+	return nil, fmt.Errorf("unsupported file type, use .xlsx or .csv")
+}
+
+// validateImportRow checks the fields of a single row
+func validateImportRow(row *importRow) {
+	if row.get("code") == "" {
+		row.fail("code is required")
+	}
+
+	if row.get("address_name") != "" || row.get("address") != "" {
+		if row.get("address_name") == "" {
+			row.fail("address_name is required when address is filled")
+		}
+		if row.get("address") == "" {
+			row.fail("address is required when address_name is filled")
+		}
+	}
+
+	hasContact := false
+	for _, column := range []string{"contact_name", "contact_job_position", "contact_email", "contact_phone", "contact_mobile", "contact_birthdate"} {
+		if row.get(column) != "" {
+			hasContact = true
+		}
+	}
+	if hasContact && row.get("contact_name") == "" {
+		row.fail("contact_name is required when other contact fields are filled")
+	}
+	if email := row.get("contact_email"); email != "" {
+		if _, err := mail.ParseAddress(email); err != nil {
+			row.fail("contact_email %q is not a valid email", email)
+		}
+	}
+	if birthdate := row.get("contact_birthdate"); birthdate != "" {
+		if _, err := time.Parse("2006-01-02", birthdate); err != nil {
+			row.fail("contact_birthdate must use format YYYY-MM-DD")
+		}
+	}
+
+	if (row.get("sosmed_platform") == "") != (row.get("sosmed_handle") == "") {
+		row.fail("sosmed_platform and sosmed_handle must be filled together")
+	}
+}
+
+// buildImportRequest merges the rows of one customer into the same request
+// CreateCustomer accepts
+func buildImportRequest(group *importGroup) dto.CreateCustomerRequest {
+	first := group.rows[0]
+	name, brandName, code, manager := "", first.get("brand_name"), group.code, ""
+	// Baris lanjutan boleh mengosongkan name dan account_manager_id
+	for _, row := range group.rows {
+		if name == "" {
+			name = row.get("name")
+		}
+		if manager == "" {
+			manager = row.get("account_manager_id")
+		}
+	}
+	req := dto.CreateCustomerRequest{Name: &name, BrandName: &brandName, Code: &code, AccountManagerId: &manager}
+
+	for _, row := range group.rows {
+		if row.get("address") != "" {
+			req.Addresses = append(req.Addresses, dto.CreateAddressRequest{
+				Name:    row.get("address_name"),
+				Address: row.get("address"),
+				IsMain:  len(req.Addresses) == 0,
+				Active:  true,
+			})
+		}
+		if row.get("contact_name") != "" {
+			req.Contacts = append(req.Contacts, dto.CreateContactRequest{
+				Name:        row.get("contact_name"),
+				JobPosition: row.get("contact_job_position"),
+				Email:       row.get("contact_email"),
+				Phone:       row.get("contact_phone"),
+				Mobile:      row.get("contact_mobile"),
+				Birthdate:   row.get("contact_birthdate"),
+				IsMain:      len(req.Contacts) == 0,
+				Active:      true,
+			})
+		}
+		if row.get("sosmed_platform") != "" {
+			req.Socials = append(req.Socials, dto.CreateSocialRequest{
+				Platform: row.get("sosmed_platform"),
+				Handle:   row.get("sosmed_handle"),
+				Active:   true,
+			})
+		}
+	}
+	return req
+}
+
+// validateImportGroups checks rules that span rows or need the database
+func validateImportGroups(groups []*importGroup) error {
+	codes := make([]string, 0, len(groups))
+	managers := make(map[string]bool)
+	for _, group := range groups {
+		codes = append(codes, group.code)
+
+		name, manager, managerRow := "", "", 0
+		for _, row := range group.rows {
+			if rowManager := row.get("account_manager_id"); rowManager != "" {
+				managers[rowManager] = true
+				if manager == "" {
+					manager, managerRow = rowManager, row.result.Row
+				} else if rowManager != manager {
+					row.fail("account_manager_id %q conflicts with %q in row %d for the same code", rowManager, manager, managerRow)
+				}
+			}
+
+			rowName := row.get("name")
+			if rowName == "" {
+				continue
+			}
+			if name == "" {
+				name = rowName
+			} else if !strings.EqualFold(rowName, name) {
+				row.fail("name %q conflicts with %q in row %d for the same code", rowName, name, group.rows[0].result.Row)
+			}
+		}
+		if name == "" {
+			group.rows[0].fail("name is required")
+		}
+	}
+
+	// Code unik termasuk customer yang sudah di-soft delete
+	var existing []string
+	if err := config.DB.Unscoped().Model(&entity.Customer{}).Where("code IN ?", codes).Pluck("code", &existing).Error; err != nil {
+		return err
+	}
+	taken := make(map[string]bool)
+	for _, code := range existing {
+		taken[code] = true
+	}
+
+	knownManagers := make(map[string]bool)
+	if len(managers) > 0 {
+		ids := make([]string, 0, len(managers))
+		for id := range managers {
+			ids = append(ids, id)
+		}
+		var found []string
+		if err := config.DB.Model(&entity.User{}).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+			return err
+		}
+		for _, id := range found {
+			knownManagers[id] = true
+		}
+	}
+
+	for _, group := range groups {
+		if taken[group.code] {
+			group.rows[0].fail("customer with code %q already exists", group.code)
+		}
+		for _, row := range group.rows {
+			if manager := row.get("account_manager_id"); manager != "" && !knownManagers[manager] {
+				row.fail("account_manager_id %q is not a known user", manager)
+			}
+		}
+	}
+	return nil
+}
+
+// @Summary Import customers
+// @Description Bulk create customers with addresses, contacts and social media from an .xlsx or .csv file.
+// @Description Every row is validated; with dry_run=true nothing is saved. Rows sharing a code belong to one customer.
+// @Description Use report=excel or report=csv to download the per-row result instead of JSON.
+// @Tags Customers
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Customers file (.xlsx or .csv)"
+// @Param dry_run query bool false "Validate only, do not save"
+// @Param report query string false "Report format" Enums(json, excel, csv)
+// @Success 200 {object} dto.CustomerImportResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/customers/import [post]
+func ImportCustomers(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true" || c.PostForm("dry_run") == "true"
+	reportFormat := c.DefaultQuery("report", "json")
+	if reportFormat != "json" && reportFormat != "excel" && reportFormat != "csv" {
+		sendError(c, http.StatusBadRequest, "Invalid report (must be 'json', 'excel' or 'csv')")
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		sendError(c, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "file is required")
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		sendError(c, http.StatusBadRequest, "file is too large (max 10 MB)")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "Failed to read file")
+		return
+	}
+	defer file.Close()
+
+	records, err := readImportFile(file, fileHeader.Filename)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "Failed to read file: "+err.Error())
+		return
+	}
+	if len(records) < 2 {
+		sendError(c, http.StatusBadRequest, "file has no data rows")
+		return
+	}
+	if len(records)-1 > maxImportRows {
+		sendError(c, http.StatusBadRequest, fmt.Sprintf("file has too many rows (max %d)", maxImportRows))
+		return
+	}
+
+	headers := make([]string, len(records[0]))
+	present := make(map[string]bool)
+	for i, header := range records[0] {
+		headers[i] = normalizeImportHeader(header)
+		present[headers[i]] = true
+	}
+	for _, required := range []string{"code", "name"} {
+		if !present[required] {
+			sendError(c, http.StatusBadRequest, "missing required column: "+required)
+			return
+		}
+	}
+
+	var rows []*importRow
+	var groups []*importGroup
+	byCode := make(map[string]*importGroup)
+	for i, record := range records[1:] {
+		row := &importRow{values: make(map[string]string)}
+		empty := true
+		for col, value := range record {
+			if col < len(headers) && headers[col] != "" {
+				row.values[headers[col]] = value
+				if strings.TrimSpace(value) != "" {
+					empty = false
+				}
+			}
+		}
+		if empty {
+			continue
+		}
+		// Nomor baris sesuai spreadsheet (header = baris 1)
+		row.result = dto.CustomerImportRow{Row: i + 2, Code: row.get("code"), Name: row.get("name"), Status: ImportStatusValid}
+		validateImportRow(row)
+		rows = append(rows, row)
+
+		code := row.get("code")
+		if code == "" {
+			continue
+		}
+		group, ok := byCode[code]
+		if !ok {
+			group = &importGroup{code: code}
+			byCode[code] = group
+			groups = append(groups, group)
+		}
+		group.rows = append(group.rows, row)
+	}
+
+	if err := validateImportGroups(groups); err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to validate import: "+err.Error())
+		return
+	}
+
+	response := dto.CustomerImportResponse{DryRun: dryRun, TotalRows: len(rows), TotalCustomers: len(groups)}
+	for _, group := range groups {
+		if group.failed() {
+			// Customer hanya dibuat jika semua barisnya valid
+			for _, row := range group.rows {
+				if row.result.Status != ImportStatusFailed {
+					row.result.Status = ImportStatusSkipped
+					row.result.Errors = append(row.result.Errors, "another row of this customer has errors")
+				}
+			}
+			continue
+		}
+		response.ValidCustomers++
+		if dryRun {
+			continue
+		}
+
+		customer, err := createCustomerRecords(config.DB, buildImportRequest(group), userID)
+		for _, row := range group.rows {
+			if err != nil {
+				row.fail("%s", err.Error())
+				continue
+			}
+			row.result.Status = ImportStatusCreated
+			row.result.CustomerID = customer.ID
+		}
+		if err == nil {
+			response.CreatedCustomers++
+		}
+	}
+
+	response.Rows = make([]dto.CustomerImportRow, 0, len(rows))
+	for _, row := range rows {
+		if row.result.Status == ImportStatusFailed || row.result.Status == ImportStatusSkipped {
+			response.FailedRows++
+		}
+		response.Rows = append(response.Rows, row.result)
+	}
+
+	if reportFormat == "json" {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Customer import processed",
+			"data":    response,
+		})
+		return
+	}
+
+	reportHeaders := []string{"Row", "Code", "Name", "Status", "Customer ID", "Errors"}
+	reportRows := make([][]interface{}, 0, len(response.Rows))
+	for _, row := range response.Rows {
+		reportRows = append(reportRows, []interface{}{row.Row, row.Code, row.Name, row.Status, row.CustomerID, strings.Join(row.Errors, "; ")})
+	}
+	filename := "customer-import-report-" + time.Now().Format("20060102150405")
+
+	if reportFormat == "excel" {
+		data, err := createExcelTable("Import Report", reportHeaders, reportRows)
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, "Failed to create excel file: "+err.Error())
+			return
+		}
+		sendFile(c, data, filename+".xlsx", mimeExcel)
+		return
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(reportHeaders)
+	for _, row := range reportRows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = fmt.Sprint(value)
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	sendFile(c, buf.Bytes(), filename+".csv", "text/csv")
+}
+
+// @Summary Download customer import template
+// @Description Excel template with the columns accepted by the customer import
+// @Tags Customers
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Success 200 {file} file
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/customers/import/template [get]
+func GetCustomerImportTemplate(c *gin.Context) {
+	examples := [][]interface{}{
+		{"TM001", "PT Teknologi Maju", "TechMaju", "", "Head Office", "Jl. Sudirman No. 123, Jakarta Selatan",
+			"Budi Santoso", "CEO", "budi@teknologimaju.com", "021-5551234", "0812-3456-7890", "1985-03-15", "Instagram", "@techmaju"},
+		{"TM001", "", "", "", "Branch Bandung", "Jl. Asia Afrika No. 8, Bandung",
+			"Siti Rahma", "Finance Manager", "siti@teknologimaju.com", "", "", "", "", ""},
+	}
+	data, err := createExcelTable("Customers", customerImportColumns, examples)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to create excel file: "+err.Error())
+		return
+	}
+	sendFile(c, data, "customer-import-template.xlsx", mimeExcel)
+}
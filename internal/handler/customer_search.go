@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"html"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// Ambang kemiripan trigram untuk menandai kata yang salah ketik
+	highlightSimilarity = 0.45
+	snippetMaxLength    = 120
+)
+
+var searchWordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// searchTerms splits the query into lowercase words, dropping punctuation so
+// the terms are safe to use inside to_tsquery
+func searchTerms(q string) []string {
+	return searchWordPattern.FindAllString(strings.ToLower(q), -1)
+}
+
+// prefixTSQuery builds "term1:* & term2:*" so partially typed words match
+func prefixTSQuery(terms []string) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		parts = append(parts, term+":*")
+	}
+	return strings.Join(parts, " & ")
+}
+
+// trigrams mirrors pg_trgm: the word is padded with two leading and one trailing space
+func trigrams(word string) map[string]bool {
+	runes := []rune("  " + word + " ")
+	set := make(map[string]bool)
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = true
+	}
+	return set
+}
+
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	union := len(ta) + len(tb) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+func wordMatches(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+		if len([]rune(term)) >= 3 && (strings.Contains(word, term) || trigramSimilarity(term, word) >= highlightSimilarity) {
+			return true
+		}
+	}
+	return false
+}
+
+// highlight wraps matching words of value in <mark> and returns a snippet
+// around the first match, or false when nothing in value matches. The rest of
+// the snippet is HTML-escaped.
+func highlight(value string, terms []string) (string, bool) {
+	var locs [][]int
+	for _, loc := range searchWordPattern.FindAllStringIndex(value, -1) {
+		if wordMatches(strings.ToLower(value[loc[0]:loc[1]]), terms) {
+			locs = append(locs, loc)
+		}
+	}
+	if len(locs) == 0 {
+		return "", false
+	}
+
+	// Nilai panjang (mis. alamat) dipotong di sekitar kecocokan pertama
+	start, end := 0, len(value)
+	if len(value) > snippetMaxLength {
+		if start = locs[0][0] - 40; start > 0 {
+			if space := strings.IndexByte(value[start:locs[0][0]], ' '); space >= 0 {
+				start += space + 1
+			}
+			// Jangan memotong di tengah karakter multibyte
+			for start < locs[0][0] && !utf8.RuneStart(value[start]) {
+				start++
+			}
+		} else {
+			start = 0
+		}
+		if end = start + snippetMaxLength; end < len(value) {
+			if end < locs[0][1] {
+				end = locs[0][1]
+			} else if space := strings.LastIndexByte(value[locs[0][1]:end], ' '); space >= 0 {
+				end = locs[0][1] + space
+			}
+			for end > locs[0][1] && !utf8.RuneStart(value[end]) {
+				end--
+			}
+		} else {
+			end = len(value)
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	last := start
+	for _, loc := range locs {
+		if loc[0] < start || loc[1] > end {
+			continue
+		}
+		// Hanya <mark> yang boleh menjadi HTML, isi customer di-escape
+		b.WriteString(html.EscapeString(value[last:loc[0]]))
+		b.WriteString("<mark>" + html.EscapeString(value[loc[0]:loc[1]]) + "</mark>")
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(value[last:end]))
+	if end < len(value) {
+		b.WriteString(" …")
+	}
+	return b.String(), true
+}
+
+// searchMatches lists every field of the customer that matches the terms
+func searchMatches(customer entity.Customer, terms []string) []dto.CustomerSearchMatch {
+	matches := make([]dto.CustomerSearchMatch, 0)
+	add := func(field, value string) {
+		if snippet, ok := highlight(value, terms); ok {
+			matches = append(matches, dto.CustomerSearchMatch{Field: field, Value: value, Snippet: snippet})
+		}
+	}
+
+	add("name", customer.Name)
+	add("brand_name", customer.BrandName)
+	add("code", customer.Code)
+	for _, contact := range customer.Contacts {
+		add("contact.name", contact.Name)
+		add("contact.email", contact.Email)
+		add("contact.phone", contact.Phone)
+		add("contact.mobile", contact.Mobile)
+	}
+	for _, address := range customer.Addresses {
+		add("address.city", address.City)
+		add("address.address", address.Address)
+	}
+	return matches
+}
+
+// @Summary Search customers
+// @Description Full-text search over customer name, brand name, code, contact name/email/phone and address city/address,
+// @Description with trigram matching for typos. Results are ranked and list the fields that matched with <mark> highlights.
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Search text (min 2 characters)"
+// @Param limit query int false "Max results (default 20, max 100)"
+// @Success 200 {array} dto.CustomerSearchResult
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/customers/search [get]
+func SearchCustomers(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	terms := searchTerms(q)
+	if len([]rune(q)) < 2 || len(terms) == 0 {
+		sendError(c, http.StatusBadRequest, "q must contain at least 2 characters")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			sendError(c, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		if n > maxSearchLimit {
+			n = maxSearchLimit
+		}
+		limit = n
+	}
+
+	var hits []struct {
+		CustomerID string
+		TextRank   float64
+		Similarity float64
+	}
+	tsquery, text := prefixTSQuery(terms), strings.ToLower(q)
+	err := config.DB.Raw(`SELECT * FROM (
+			SELECT s.customer_id,
+				ts_rank_cd(s.search_vector, to_tsquery('simple', ?)) AS text_rank,
+				word_similarity(?, s.document) AS similarity
+			FROM customer_search_index s
+			WHERE s.search_vector @@ to_tsquery('simple', ?) OR ? <% s.document
+		) hits
+		ORDER BY text_rank + similarity DESC, customer_id
+		LIMIT ?`, tsquery, text, tsquery, text, limit).
+		Scan(&hits).Error
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to search customers: "+err.Error())
+		return
+	}
+
+	results := make([]dto.CustomerSearchResult, 0, len(hits))
+	if len(hits) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "No customers found",
+			"data":    results,
+		})
+		return
+	}
+
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.CustomerID)
+	}
+	var customers []entity.Customer
+	if err := config.DB.Preload("Contacts").Preload("Addresses").Where("id IN ?", ids).Find(&customers).Error; err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch customers")
+		return
+	}
+	byID := make(map[string]entity.Customer, len(customers))
+	for _, customer := range customers {
+		byID[customer.ID] = customer
+	}
+
+	for _, hit := range hits {
+		customer, ok := byID[hit.CustomerID]
+		if !ok {
+			continue
+		}
+		results = append(results, dto.CustomerSearchResult{
+			Customer: toCustomerResponse(customer),
+			Score:   roundScore(hit.TextRank + hit.Similarity),
+			Matches: searchMatches(customer, terms),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Customers found",
+		"data":    results,
+	})
+}
+
+func roundScore(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,743 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"customer-api/internal/audit"
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+	"customer-api/internal/query"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// statusTransition lists what a move between two customer statuses needs
+type statusTransition struct {
+	requireReason   bool
+	requireDocument bool
+	requireApproval bool // disetujui user lain, bukan yang meminta
+}
+
+// customerStatusTransitions is the customer status machine. A move that is
+// not listed here is rejected.
+var customerStatusTransitions = map[string]map[string]statusTransition{
+	entity.CustomerStatusDraft: {
+		entity.CustomerStatusActive:   {},
+		entity.CustomerStatusInactive: {requireReason: true},
+	},
+	entity.CustomerStatusActive: {
+		entity.CustomerStatusInactive: {requireReason: true},
+		entity.CustomerStatusBlocked:  {requireReason: true, requireDocument: true, requireApproval: true},
+	},
+	entity.CustomerStatusInactive: {
+		entity.CustomerStatusActive:  {requireReason: true},
+		entity.CustomerStatusBlocked: {requireReason: true, requireDocument: true, requireApproval: true},
+	},
+	entity.CustomerStatusBlocked: {
+		entity.CustomerStatusActive:   {requireReason: true, requireApproval: true},
+		entity.CustomerStatusInactive: {requireReason: true, requireApproval: true},
+	},
+}
+
+var customerStatuses = []string{
+	entity.CustomerStatusDraft,
+	entity.CustomerStatusActive,
+	entity.CustomerStatusInactive,
+	entity.CustomerStatusBlocked,
+}
+
+// Scheduler memeriksa perubahan status terjadwal setiap menit
+const customerStatusSchedulerInterval = time.Minute
+
+var (
+	errStatusChanged      = errors.New("customer status changed before the change was applied")
+	errStatusChangeClosed = errors.New("status change is no longer open")
+	errStatusChangeHeld   = errors.New("status change is held by an approval request")
+	errStatusChangeOpen   = errors.New("customer already has a status change waiting for approval")
+)
+
+var statusChangeListSpec = query.Spec{
+	Table: "customer_status_changes",
+	Fields: map[string]query.Field{
+		"state":        {},
+		"from_status":  {},
+		"to_status":    {},
+		"requested_by": {},
+		"effective_at": {Type: query.Time},
+		"created_at":   {Type: query.Time},
+	},
+	DefaultSort: "-created_at",
+}
+
+// normalizeCustomerStatus maps a status in any casing to its canonical form
+func normalizeCustomerStatus(status string) (string, bool) {
+	for _, s := range customerStatuses {
+		if strings.EqualFold(strings.TrimSpace(status), s) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// parseStatusTime accepts RFC3339 or a plain date (local midnight)
+func parseStatusTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// applyStatusChange moves the customer to change.ToStatus, records the reason
+// and history and marks the change applied. A change with RevertAt schedules
+// the move back, e.g. an automatic unblock. Returns errStatusChanged when the
+// customer is no longer in change.FromStatus.
+func applyStatusChange(tx *gorm.DB, change *entity.CustomerStatusChange) error {
+	var customer entity.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", change.CustomerID).First(&customer).Error; err != nil {
+		return err
+	}
+	if current, _ := normalizeCustomerStatus(customer.Status); current != change.FromStatus {
+		return errStatusChanged
+	}
+
+	before := customer
+	customer.Status = change.ToStatus
+	if err := tx.Omit(clause.Associations).Save(&customer).Error; err != nil {
+		return err
+	}
+
+	// Hanya alasan status terakhir yang aktif
+	if err := tx.Model(&entity.StatusReasons{}).Where("customer_id = ? AND is_active = ?", customer.ID, true).
+		Update("is_active", false).Error; err != nil {
+		return err
+	}
+	statusReason := entity.StatusReasons{
+		CustomerID: customer.ID,
+		Reason:     change.Reason,
+		Status:     change.ToStatus,
+		IsActive:   true,
+	}
+	if err := tx.Create(&statusReason).Error; err != nil {
+		return err
+	}
+	if err := recordCustomerChange(tx, change.RequestedBy, "Status Changed", &before, &customer); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	change.State = entity.StatusChangeApplied
+	change.AppliedAt = &now
+	if err := tx.Save(change).Error; err != nil {
+		return err
+	}
+
+	if change.RevertAt == nil {
+		return nil
+	}
+	// Perpindahan balik sudah ikut disetujui bersama perubahan ini
+	revert := entity.CustomerStatusChange{
+		CustomerID:  customer.ID,
+		FromStatus:  change.ToStatus,
+		ToStatus:    change.FromStatus,
+		Reason:      fmt.Sprintf("Automatic revert of status change %s", change.ID),
+		State:       entity.StatusChangeScheduled,
+		EffectiveAt: change.RevertAt,
+		RequestedBy: change.RequestedBy,
+		DecidedBy:   change.DecidedBy,
+		DecidedAt:   change.DecidedAt,
+	}
+	return tx.Create(&revert).Error
+}
+
+// markStatusChangeFailed closes a change that could not be applied because
+// the customer status moved on in the meantime
+func markStatusChangeFailed(db *gorm.DB, id string, cause error) {
+	db.Model(&entity.CustomerStatusChange{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"state": entity.StatusChangeFailed,
+		"error": cause.Error(),
+	})
+}
+
+// lockOpenStatusChange loads a change of the customer for update and checks
+// that it is still in one of the given states
+func lockOpenStatusChange(tx *gorm.DB, customerID, id string, change *entity.CustomerStatusChange, states ...string) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND customer_id = ?", id, customerID).First(change).Error
+	if err != nil {
+		return err
+	}
+	for _, state := range states {
+		if change.State == state {
+			return nil
+		}
+	}
+	return errStatusChangeClosed
+}
+
+// @Summary Change customer status
+// @Description Move a customer through the status machine (see GET /api/customers/status-transitions).
+// @Description Moves that require approval wait for another user, moves with effective_at in the future are
+// @Description applied by the scheduler. revert_at schedules the move back, e.g. block until a date.
+// @Tags Customers
+// @Accept json,mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param request body dto.CustomerStatusChangeRequest true "Status change"
+// @Success 200 {object} entity.CustomerStatusChange "Applied"
+// @Success 202 {object} entity.CustomerStatusChange "Waiting for approval or scheduled"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /api/customers/{id}/status [post]
+func UpdateCustomerStatus(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req dto.CustomerStatusChangeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	status, ok := normalizeCustomerStatus(req.Status)
+	if !ok {
+		sendError(c, http.StatusBadRequest, "Invalid status, use one of "+strings.Join(customerStatuses, ", "))
+		return
+	}
+
+	var customer entity.Customer
+	if err := config.DB.Where("id = ?", c.Param("id")).First(&customer).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Customer not found")
+		return
+	}
+	current, _ := normalizeCustomerStatus(customer.Status)
+	if current == status {
+		sendError(c, http.StatusConflict, "Customer is already "+status)
+		return
+	}
+	rule, ok := customerStatusTransitions[current][status]
+	if !ok {
+		sendError(c, http.StatusConflict, fmt.Sprintf("Cannot change status from %s to %s", customer.Status, status))
+		return
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if rule.requireReason && reason == "" {
+		sendError(c, http.StatusBadRequest, fmt.Sprintf("Reason is required to change status from %s to %s", current, status))
+		return
+	}
+	file, _ := c.FormFile("file")
+	if rule.requireDocument && file == nil {
+		sendError(c, http.StatusBadRequest, fmt.Sprintf("A supporting document (file) is required to change status from %s to %s", current, status))
+		return
+	}
+
+	effectiveAt, err := parseStatusTime(req.EffectiveAt)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "Invalid effective_at, use RFC3339 or YYYY-MM-DD")
+		return
+	}
+	revertAt, err := parseStatusTime(req.RevertAt)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "Invalid revert_at, use RFC3339 or YYYY-MM-DD")
+		return
+	}
+	now := time.Now()
+	if effectiveAt != nil && !effectiveAt.After(now) {
+		effectiveAt = nil
+	}
+	if revertAt != nil {
+		start := now
+		if effectiveAt != nil {
+			start = *effectiveAt
+		}
+		if !revertAt.After(start) {
+			sendError(c, http.StatusBadRequest, "revert_at must be after the change takes effect")
+			return
+		}
+		if _, ok := customerStatusTransitions[status][current]; !ok {
+			sendError(c, http.StatusBadRequest, fmt.Sprintf("Status %s cannot be reverted to %s", status, current))
+			return
+		}
+	}
+
+	change := entity.CustomerStatusChange{
+		CustomerID:  customer.ID,
+		FromStatus:  current,
+		ToStatus:    status,
+		Reason:      reason,
+		Notes:       req.Notes,
+		EffectiveAt: effectiveAt,
+		RevertAt:    revertAt,
+		RequestedBy: userID,
+	}
+	switch {
+	case rule.requireApproval:
+		change.State = entity.StatusChangePending
+	case effectiveAt != nil:
+		change.State = entity.StatusChangeScheduled
+	}
+
+	var approval entity.ApprovalRequest
+	var held bool
+	var filePath string
+	err = config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// Baris customer dikunci supaya dua permintaan tidak sama-sama lolos
+		// cek status dan cek perubahan pending
+		var locked entity.Customer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", customer.ID).First(&locked).Error; err != nil {
+			return err
+		}
+		if latest, _ := normalizeCustomerStatus(locked.Status); latest != current {
+			return errStatusChanged
+		}
+		var pending int64
+		if err := tx.Model(&entity.CustomerStatusChange{}).
+			Where("customer_id = ? AND state = ?", customer.ID, entity.StatusChangePending).Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return errStatusChangeOpen
+		}
+
+		if file != nil {
+			filePath = fmt.Sprintf("uploads/documents/%s_%s_%s", customer.ID, now.Format("20060102150405"), filepath.Base(file.Filename))
+			if err := c.SaveUploadedFile(file, filePath); err != nil {
+				return fmt.Errorf("failed to upload file: %w", err)
+			}
+			document := entity.Document{
+				CustomerID: customer.ID,
+				UserID:     userID,
+				Notes:      req.Notes,
+				Type:       "StatusChange",
+				URLFile:    filePath,
+			}
+			if err := tx.Create(&document).Error; err != nil {
+				return err
+			}
+			change.DocumentID = &document.ID
+		}
+		if change.State != "" {
+			if err := tx.Create(&change).Error; err != nil {
+				return err
+			}
+			if change.State != entity.StatusChangePending || status != entity.CustomerStatusBlocked {
+				return nil
+			}
+			// Blokir mengikuti approval berjenjang bila ada workflow customer_block
+			// yang cocok, selain itu cukup disetujui satu user lain
+			approval = entity.ApprovalRequest{
+				Action:      entity.ApprovalCustomerBlock,
+				EntityID:    change.ID,
+				CustomerID:  customer.ID,
+				Summary:     fmt.Sprintf("Block customer %s: %s", customer.Name, reason),
+				Value:       customerOutstanding(tx, customer.ID),
+				RequestedBy: userID,
+			}
+			ok, err := requestApproval(tx, &approval, change)
+			held = ok
+			return err
+		}
+		// Perpindahan langsung tetap dicatat, Save di applyStatusChange membuat barisnya
+		return applyStatusChange(tx, &change)
+	})
+	if err != nil && filePath != "" {
+		// Dokumen tidak jadi tercatat, filenya jangan tertinggal
+		os.Remove(filePath)
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		sendError(c, http.StatusNotFound, "Customer not found")
+		return
+	case errors.Is(err, errStatusChanged):
+		sendError(c, http.StatusConflict, "Customer status was changed by another request, reload and retry")
+		return
+	case errors.Is(err, errStatusChangeOpen):
+		sendError(c, http.StatusConflict, "Customer already has a status change waiting for approval")
+		return
+	case err != nil:
+		sendError(c, http.StatusInternalServerError, "Failed to change customer status")
+		return
+	}
+
+	if held {
+		c.JSON(http.StatusAccepted, gin.H{
+			"status": "success",
+			"message": fmt.Sprintf("Status change is waiting for approval request %s (%d level(s), now %s)",
+				approval.ID, approval.Levels, approval.CurrentStep.Name),
+			"data":             change,
+			"approval_request": approval,
+		})
+		return
+	}
+
+	code, message := http.StatusOK, "Customer status updated to "+status
+	switch change.State {
+	case entity.StatusChangePending:
+		code, message = http.StatusAccepted, "Status change is waiting for approval by another user"
+	case entity.StatusChangeScheduled:
+		code, message = http.StatusAccepted, "Status change scheduled for "+effectiveAt.Format(time.RFC3339)
+	}
+	c.JSON(code, gin.H{
+		"status":  "success",
+		"message": message,
+		"data":    change,
+	})
+}
+
+// @Summary Get customer status reasons and documents
+// @Description Current status of a customer with its active reason, documents and the status changes still open
+// @Tags Customers
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/customers/{id}/status [get]
+func GetCustomerStatus(c *gin.Context) {
+	var customer entity.Customer
+	if err := config.DB.Where("id = ?", c.Param("id")).First(&customer).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+
+	// Ambil status reasons
+	var statusReasons []entity.StatusReasons
+	if err := config.DB.Where("customer_id = ? AND is_active = ?", customer.ID, true).Find(&statusReasons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status reasons"})
+		return
+	}
+
+	// Ambil documents terkait status perubahan
+	var documents []entity.Document
+	if err := config.DB.Where("customer_id = ? AND is_active = ?", customer.ID, true).Find(&documents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch documents"})
+		return
+	}
+
+	// Perubahan yang masih menunggu approval atau jadwal
+	var openChanges []entity.CustomerStatusChange
+	if err := config.DB.Where("customer_id = ? AND state IN ?", customer.ID,
+		[]string{entity.StatusChangePending, entity.StatusChangeScheduled}).
+		Order("created_at").Find(&openChanges).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status changes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Success Get Customer status",
+		"customer":      customer,
+		"StatusReasons": statusReasons,
+		"document":      documents,
+		"open_changes":  openChanges,
+	})
+}
+
+// @Summary List customer status transitions
+// @Description The customer status machine: every allowed move and what it requires
+// @Tags Customers
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.CustomerStatusTransition
+// @Router /api/customers/status-transitions [get]
+func GetCustomerStatusTransitions(c *gin.Context) {
+	order := make(map[string]int, len(customerStatuses))
+	for i, s := range customerStatuses {
+		order[s] = i
+	}
+	data := make([]dto.CustomerStatusTransition, 0)
+	for from, targets := range customerStatusTransitions {
+		for to, rule := range targets {
+			data = append(data, dto.CustomerStatusTransition{
+				From:            from,
+				To:              to,
+				RequireReason:   rule.requireReason,
+				RequireDocument: rule.requireDocument,
+				RequireApproval: rule.requireApproval,
+			})
+		}
+	}
+	sort.Slice(data, func(i, j int) bool {
+		if data[i].From != data[j].From {
+			return order[data[i].From] < order[data[j].From]
+		}
+		return order[data[i].To] < order[data[j].To]
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Customer status transitions fetched successfully",
+		"data":    data,
+	})
+}
+
+// @Summary List customer status changes
+// @Description Requested, scheduled and applied status changes of a customer, newest first
+// @Tags Customers
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param state query string false "Filter by state" Enums(pending, scheduled, applied, rejected, cancelled, failed)
+// @Param to_status query string false "Filter by target status"
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 20, max 200)"
+// @Success 200 {array} entity.CustomerStatusChange
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/customers/{id}/status/changes [get]
+func GetCustomerStatusChanges(c *gin.Context) {
+	var customer entity.Customer
+	if err := config.DB.Where("id = ?", c.Param("id")).First(&customer).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Customer not found")
+		return
+	}
+	params, ok := parseList(c, statusChangeListSpec)
+	if !ok {
+		return
+	}
+
+	var changes []entity.CustomerStatusChange
+	page, err := statusChangeListSpec.Find(config.DB.Where("customer_id = ?", customer.ID), params, &changes,
+		func(db *gorm.DB) *gorm.DB { return db.Preload("Document") })
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch status changes")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"message":    "Status changes fetched successfully",
+		"data":       changes,
+		"pagination": page,
+	})
+}
+
+// decideStatusChange approves or rejects a pending change. The requester
+// cannot decide their own change.
+func decideStatusChange(c *gin.Context, approve bool) {
+	userID := c.GetString("user_id")
+	var req dto.StatusChangeDecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			sendError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if !approve && strings.TrimSpace(req.Note) == "" {
+		sendError(c, http.StatusBadRequest, "Note is required to reject a status change")
+		return
+	}
+
+	var change entity.CustomerStatusChange
+	var approvalID string
+	errSameUser := errors.New("requester cannot decide")
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := lockOpenStatusChange(tx, c.Param("id"), c.Param("change_id"), &change, entity.StatusChangePending); err != nil {
+			return err
+		}
+		if approvalID = pendingApprovalID(tx, entity.ApprovalCustomerBlock, change.ID); approvalID != "" {
+			return errStatusChangeHeld
+		}
+		if change.RequestedBy == userID {
+			return errSameUser
+		}
+		now := time.Now()
+		change.DecidedBy = userID
+		change.DecidedAt = &now
+		change.DecisionNote = strings.TrimSpace(req.Note)
+		switch {
+		case !approve:
+			change.State = entity.StatusChangeRejected
+		case change.EffectiveAt != nil && change.EffectiveAt.After(now):
+			change.State = entity.StatusChangeScheduled
+		default:
+			return applyStatusChange(tx, &change)
+		}
+		return tx.Save(&change).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		sendError(c, http.StatusNotFound, "Status change not found")
+		return
+	case errors.Is(err, errStatusChangeClosed):
+		sendError(c, http.StatusConflict, "Status change is already "+change.State)
+		return
+	case errors.Is(err, errSameUser):
+		sendError(c, http.StatusForbidden, "A status change must be approved or rejected by another user")
+		return
+	case errors.Is(err, errStatusChangeHeld):
+		sendError(c, http.StatusConflict, "Status change is decided through approval request "+approvalID)
+		return
+	case errors.Is(err, errStatusChanged):
+		markStatusChangeFailed(config.DB.WithContext(c), change.ID, err)
+		sendError(c, http.StatusConflict, "Customer status changed since the request was made, the change was closed as failed")
+		return
+	case err != nil:
+		sendError(c, http.StatusInternalServerError, "Failed to update status change")
+		return
+	}
+
+	message := "Status change rejected"
+	switch change.State {
+	case entity.StatusChangeApplied:
+		message = "Status change approved and applied"
+	case entity.StatusChangeScheduled:
+		message = "Status change approved, scheduled for " + change.EffectiveAt.Format(time.RFC3339)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": message,
+		"data":    change,
+	})
+}
+
+// @Summary Approve customer status change
+// @Description Approve a pending status change requested by another user. It is applied now or at effective_at.
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param change_id path string true "Status change ID"
+// @Param request body dto.StatusChangeDecisionRequest false "Optional note"
+// @Success 200 {object} entity.CustomerStatusChange
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /api/customers/{id}/status/changes/{change_id}/approve [post]
+func ApproveCustomerStatusChange(c *gin.Context) {
+	decideStatusChange(c, true)
+}
+
+// @Summary Reject customer status change
+// @Description Reject a pending status change requested by another user
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param change_id path string true "Status change ID"
+// @Param request body dto.StatusChangeDecisionRequest true "Reason of the rejection"
+// @Success 200 {object} entity.CustomerStatusChange
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /api/customers/{id}/status/changes/{change_id}/reject [post]
+func RejectCustomerStatusChange(c *gin.Context) {
+	decideStatusChange(c, false)
+}
+
+// @Summary Cancel customer status change
+// @Description Cancel a status change that is still waiting for approval or scheduled, e.g. an automatic unblock
+// @Tags Customers
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param change_id path string true "Status change ID"
+// @Success 200 {object} entity.CustomerStatusChange
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /api/customers/{id}/status/changes/{change_id}/cancel [post]
+func CancelCustomerStatusChange(c *gin.Context) {
+	userID := c.GetString("user_id")
+	var change entity.CustomerStatusChange
+	var approvalID string
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := lockOpenStatusChange(tx, c.Param("id"), c.Param("change_id"), &change,
+			entity.StatusChangePending, entity.StatusChangeScheduled); err != nil {
+			return err
+		}
+		if approvalID = pendingApprovalID(tx, entity.ApprovalCustomerBlock, change.ID); approvalID != "" {
+			return errStatusChangeHeld
+		}
+		now := time.Now()
+		change.State = entity.StatusChangeCancelled
+		change.DecidedBy = userID
+		change.DecidedAt = &now
+		return tx.Save(&change).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		sendError(c, http.StatusNotFound, "Status change not found")
+		return
+	case errors.Is(err, errStatusChangeClosed):
+		sendError(c, http.StatusConflict, "Status change is already "+change.State)
+		return
+	case errors.Is(err, errStatusChangeHeld):
+		sendError(c, http.StatusConflict, "Status change is held by approval request "+approvalID+", cancel that request instead")
+		return
+	case err != nil:
+		sendError(c, http.StatusInternalServerError, "Failed to cancel status change")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Status change cancelled",
+		"data":    change,
+	})
+}
+
+// applyDueStatusChanges applies every scheduled change whose effective_at has
+// passed, each in its own transaction on behalf of the requester
+func applyDueStatusChanges(db *gorm.DB, now time.Time) (int, error) {
+	var due []entity.CustomerStatusChange
+	err := db.Where("state = ? AND effective_at <= ?", entity.StatusChangeScheduled, now).
+		Order("effective_at").Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, change := range due {
+		ctx := audit.WithActor(context.Background(), audit.Actor{UserID: change.RequestedBy})
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := lockOpenStatusChange(tx, change.CustomerID, change.ID, &change, entity.StatusChangeScheduled); err != nil {
+				return err
+			}
+			return applyStatusChange(tx, &change)
+		})
+		switch {
+		case err == nil:
+			applied++
+		case errors.Is(err, errStatusChangeClosed):
+			// sudah dibatalkan di antara query dan lock
+		case errors.Is(err, errStatusChanged), errors.Is(err, gorm.ErrRecordNotFound):
+			markStatusChangeFailed(db, change.ID, err)
+		default:
+			log.Printf("customer status scheduler: change %s: %v", change.ID, err)
+		}
+	}
+	return applied, nil
+}
+
+// StartCustomerStatusScheduler applies scheduled status changes in the
+// background, e.g. unblocking a customer on the requested date
+func StartCustomerStatusScheduler() {
+	go func() {
+		ticker := time.NewTicker(customerStatusSchedulerInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := applyDueStatusChanges(config.DB, time.Now()); err != nil {
+				log.Printf("customer status scheduler: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
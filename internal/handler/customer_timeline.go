@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+	"customer-api/internal/query"
+	"customer-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+var timelineListSpec = query.Spec{
+	Table: "timeline",
+	Fields: map[string]query.Field{
+		"type":        {},
+		"user_id":     {},
+		"occurred_at": {Type: query.Time},
+	},
+	DefaultSort: "-occurred_at",
+}
+
+// Setiap sumber dipetakan ke kolom yang sama. Satu placeholder per sumber
+// untuk customer ID. activity_checkins.activity_id dan activities.created_by
+// bertipe integer sementara ID lain ULID, sehingga di-cast ke text.
+// source_table dipakai untuk mencari user dari audit log bila record tidak
+// menyimpan pembuatnya.
+type timelineSource struct {
+	permission string // permission tambahan selain customers:read, kosong jika tidak ada
+	query      string
+}
+
+var timelineSources = []timelineSource{
+	{query: `SELECT 'history' AS type, h.id::text AS id, h.created_at AS occurred_at, COALESCE(h.notes, '') AS title,
+		COALESCE(h.action, '') AS subtype, COALESCE(h.status, '') AS status, CAST(NULL AS numeric) AS amount,
+		COALESCE(h.entity_id, '') AS reference, COALESCE(h.user_id::text, '') AS user_id, '' AS source_table
+	FROM history_customers h WHERE h.customer_id = ?`},
+
+	{query: `SELECT 'status', s.id::text, s.created_at, s.reason, '', s.status, NULL, '', '', 'status_reasons'
+	FROM status_reasons s WHERE s.customer_id = ? AND s.deleted_at IS NULL`},
+
+	{query: `SELECT 'document', d.id::text, d.created_at, COALESCE(NULLIF(d.notes, ''), d.type), d.type, '', NULL, d.url_file,
+		COALESCE(d.user_id, ''), 'documents'
+	FROM documents d WHERE d.customer_id = ? AND d.deleted_at IS NULL`},
+
+	{query: `SELECT 'activity', a.id::text, a.start_time, a.title, a.type, COALESCE(a.status, ''), NULL, '', '', 'activities'
+	FROM activities a WHERE a.customer_id = ? AND a.deleted_at IS NULL`},
+
+	{query: `SELECT 'checkin', ac.id::text, ac.checked_in_at, a.title, a.type, '', NULL, a.id::text, '', 'activity_checkins'
+	FROM activity_checkins ac JOIN activities a ON a.id::text = ac.activity_id::text
+	WHERE a.customer_id = ? AND ac.deleted_at IS NULL AND a.deleted_at IS NULL`},
+
+	{query: `SELECT 'event', e.id::text, e.scheduled_at, COALESCE(NULLIF(e.agenda, ''), e.location, ''), '', COALESCE(e.status, ''), NULL, '',
+		'', 'events'
+	FROM events e WHERE e.customer_id = ? AND e.deleted_at IS NULL`},
+
+	{permission: config.PermissionName("invoices", config.ActionRead), query: `SELECT 'invoice', i.id::text, i.issued_date, i.invoice_number, '', '', i.amount, '', '', 'invoices'
+	FROM invoices i WHERE i.customer_id = ? AND i.deleted_at IS NULL`},
+
+	{permission: config.PermissionName("payments", config.ActionRead), query: `SELECT 'payment', p.id::text, p.paid_at, 'Payment ' || COALESCE(i.invoice_number, ''), COALESCE(p.method, ''),
+		CASE WHEN p.reversed_at IS NOT NULL THEN 'Reversed' ELSE 'Received' END, p.amount, p.invoice_id::text,
+		COALESCE(p.created_by, ''), 'payments'
+	FROM payments p LEFT JOIN invoices i ON i.id = p.invoice_id
+	WHERE p.customer_id = ? AND p.deleted_at IS NULL`},
+}
+
+// timelineQuery joins the entries of one customer once with the audit
+// "create" entries of the records that do not store their user
+const timelineQuery = `WITH entries AS (%s),
+creators AS (
+	SELECT DISTINCT ON (al.entity_type, al.entity_id) al.entity_type, al.entity_id, al.user_id
+	FROM audit_logs al JOIN entries e ON e.source_table = al.entity_type AND e.id = al.entity_id
+	WHERE al.action = 'create' AND e.user_id = ''
+	ORDER BY al.entity_type, al.entity_id, al.created_at
+)
+SELECT e.type, e.id, e.occurred_at, e.title, e.subtype, e.status, e.amount, e.reference,
+	COALESCE(NULLIF(e.user_id, ''), c.user_id, '') AS user_id
+FROM entries e LEFT JOIN creators c ON c.entity_type = e.source_table AND c.entity_id = e.id`
+
+type timelineRow struct {
+	Type       string
+	ID         string
+	OccurredAt time.Time
+	Title      string
+	Subtype    string
+	Status     string
+	Amount     *float64
+	Reference  string
+	UserID     string
+}
+
+// @Summary Get customer timeline
+// @Description One chronological feed per customer: history, status changes, documents, activities, check-ins,
+// @Description events, invoices and payments, each with the acting user. Newest first by default.
+// @Description Invoices and payments are included only with invoices:read and payments:read.
+// @Tags Customers
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param type query string false "Filter by entry type" Enums(history, status, document, activity, checkin, event, invoice, payment)
+// @Param type[in] query string false "Several types, e.g. invoice,payment"
+// @Param user_id query string false "Filter by acting user"
+// @Param occurred_at[gte] query string false "From date, e.g. 2025-01-01"
+// @Param occurred_at[lte] query string false "To date (inclusive)"
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 20, max 200)"
+// @Param sort query string false "Sort, e.g. occurred_at for oldest first"
+// @Success 200 {array} dto.TimelineEntry
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/customers/{id}/timeline [get]
+func GetCustomerTimeline(c *gin.Context) {
+	var customer entity.Customer
+	if err := config.DB.Unscoped().Where("id = ?", c.Param("id")).First(&customer).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Customer not found")
+		return
+	}
+
+	params, ok := parseList(c, timelineListSpec)
+	if !ok {
+		return
+	}
+
+	// Invoice dan payment hanya untuk user yang juga boleh membaca invoice/payment
+	var sources []string
+	var args []interface{}
+	for _, source := range timelineSources {
+		if source.permission != "" && !middleware.HasPermission(c, source.permission) {
+			continue
+		}
+		sources = append(sources, source.query)
+		args = append(args, customer.ID)
+	}
+	union := config.DB.Raw(fmt.Sprintf(timelineQuery, strings.Join(sources, "\nUNION ALL\n")), args...)
+
+	var rows []timelineRow
+	page, err := timelineListSpec.Find(config.DB.Table("(?) AS timeline", union), params, &rows)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch customer timeline")
+		return
+	}
+
+	// Status invoice dihitung dengan aturan yang sama seperti daftar invoice,
+	// username dimuat sekali untuk satu halaman
+	var invoiceIDs, userIDs []string
+	for _, row := range rows {
+		if row.Type == "invoice" {
+			invoiceIDs = append(invoiceIDs, row.ID)
+		}
+		if row.UserID != "" {
+			userIDs = append(userIDs, row.UserID)
+		}
+	}
+	invoiceStatuses := make(map[string]string)
+	if len(invoiceIDs) > 0 {
+		var invoices []entity.Invoice
+		config.DB.Where("id IN ?", invoiceIDs).Find(&invoices)
+		now := time.Now()
+		for _, invoice := range invoices {
+			invoiceStatuses[invoice.ID] = invoiceStatus(invoice, now)
+		}
+	}
+	usernames := make(map[string]string)
+	if len(userIDs) > 0 {
+		var users []entity.User
+		config.DB.Select("id", "username").Where("id IN ?", userIDs).Find(&users)
+		for _, user := range users {
+			usernames[user.ID] = user.Username
+		}
+	}
+
+	data := make([]dto.TimelineEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := dto.TimelineEntry{
+			Type:       row.Type,
+			ID:         row.ID,
+			OccurredAt: row.OccurredAt,
+			Title:      row.Title,
+			Subtype:    row.Subtype,
+			Status:     row.Status,
+			Amount:     row.Amount,
+			Reference:  row.Reference,
+		}
+		if row.Type == "invoice" {
+			entry.Status = invoiceStatuses[row.ID]
+		}
+		if row.UserID != "" {
+			entry.User = &dto.HistoryUser{ID: row.UserID, Username: usernames[row.UserID]}
+		}
+		data = append(data, entry)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"message":    "Customer timeline fetched successfully",
+		"data":       data,
+		"pagination": page,
+	})
+}
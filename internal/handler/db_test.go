@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// fakeDB is a database/sql driver for tests without Postgres: every
+// statement GORM sends is answered by the test through handle.
+type fakeDB struct {
+	mu     sync.Mutex
+	handle func(query string, args []driver.Value) (*fakeRows, error)
+}
+
+// openFakeDB returns a GORM handle (postgres dialect) backed by handle
+func openFakeDB(t *testing.T, handle func(query string, args []driver.Value) (*fakeRows, error)) *gorm.DB {
+	t.Helper()
+	sqlDB := sql.OpenDB(&fakeDB{handle: handle})
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return f }
+func (f *fakeDB) Open(string) (driver.Conn, error)             { return fakeConn{f}, nil }
+
+func (f *fakeDB) run(query string, named []driver.NamedValue) (*fakeRows, error) {
+	args := make([]driver.Value, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows, err := f.handle(query, args)
+	if rows == nil && err == nil {
+		rows = &fakeRows{}
+	}
+	return rows, err
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakedb: prepare not supported")
+}
+func (fakeConn) Close() error              { return nil }
+func (fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.db.run(query, args)
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rows, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(rows.affected), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+// fakeRows is the answer to one statement: result rows for queries, the
+// number of affected rows for exec
+type fakeRows struct {
+	columns  []string
+	values   [][]driver.Value
+	affected int64
+}
+
+// rowsOf returns records (entity structs) as result rows, one column per
+// GORM field
+func rowsOf(records ...interface{}) *fakeRows {
+	rows := &fakeRows{}
+	for i, record := range records {
+		s, err := schema.Parse(record, &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			panic(err)
+		}
+		value := reflect.Indirect(reflect.ValueOf(record))
+		var row []driver.Value
+		for _, field := range s.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if i == 0 {
+				rows.columns = append(rows.columns, field.DBName)
+			}
+			v, _ := field.ValueOf(context.Background(), value)
+			dv, err := driver.DefaultParameterConverter.ConvertValue(v)
+			if err != nil {
+				panic(err)
+			}
+			row = append(row, dv)
+		}
+		rows.values = append(rows.values, row)
+	}
+	return rows
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
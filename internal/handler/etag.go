@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"customer-api/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Optimistic locking: customer, address, contact, workflow dan stage punya
+// kolom version yang naik setiap update. GET mengirimnya sebagai ETag dan
+// PUT/DELETE wajib mengirim ETag terakhir yang dibaca lewat If-Match, supaya
+// perubahan orang lain tidak tertimpa diam-diam.
+
+var errVersionConflict = errors.New("record was modified by another request")
+
+// etag renders a version as a strong entity tag, e.g. "3"
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+func versionOf(record interface{}) int {
+	return int(reflect.Indirect(reflect.ValueOf(record)).FieldByName("Version").Int())
+}
+
+// setETag sends the version of record in the ETag header
+func setETag(c *gin.Context, record interface{}) {
+	c.Header("ETag", etag(versionOf(record)))
+}
+
+// checkIfMatch compares If-Match with the version of the record the handler
+// just loaded. It responds 428 when the header is missing and 412 with the
+// current record when the client edited an older version.
+func checkIfMatch(c *gin.Context, record interface{}) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		sendError(c, http.StatusPreconditionRequired, "If-Match header is required, send the ETag of the last GET")
+		return false
+	}
+	current := etag(versionOf(record))
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	sendPreconditionFailed(c, record)
+	return false
+}
+
+func sendPreconditionFailed(c *gin.Context, current interface{}) {
+	setETag(c, current)
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"status":  "failed",
+		"message": "Record was modified by another user, reload it and retry",
+		"data":    current,
+	})
+}
+
+// lockVersion locks the row until the transaction ends and fails with
+// errVersionConflict when its version changed after checkIfMatch. model is a
+// zero value of the entity, e.g. &entity.Customer{}.
+func lockVersion(tx *gorm.DB, model interface{}, id string, version int) error {
+	var current []int
+	err := tx.Model(model).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Pluck("version", &current).Error
+	if err != nil {
+		return err
+	}
+	if len(current) == 0 || current[0] != version {
+		return errVersionConflict
+	}
+	return nil
+}
+
+// sendVersionConflict reloads record after losing a race and responds 412
+func sendVersionConflict(c *gin.Context, record interface{}, id string) {
+	if err := config.DB.Where("id = ?", id).First(record).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Record not found")
+		return
+	}
+	sendPreconditionFailed(c, record)
+}
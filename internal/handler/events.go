@@ -2,10 +2,10 @@ package handler
 
 import (
 	"net/http"
-	"strconv"
 
 	"customer-api/internal/config"
 	"customer-api/internal/entity"
+	"customer-api/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm" 
 
@@ -14,32 +14,47 @@ import (
 
 
 
+var eventListSpec = query.Spec{
+	Table: "events",
+	Fields: map[string]query.Field{
+		"activity_type_id": {},
+		"customer_id":      {},
+		"project_id":       {},
+		"status":           {},
+		"location":         {},
+		"is_active":        {Type: query.Bool},
+		"scheduled_at":     {Type: query.Time},
+		"created_at":       {Type: query.Time},
+	},
+	DefaultSort:     "-scheduled_at",
+	DefaultPageSize: 10,
+}
+
 // @Summary Get all Events
 // @Description Get list of all events
 // @Tags Events
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param limit query int false "Limit"
+// @Param limit query int false "Page size (alias of page_size)"
 // @Param page query int false "Page"
+// @Param page_size query int false "Page size (default 10, max 200)"
+// @Param sort query string false "Sort fields, prefix - for descending"
+// @Param cursor query string false "Cursor pagination on id"
 // @Success 200 {array} entity.Event
+// @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/events [get]
 func ReadEvents(c *gin.Context) {
-	limit, _ := strconv.Atoi(c.Query("limit"))
-	page, _ := strconv.Atoi(c.Query("page"))
-	if limit == 0 {
-		limit = 10 // Default limit
-	}
-	if page == 0 {
-		page = 1 // Default page
+	params, ok := parseList(c, eventListSpec)
+	if !ok {
+		return
 	}
 
 	var events []entity.Event
-	offset := (page - 1) * limit
-
-	if result := config.DB.Limit(limit).Offset(offset).Find(&events); result.Error != nil {
+	page, err := eventListSpec.Find(config.DB, params, &events)
+	if err != nil {
 
 		c.JSON(http.StatusNotFound, dto.Response{
 			Status:  http.StatusNotFound,
@@ -50,18 +65,20 @@ func ReadEvents(c *gin.Context) {
 	}
 	if len(events) == 0 {
 		c.JSON(http.StatusOK, dto.Response{
-			Status:  http.StatusOK,
-			Message: "No events found",
-			Data:    events,
+			Status:     http.StatusOK,
+			Message:    "No events found",
+			Data:       events,
+			Pagination: &page,
 		})
 		return
 	}
 
 
 	c.JSON(http.StatusOK, dto.Response{
-			Status:  http.StatusOK,
-			Message: "Events retrieved successfully",
-			Data:    events,
+			Status:     http.StatusOK,
+			Message:    "Events retrieved successfully",
+			Data:       events,
+			Pagination: &page,
 		})
 }
 
@@ -85,7 +102,7 @@ func CreateEvents(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Create(&event).Error; err != nil {
+	if err := config.DB.WithContext(c).Create(&event).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan data event"})
 		return
 	}
@@ -152,7 +169,7 @@ func UpdateEvents(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Save(&event).Error; err != nil {
+	if err := config.DB.WithContext(c).Save(&event).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan data event"})
 		return
 	}
@@ -185,7 +202,7 @@ func DeleteEvents(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Delete(&event).Error; err != nil {
+	if err := config.DB.WithContext(c).Delete(&event).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus data event"})
 		return
 	}
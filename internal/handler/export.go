@@ -0,0 +1,399 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"customer-api/internal/query"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	mimeExcel = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	mimePDF   = "application/pdf"
+	mimeCSV   = "text/csv; charset=utf-8"
+	mimeJSONL = "application/x-ndjson"
+)
+
+// Export formats
+const (
+	ExportExcel = "excel"
+	ExportPDF   = "pdf"
+	ExportCSV   = "csv"
+	ExportJSONL = "jsonl"
+)
+
+// Jumlah baris yang dimuat per query saat export
+const exportBatchSize = 500
+
+var exportFormats = map[string]struct{ contentType, extension string }{
+	ExportExcel: {mimeExcel, ".xlsx"},
+	ExportPDF:   {mimePDF, ".pdf"},
+	ExportCSV:   {mimeCSV, ".csv"},
+	ExportJSONL: {mimeJSONL, ".jsonl"},
+}
+
+// exportWriter writes a table row by row. CSV and JSON lines go straight to
+// the underlying writer; Excel and PDF are only complete after Close.
+type exportWriter interface {
+	WriteRow(values []interface{}) error
+	Flush() error
+	Close() error
+}
+
+// newExportWriter writes the header (keys for JSON lines, headers otherwise)
+// and returns a writer for the remaining rows
+func newExportWriter(format string, w io.Writer, title string, keys, headers []string) (exportWriter, error) {
+	switch format {
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		return &csvExportWriter{w: cw}, cw.Write(headers)
+	case ExportJSONL:
+		return &jsonlExportWriter{w: bufio.NewWriter(w), keys: keys}, nil
+	case ExportExcel:
+		return newExcelExportWriter(w, title, headers)
+	case ExportPDF:
+		return &pdfExportWriter{w: w, title: title, headers: headers}, nil
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+// exportQuery applies the filters and sort of params to model, with the id as
+// tie-breaker so consecutive batches never overlap
+func exportQuery(db *gorm.DB, spec query.Spec, params query.Params, model interface{}) *gorm.DB {
+	q := params.Filter(db.Model(model))
+	for _, order := range params.Sort {
+		q = q.Order(order)
+	}
+	return q.Order(spec.Table + ".id")
+}
+
+// writeExportBatches pages through q, writes the rows returned by load and
+// closes out. progress (optional) receives the number of rows written so far.
+func writeExportBatches(out exportWriter, q *gorm.DB, load func(batch *gorm.DB) ([][]interface{}, error), progress func(done int)) (int, error) {
+	count := 0
+	for offset := 0; ; offset += exportBatchSize {
+		rows, err := load(q.Session(&gorm.Session{}).Limit(exportBatchSize).Offset(offset))
+		if err != nil {
+			return count, err
+		}
+		for _, row := range rows {
+			if err := out.WriteRow(row); err != nil {
+				return count, err
+			}
+		}
+		count += len(rows)
+		if err := out.Flush(); err != nil {
+			return count, err
+		}
+		if progress != nil {
+			progress(count)
+		}
+		if len(rows) < exportBatchSize {
+			break
+		}
+	}
+	return count, out.Close()
+}
+
+// exportText formats a cell for text based outputs
+func exportText(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+		return val.Format(time.RFC3339)
+	case *time.Time:
+		if val == nil {
+			return ""
+		}
+		return exportText(*val)
+	}
+	return fmt.Sprint(v)
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (e *csvExportWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = exportText(v)
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvExportWriter) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExportWriter) Close() error {
+	return e.Flush()
+}
+
+// jsonlExportWriter writes one JSON object per line, keeping column order
+type jsonlExportWriter struct {
+	w    *bufio.Writer
+	keys []string
+}
+
+func (e *jsonlExportWriter) WriteRow(values []interface{}) error {
+	e.w.WriteByte('{')
+	for i, key := range e.keys {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		v, err := json.Marshal(values[i])
+		if err != nil {
+			return err
+		}
+		e.w.Write(k)
+		e.w.WriteByte(':')
+		e.w.Write(v)
+	}
+	e.w.WriteString("}\n")
+	return nil
+}
+
+func (e *jsonlExportWriter) Flush() error {
+	return e.w.Flush()
+}
+
+func (e *jsonlExportWriter) Close() error {
+	return e.Flush()
+}
+
+// excelExportWriter uses excelize's stream writer so rows are not kept as
+// cell objects in memory
+type excelExportWriter struct {
+	w    io.Writer
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	row  int
+}
+
+func newExcelExportWriter(w io.Writer, sheet string, headers []string) (*excelExportWriter, error) {
+	f := excelize.NewFile()
+	if err := f.SetSheetName(f.GetSheetName(0), sheet); err != nil {
+		f.Close()
+		return nil, err
+	}
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	e := &excelExportWriter{w: w, file: f, sw: sw}
+	header := make([]interface{}, len(headers))
+	for i, h := range headers {
+		header[i] = h
+	}
+	return e, e.WriteRow(header)
+}
+
+func (e *excelExportWriter) WriteRow(values []interface{}) error {
+	e.row++
+	cell, _ := excelize.CoordinatesToCellName(1, e.row)
+	row := make([]interface{}, len(values))
+	for i, v := range values {
+		switch val := v.(type) {
+		case nil:
+			row[i] = ""
+		case *time.Time:
+			if val == nil {
+				row[i] = ""
+			} else {
+				row[i] = *val
+			}
+		default:
+			row[i] = v
+		}
+	}
+	return e.sw.SetRow(cell, row)
+}
+
+func (e *excelExportWriter) Flush() error {
+	return nil
+}
+
+func (e *excelExportWriter) Close() error {
+	defer e.file.Close()
+	if err := e.sw.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.w)
+}
+
+// pdfExportWriter collects the rows and renders them on Close, since column
+// widths depend on the content and gofpdf keeps the document in memory anyway
+type pdfExportWriter struct {
+	w           io.Writer
+	title       string
+	orientation string
+	headers     []string
+	rows        [][]string
+	numeric     []bool
+}
+
+func (e *pdfExportWriter) WriteRow(values []interface{}) error {
+	if e.numeric == nil {
+		e.numeric = make([]bool, len(values))
+		for i, v := range values {
+			_, e.numeric[i] = v.(float64)
+		}
+	}
+	row := make([]string, len(values))
+	for i, v := range values {
+		if f, ok := v.(float64); ok {
+			row[i] = fmt.Sprintf("%.2f", f)
+			continue
+		}
+		if t, ok := v.(time.Time); ok && !t.IsZero() {
+			row[i] = t.Format("2006-01-02 15:04")
+			continue
+		}
+		row[i] = exportText(v)
+	}
+	e.rows = append(e.rows, row)
+	return nil
+}
+
+func (e *pdfExportWriter) Flush() error {
+	return nil
+}
+
+func (e *pdfExportWriter) Close() error {
+	orientation := e.orientation
+	if orientation == "" {
+		// Tabel lebar otomatis landscape
+		orientation = "P"
+		if len(e.headers) > 6 {
+			orientation = "L"
+		}
+	}
+	pdf := gofpdf.New(orientation, "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 10)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, _, right, bottom := pdf.GetMargins()
+	available := pageWidth - left - right
+	const lineHeight, padding, maxNatural = 5.0, 2.0, 70.0
+
+	// Lebar kolom mengikuti isi terpanjang, lalu diskalakan ke lebar halaman
+	widths := make([]float64, len(e.headers))
+	total := 0.0
+	for i, h := range e.headers {
+		pdf.SetFont("Arial", "B", 9)
+		w := pdf.GetStringWidth(tr(h))
+		pdf.SetFont("Arial", "", 8)
+		for _, row := range e.rows {
+			if i < len(row) {
+				w = math.Max(w, pdf.GetStringWidth(tr(row[i])))
+			}
+		}
+		widths[i] = math.Min(w, maxNatural) + 2*padding
+		total += widths[i]
+	}
+	for i := range widths {
+		widths[i] = widths[i] * available / total
+	}
+
+	fonts := map[string]float64{"B": 9, "": 8}
+	rowHeight := func(cells []string, style string) float64 {
+		pdf.SetFont("Arial", style, fonts[style])
+		lines := 1
+		for i, cell := range cells {
+			if n := len(pdf.SplitLines([]byte(tr(cell)), widths[i]-padding)); n > lines {
+				lines = n
+			}
+		}
+		return float64(lines) * lineHeight
+	}
+	drawRow := func(cells []string, style string) {
+		h := rowHeight(cells, style)
+		x, y := pdf.GetXY()
+		for i, cell := range cells {
+			align := "L"
+			if style == "B" {
+				align = "C"
+			} else if i < len(e.numeric) && e.numeric[i] {
+				align = "R"
+			}
+			pdf.Rect(x, y, widths[i], h, "D")
+			pdf.SetXY(x, y)
+			pdf.MultiCell(widths[i], lineHeight, tr(cell), "", align, false)
+			x += widths[i]
+		}
+		pdf.SetXY(left, y+h)
+	}
+
+	if e.title != "" {
+		pdf.SetFont("Arial", "B", 14)
+		pdf.CellFormat(0, 10, tr(e.title), "", 1, "L", false, 0, "")
+	}
+	drawRow(e.headers, "B")
+	for _, row := range e.rows {
+		// Header diulang di setiap halaman baru
+		if pdf.GetY()+rowHeight(row, "") > pageHeight-bottom {
+			pdf.AddPage()
+			drawRow(e.headers, "B")
+		}
+		drawRow(row, "")
+	}
+
+	return pdf.Output(e.w)
+}
+
+// createExcelTable builds a single-sheet workbook with a header row followed by rows
+func createExcelTable(sheet string, headers []string, rows [][]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := newExcelExportWriter(&buf, sheet, headers)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// createPDFTable renders a bordered table; column widths follow the content
+// and long values wrap instead of being cut off
+func createPDFTable(title string, orientation string, headers []string, rows [][]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	w := &pdfExportWriter{w: &buf, title: title, orientation: orientation, headers: headers}
+	for _, row := range rows {
+		w.WriteRow(row)
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
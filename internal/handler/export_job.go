@@ -0,0 +1,595 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+	"customer-api/internal/query"
+	"customer-api/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	exportDir                 = "uploads/exports"
+	defaultExportRetention    = 24 * time.Hour
+	defaultExportLinkTTL      = time.Hour
+	exportWorkerPollInterval  = 5 * time.Second
+	exportProgressUpdateEvery = 2 * time.Second
+)
+
+// exportDataset is an entity that can be exported by an export job
+type exportDataset struct {
+	resource string // read permission on this resource is required
+	spec     query.Spec
+	model    interface{}
+	// columns validates the columns parameter; nil when the columns are fixed
+	columns func(raw string) error
+	write   func(db *gorm.DB, params query.Params, format, columns string, w io.Writer, progress func(done int)) (int, error)
+}
+
+var historyListSpec = query.Spec{
+	Table: "history_customers",
+	Fields: map[string]query.Field{
+		"customer_id": {},
+		"user_id":     {},
+		"status":      {},
+		"entity_type": {},
+		"entity_id":   {},
+		"action":      {},
+		"created_at":  {Type: query.Time},
+	},
+	DefaultSort: "-created_at",
+}
+
+var exportDatasets = map[string]exportDataset{
+	"customers": {
+		resource: "customers",
+		spec:     customerListSpec,
+		model:    &entity.Customer{},
+		columns: func(raw string) error {
+			_, _, err := resolveCustomerExportColumns(raw)
+			return err
+		},
+		write: func(db *gorm.DB, params query.Params, format, columns string, w io.Writer, progress func(int)) (int, error) {
+			keys, cols, err := resolveCustomerExportColumns(columns)
+			if err != nil {
+				return 0, err
+			}
+			return writeCustomerExport(db, params, format, keys, cols, w, progress)
+		},
+	},
+	"activities": {
+		resource: "activities",
+		spec:     activityListSpec,
+		model:    &entity.Activity{},
+		write:    writeActivityExport,
+	},
+	"invoices": {
+		resource: "invoices",
+		spec:     invoiceListSpec,
+		model:    &entity.Invoice{},
+		write:    writeInvoiceExport,
+	},
+	"history": {
+		resource: "customers",
+		spec:     historyListSpec,
+		model:    &entity.HistoryCustomer{},
+		write:    writeHistoryExport,
+	},
+}
+
+func writeActivityExport(db *gorm.DB, params query.Params, format, _ string, w io.Writer, progress func(int)) (int, error) {
+	keys := []string{"id", "customer_id", "title", "type", "status", "agenda", "start_time", "end_time", "location_name", "created_by", "created_at"}
+	headers := []string{"ID", "Customer ID", "Title", "Type", "Status", "Agenda", "Start Time", "End Time", "Location", "Created By", "Created At"}
+	out, err := newExportWriter(format, w, "Activities", keys, headers)
+	if err != nil {
+		return 0, err
+	}
+	return writeExportBatches(out, exportQuery(db, activityListSpec, params, &entity.Activity{}), func(batch *gorm.DB) ([][]interface{}, error) {
+		var activities []entity.Activity
+		if err := batch.Find(&activities).Error; err != nil {
+			return nil, err
+		}
+		rows := make([][]interface{}, 0, len(activities))
+		for _, a := range activities {
+			rows = append(rows, []interface{}{a.ID, a.CustomerID, a.Title, a.Type, a.Status, a.Agenda, a.StartTime, a.EndTime, a.LocationName, a.CreatedBy, a.CreatedAt})
+		}
+		return rows, nil
+	}, progress)
+}
+
+func writeInvoiceExport(db *gorm.DB, params query.Params, format, _ string, w io.Writer, progress func(int)) (int, error) {
+	keys := []string{"id", "invoice_number", "customer_id", "customer_name", "issued_date", "due_date", "amount", "tax_amount", "paid_amount", "balance", "status"}
+	headers := []string{"ID", "Invoice Number", "Customer ID", "Customer", "Issued Date", "Due Date", "Amount", "Tax", "Paid", "Balance", "Status"}
+	out, err := newExportWriter(format, w, "Invoices", keys, headers)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	return writeExportBatches(out, exportQuery(db, invoiceListSpec, params, &entity.Invoice{}).Preload("Customer"), func(batch *gorm.DB) ([][]interface{}, error) {
+		var invoices []entity.Invoice
+		if err := batch.Find(&invoices).Error; err != nil {
+			return nil, err
+		}
+		rows := make([][]interface{}, 0, len(invoices))
+		for _, inv := range invoices {
+			rows = append(rows, []interface{}{inv.ID, inv.InvoiceNumber, inv.CustomerID, inv.Customer.Name, inv.IssuedDate, inv.DueDate,
+				inv.Amount, inv.TaxAmount, inv.PaidAmount, invoiceBalance(inv), invoiceStatus(inv, now)})
+		}
+		return rows, nil
+	}, progress)
+}
+
+func writeHistoryExport(db *gorm.DB, params query.Params, format, _ string, w io.Writer, progress func(int)) (int, error) {
+	keys := []string{"id", "customer_id", "customer_name", "user_id", "username", "action", "entity_type", "entity_id", "status", "notes", "created_at"}
+	headers := []string{"ID", "Customer ID", "Customer", "User ID", "User", "Action", "Entity", "Entity ID", "Status", "Notes", "Created At"}
+	out, err := newExportWriter(format, w, "Customer History", keys, headers)
+	if err != nil {
+		return 0, err
+	}
+	q := exportQuery(db, historyListSpec, params, &entity.HistoryCustomer{}).
+		Preload("Customer", func(tx *gorm.DB) *gorm.DB { return tx.Unscoped() }).
+		Preload("User")
+	return writeExportBatches(out, q, func(batch *gorm.DB) ([][]interface{}, error) {
+		var history []entity.HistoryCustomer
+		if err := batch.Find(&history).Error; err != nil {
+			return nil, err
+		}
+		rows := make([][]interface{}, 0, len(history))
+		for _, h := range history {
+			rows = append(rows, []interface{}{h.ID, h.CustomerID, h.Customer.Name, h.UserID, h.User.Username, h.Action, h.EntityType, h.EntityID, h.Status, h.Notes, h.CreatedAt})
+		}
+		return rows, nil
+	}, progress)
+}
+
+// exportSignature signs the download link of a job
+func exportSignature(id string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("JWT_SECRET")))
+	mac.Write([]byte("export:" + id + ":" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func toExportJobResponse(job entity.ExportJob) dto.ExportJobResponse {
+	res := dto.ExportJobResponse{
+		ID:            job.ID,
+		Type:          job.Type,
+		Format:        job.Format,
+		Status:        job.Status,
+		TotalRows:     job.TotalRows,
+		ProcessedRows: job.ProcessedRows,
+		FileName:      job.FileName,
+		FileSize:      job.FileSize,
+		Error:         job.Error,
+		StartedAt:     job.StartedAt,
+		FinishedAt:    job.FinishedAt,
+		ExpiresAt:     job.ExpiresAt,
+		CreatedAt:     job.CreatedAt,
+	}
+	switch {
+	case job.Status == entity.ExportJobCompleted:
+		res.Progress = 100
+	case job.TotalRows > 0:
+		res.Progress = min(99, job.ProcessedRows*100/job.TotalRows)
+	}
+
+	if job.Status == entity.ExportJobCompleted && job.ExpiresAt != nil {
+		// Link berlaku singkat, tapi tidak melebihi umur file
+		expires := time.Now().Add(durationFromEnv("EXPORT_LINK_TTL", defaultExportLinkTTL))
+		if job.ExpiresAt.Before(expires) {
+			expires = *job.ExpiresAt
+		}
+		res.DownloadURL = fmt.Sprintf("/exports/%s/download?expires=%d&signature=%s", job.ID, expires.Unix(), exportSignature(job.ID, expires.Unix()))
+		res.DownloadExpiresAt = &expires
+	}
+	return res
+}
+
+// findOwnExportJob loads a job of the current user, answering 404 itself
+func findOwnExportJob(c *gin.Context) (entity.ExportJob, bool) {
+	var job entity.ExportJob
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), c.GetString("user_id")).First(&job).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Export job not found")
+		return job, false
+	}
+	return job, true
+}
+
+// @Summary Create export job
+// @Description Queue a background export of customers, activities, invoices or customer history.
+// @Description Filters and sort use the syntax of the matching list endpoint. Poll GET /api/exports/{id} for progress and the download link.
+// @Tags Exports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateExportJobRequest true "Export job"
+// @Success 202 {object} dto.ExportJobResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/exports [post]
+func CreateExportJob(c *gin.Context) {
+	var req dto.CreateExportJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	dataset := exportDatasets[req.Type]
+
+	// Hak akses mengikuti modul yang diexport
+	middleware.RequirePermission(config.PermissionName(dataset.resource, config.ActionRead))(c)
+	if c.IsAborted() {
+		return
+	}
+
+	values := url.Values{}
+	for key, value := range req.Filters {
+		values.Set(key, value)
+	}
+	if req.Sort != "" {
+		values.Set("sort", req.Sort)
+	}
+	for _, key := range []string{"page", "page_size", "limit", "cursor"} {
+		values.Del(key)
+	}
+	if _, err := dataset.spec.Parse(values); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Columns != "" {
+		if dataset.columns == nil {
+			sendError(c, http.StatusBadRequest, "columns can only be chosen for customers exports")
+			return
+		}
+		if err := dataset.columns(req.Columns); err != nil {
+			sendError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	job := entity.ExportJob{
+		UserID:  c.GetString("user_id"),
+		Type:    req.Type,
+		Format:  req.Format,
+		Params:  values.Encode(),
+		Columns: req.Columns,
+		Status:  entity.ExportJobQueued,
+	}
+	if err := config.DB.WithContext(c).Create(&job).Error; err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to create export job")
+		return
+	}
+	wakeExportWorker()
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  "success",
+		"message": "Export job queued",
+		"data":    toExportJobResponse(job),
+	})
+}
+
+var exportJobListSpec = query.Spec{
+	Table: "export_jobs",
+	Fields: map[string]query.Field{
+		"type":       {},
+		"format":     {},
+		"status":     {},
+		"created_at": {Type: query.Time},
+	},
+	DefaultSort: "-created_at",
+}
+
+// @Summary Get export jobs
+// @Description Get a page of the current user's export jobs, filterable on type, format, status, created_at
+// @Tags Exports
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Status" Enums(queued, running, completed, failed, expired)
+// @Param page query int false "Page"
+// @Param page_size query int false "Page size"
+// @Success 200 {array} dto.ExportJobResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/exports [get]
+func GetExportJobs(c *gin.Context) {
+	params, ok := parseList(c, exportJobListSpec)
+	if !ok {
+		return
+	}
+
+	var jobs []entity.ExportJob
+	page, err := exportJobListSpec.Find(config.DB.Where("user_id = ?", c.GetString("user_id")), params, &jobs)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch export jobs")
+		return
+	}
+
+	data := make([]dto.ExportJobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		data = append(data, toExportJobResponse(job))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"message":    "Export jobs fetched successfully",
+		"data":       data,
+		"pagination": page,
+	})
+}
+
+// @Summary Get export job
+// @Description Get the status and progress of an export job. Completed jobs include an expiring download_url.
+// @Tags Exports
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Export job ID"
+// @Success 200 {object} dto.ExportJobResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/exports/{id} [get]
+func GetExportJob(c *gin.Context) {
+	job, ok := findOwnExportJob(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Export job fetched successfully",
+		"data":    toExportJobResponse(job),
+	})
+}
+
+// @Summary Delete export job
+// @Description Delete an export job and its file. Running jobs cannot be deleted.
+// @Tags Exports
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Export job ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /api/exports/{id} [delete]
+func DeleteExportJob(c *gin.Context) {
+	job, ok := findOwnExportJob(c)
+	if !ok {
+		return
+	}
+	// Hanya job yang belum diambil worker yang boleh dihapus saat antre
+	result := config.DB.WithContext(c).Where("id = ? AND status <> ?", job.ID, entity.ExportJobRunning).Delete(&entity.ExportJob{})
+	if result.Error != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to delete export job")
+		return
+	}
+	if result.RowsAffected == 0 {
+		sendError(c, http.StatusConflict, "Export job is running")
+		return
+	}
+	if job.FilePath != "" {
+		os.Remove(job.FilePath)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Export job deleted successfully",
+		"data":    nil,
+	})
+}
+
+// @Summary Download export file
+// @Description Download the file of a completed export job through the signed link from GET /api/exports/{id}. No Authorization header is needed.
+// @Tags Exports
+// @Produce octet-stream
+// @Param id path string true "Export job ID"
+// @Param expires query int true "Link expiry (unix time)"
+// @Param signature query string true "Link signature"
+// @Success 200 {file} file
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 410 {object} dto.ErrorResponse
+// @Router /exports/{id}/download [get]
+func DownloadExportFile(c *gin.Context) {
+	id := c.Param("id")
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || !hmac.Equal([]byte(c.Query("signature")), []byte(exportSignature(id, expires))) {
+		sendError(c, http.StatusForbidden, "Invalid download link")
+		return
+	}
+	if time.Now().Unix() > expires {
+		sendError(c, http.StatusGone, "Download link has expired")
+		return
+	}
+
+	var job entity.ExportJob
+	if err := config.DB.Where("id = ?", id).First(&job).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Export job not found")
+		return
+	}
+	if job.Status != entity.ExportJobCompleted {
+		sendError(c, http.StatusGone, "Export file is no longer available")
+		return
+	}
+	c.FileAttachment(job.FilePath, job.FileName)
+}
+
+// publicUploadsFS hides the export files from the public /uploads route; they
+// are only reachable through signed download links
+type publicUploadsFS struct {
+	http.FileSystem
+}
+
+func (fs publicUploadsFS) Open(name string) (http.File, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/exports" || strings.HasPrefix(clean, "/exports/") {
+		return nil, os.ErrNotExist
+	}
+	return fs.FileSystem.Open(name)
+}
+
+// PublicUploads serves root like gin's Static, without the export files
+func PublicUploads(root string) http.FileSystem {
+	return publicUploadsFS{gin.Dir(root, false)}
+}
+
+var exportWake = make(chan struct{}, 1)
+
+func wakeExportWorker() {
+	select {
+	case exportWake <- struct{}{}:
+	default:
+	}
+}
+
+// StartExportWorker processes queued export jobs in the background. Jobs that
+// were running when the server stopped are queued again. The worker assumes a
+// single API process per database.
+func StartExportWorker() {
+	if err := os.MkdirAll(exportDir, 0755); err != nil {
+		log.Printf("export worker: %v", err)
+	}
+	config.DB.Model(&entity.ExportJob{}).
+		Where("status = ?", entity.ExportJobRunning).
+		Updates(map[string]interface{}{"status": entity.ExportJobQueued, "processed_rows": 0, "started_at": nil})
+
+	go func() {
+		ticker := time.NewTicker(exportWorkerPollInterval)
+		defer ticker.Stop()
+		for {
+			for runNextExportJob() {
+			}
+			purgeExpiredExports()
+			select {
+			case <-exportWake:
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// runNextExportJob claims the oldest queued job and runs it. It returns false
+// when the queue is empty.
+func runNextExportJob() bool {
+	var job entity.ExportJob
+	now := time.Now()
+	err := config.DB.Raw(`UPDATE export_jobs SET status = ?, started_at = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM export_jobs WHERE status = ? ORDER BY created_at, id LIMIT 1 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, entity.ExportJobRunning, now, now, entity.ExportJobQueued).
+		Scan(&job).Error
+	if err != nil {
+		log.Printf("export worker: failed to claim job: %v", err)
+		return false
+	}
+	if job.ID == "" {
+		return false
+	}
+
+	if err := runExportJob(&job); err != nil {
+		finished := time.Now()
+		config.DB.Model(&job).Updates(map[string]interface{}{
+			"status":      entity.ExportJobFailed,
+			"error":       err.Error(),
+			"finished_at": finished,
+		})
+		log.Printf("export job %s failed: %v", job.ID, err)
+	}
+	return true
+}
+
+func runExportJob(job *entity.ExportJob) error {
+	dataset, ok := exportDatasets[job.Type]
+	if !ok {
+		return fmt.Errorf("unknown export type %q", job.Type)
+	}
+	format, ok := exportFormats[job.Format]
+	if !ok {
+		return fmt.Errorf("unknown export format %q", job.Format)
+	}
+	values, err := url.ParseQuery(job.Params)
+	if err != nil {
+		return err
+	}
+	params, err := dataset.spec.Parse(values)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	if err := params.Filter(config.DB.Model(dataset.model)).Count(&total).Error; err != nil {
+		return err
+	}
+	config.DB.Model(job).Updates(map[string]interface{}{"total_rows": total, "processed_rows": 0})
+
+	filePath := filepath.Join(exportDir, job.ID+format.extension)
+	tmpPath := filePath + ".part"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	lastUpdate := time.Now()
+	count, err := dataset.write(config.DB, params, job.Format, job.Columns, file, func(done int) {
+		if time.Since(lastUpdate) >= exportProgressUpdateEvery {
+			config.DB.Model(job).Update("processed_rows", done)
+			lastUpdate = time.Now()
+		}
+	})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, filePath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	var size int64
+	if info, err := os.Stat(filePath); err == nil {
+		size = info.Size()
+	}
+	finished := time.Now()
+	return config.DB.Model(job).Updates(map[string]interface{}{
+		"status":         entity.ExportJobCompleted,
+		"total_rows":     count,
+		"processed_rows": count,
+		"file_path":      filePath,
+		"file_name":      job.Type + "-" + finished.Format("20060102-150405") + format.extension,
+		"file_size":      size,
+		"finished_at":    finished,
+		"expires_at":     finished.Add(durationFromEnv("EXPORT_RETENTION", defaultExportRetention)),
+	}).Error
+}
+
+// purgeExpiredExports deletes files of completed jobs past their retention
+func purgeExpiredExports() {
+	var jobs []entity.ExportJob
+	if err := config.DB.Where("status = ? AND expires_at < ?", entity.ExportJobCompleted, time.Now()).Find(&jobs).Error; err != nil {
+		return
+	}
+	for _, job := range jobs {
+		if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("export worker: failed to remove %s: %v", job.FilePath, err)
+			continue
+		}
+		config.DB.Model(&job).Update("status", entity.ExportJobExpired)
+	}
+}
@@ -2,11 +2,11 @@ package handler
 
 import (
 	"net/http"
-	"strconv"
 
 	"customer-api/internal/config"
 	"customer-api/internal/dto"
 	"customer-api/internal/entity"
+	"customer-api/internal/query"
 	"github.com/gin-gonic/gin"
 )
 
@@ -43,7 +43,7 @@ func CreateGroup(c *gin.Context) {
 			Active:    req.IndustryActive,
 		}
 
-		result := config.DB.Create(&industryGroup)
+		result := config.DB.WithContext(c).Create(&industryGroup)
 		if result.Error != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create industry group"})
 			return
@@ -61,7 +61,7 @@ func CreateGroup(c *gin.Context) {
 			Active:    req.ParentGroupActive,
 		}
 
-		result := config.DB.Create(&parentGroup)
+		result := config.DB.WithContext(c).Create(&parentGroup)
 		if result.Error != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create parent group"})
 			return
@@ -74,33 +74,47 @@ func CreateGroup(c *gin.Context) {
 	c.JSON(http.StatusBadRequest, gin.H{"error": "Either IndustryId or ParentGroupId must be provided"})
 }
 
+var groupListSpec = query.Spec{
+	Table: "groups",
+	Fields: map[string]query.Field{
+		"name_group": {},
+		"value":      {},
+		"active":     {Type: query.Bool},
+		"created_at": {Type: query.Time},
+	},
+	DefaultSort:     "name_group",
+	DefaultPageSize: 100,
+}
+
 // @Summary Get all groups
-// @Description Get list of all groups
+// @Description Get list of groups; total count is returned in the X-Total-Count header
 // @Tags Groups
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param active query bool false "Filter by active status"
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 100, max 200)"
+// @Param sort query string false "Sort fields, prefix - for descending"
 // @Success 200 {array} entity.Group
+// @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/groups [get]
 func GetGroups(c *gin.Context) {
-	var groups []entity.Group
-	query := config.DB
-
-	// Filter by active status if provided
-	if activeParam := c.Query("active"); activeParam != "" {
-		if active, err := strconv.ParseBool(activeParam); err == nil {
-			query = query.Where("active = ?", active)
-		}
+	params, ok := parseList(c, groupListSpec)
+	if !ok {
+		return
 	}
 
-	if result := query.Find(&groups); result.Error != nil {
+	var groups []entity.Group
+	page, err := groupListSpec.Find(config.DB, params, &groups)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data groups"})
 		return
 	}
 
+	setPaginationHeaders(c, page)
 	c.JSON(http.StatusOK, groups)
 }
 
@@ -167,7 +181,7 @@ func UpdateGroup(c *gin.Context) {
 	group.Value = input.Value
 	group.Active = input.Active
 
-	if result := config.DB.Save(&group); result.Error != nil {
+	if result := config.DB.WithContext(c).Save(&group); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengupdate group"})
 		return
 	}
@@ -199,9 +213,9 @@ func DeleteGroup(c *gin.Context) {
 	}
 
 	// Remove all customer-group associations first
-	config.DB.Model(&group).Association("Customers").Clear()
+	config.DB.WithContext(c).Model(&group).Association("Customers").Clear()
 
-	if result := config.DB.Delete(&group); result.Error != nil {
+	if result := config.DB.WithContext(c).Delete(&group); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus group"})
 		return
 	}
@@ -238,7 +252,7 @@ func AssignCustomerToGroup(c *gin.Context) {
 	}
 
 	// Add customer to group
-	if err := config.DB.Model(&group).Association("Customers").Append(&customer); err != nil {
+	if err := config.DB.WithContext(c).Model(&group).Association("Customers").Append(&customer); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menambahkan customer ke group"})
 		return
 	}
@@ -275,7 +289,7 @@ func RemoveCustomerFromGroup(c *gin.Context) {
 	}
 
 	// Remove customer from group
-	if err := config.DB.Model(&group).Association("Customers").Delete(&customer); err != nil {
+	if err := config.DB.WithContext(c).Model(&group).Association("Customers").Delete(&customer); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus customer dari group"})
 		return
 	}
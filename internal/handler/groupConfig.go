@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"customer-api/internal/config"
 	"customer-api/internal/entity"
+	"customer-api/internal/query"
 	"github.com/gin-gonic/gin"
 	"time"
 	"gorm.io/gorm"
@@ -44,7 +45,7 @@ func CreateConfigGroup(c *gin.Context) {
 		return
 	}
 
-	if result := config.DB.Create(&input); result.Error != nil {
+	if result := config.DB.WithContext(c).Create(&input); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal membuat config group",
@@ -58,7 +59,7 @@ func CreateConfigGroup(c *gin.Context) {
 	groupConfig := entity.GroupConfig{
 		Name: input.Name,
 	}
-	if result := config.DB.Create(&groupConfig); result.Error != nil {
+	if result := config.DB.WithContext(c).Create(&groupConfig); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal membuat group config",
@@ -76,17 +77,35 @@ func CreateConfigGroup(c *gin.Context) {
 }
 
 
+var groupConfigListSpec = query.Spec{
+	Table: "group_configs",
+	Fields: map[string]query.Field{
+		"name":       {},
+		"is_active":  {Type: query.Bool},
+		"created_at": {Type: query.Time},
+	},
+	DefaultSort:     "name",
+	DefaultPageSize: 100,
+}
+
 func GetConfigGroups(c *gin.Context) {
+	params, ok := parseList(c, groupConfigListSpec)
+	if !ok {
+		return
+	}
+
 	var groups []entity.GroupConfig
-	if result := config.DB.Find(&groups); result.Error != nil {
+	page, err := groupConfigListSpec.Find(config.DB, params, &groups)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data config groups"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Data config groups berhasil diambil",
-		"data":    groups,
+		"status":     "success",
+		"message":    "Data config groups berhasil diambil",
+		"data":       groups,
+		"pagination": page,
 	})
 }
 
@@ -126,7 +145,7 @@ func UpdateConfigGroup(c *gin.Context) {
 		group.Name = input.Name
 	}
 
-	if result := config.DB.Save(&group); result.Error != nil {
+	if result := config.DB.WithContext(c).Save(&group); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal memperbarui config group",
@@ -149,7 +168,7 @@ func DeleteConfigGroup(c *gin.Context) {
 		return
 	}
 
-	if result := config.DB.Delete(&group); result.Error != nil {
+	if result := config.DB.WithContext(c).Delete(&group); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal menghapus config group",
@@ -182,7 +201,7 @@ func CreateConfigGroupDetail(c *gin.Context) {
 		return
 	}
 
-	if result := config.DB.Create(&input); result.Error != nil {
+	if result := config.DB.WithContext(c).Create(&input); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal membuat config group detail",
@@ -249,7 +268,7 @@ func UpdateConfigGroupDetail(c *gin.Context) {
 	if input.IsActive != detail.IsActive {
 		detail.IsActive = input.IsActive
 	}
-	if result := config.DB.Save(&detail); result.Error != nil {
+	if result := config.DB.WithContext(c).Save(&detail); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal memperbarui config group detail",
@@ -271,7 +290,7 @@ func DeleteConfigGroupDetail(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Config group detail not found"})
 		return
 	}
-	if result := config.DB.Delete(&detail); result.Error != nil {
+	if result := config.DB.WithContext(c).Delete(&detail); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal menghapus config group detail",
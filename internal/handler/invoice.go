@@ -0,0 +1,644 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+	"customer-api/internal/query"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Status invoice diturunkan dari Amount, PaidAmount dan DueDate, tidak disimpan
+const (
+	InvoiceStatusPaid    = "Paid"
+	InvoiceStatusPartial = "Partial"
+	InvoiceStatusUnpaid  = "Unpaid"
+	InvoiceStatusOverdue = "Overdue"
+)
+
+// errInvoiceRejected membawa pesan validasi dari dalam transaksi
+type errInvoiceRejected struct {
+	message string
+}
+
+func (e errInvoiceRejected) Error() string {
+	return e.message
+}
+
+// errInvoiceHeld: kenaikan amount invoice masih menunggu approval
+var errInvoiceHeld = errors.New("invoice update is waiting for approval")
+
+// invoiceBalance returns the outstanding amount, rounded to cents
+func invoiceBalance(invoice entity.Invoice) float64 {
+	return math.Round((invoice.Amount-invoice.PaidAmount)*100) / 100
+}
+
+// invoiceStatus derives Paid/Partial/Unpaid/Overdue as of `now`
+func invoiceStatus(invoice entity.Invoice, now time.Time) string {
+	if invoiceBalance(invoice) <= 0 {
+		return InvoiceStatusPaid
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if invoice.DueDate.Before(today) {
+		return InvoiceStatusOverdue
+	}
+	if invoice.PaidAmount > 0 {
+		return InvoiceStatusPartial
+	}
+	return InvoiceStatusUnpaid
+}
+
+// whereInvoiceStatus is the SQL counterpart of invoiceStatus, used for filtering
+func whereInvoiceStatus(db *gorm.DB, status string) (*gorm.DB, bool) {
+	switch strings.ToLower(status) {
+	case "paid":
+		return db.Where("invoices.paid_amount >= invoices.amount"), true
+	case "overdue":
+		return db.Where("invoices.paid_amount < invoices.amount AND invoices.due_date < CURRENT_DATE"), true
+	case "partial":
+		return db.Where("invoices.paid_amount > 0 AND invoices.paid_amount < invoices.amount AND invoices.due_date >= CURRENT_DATE"), true
+	case "unpaid":
+		return db.Where("invoices.paid_amount <= 0 AND invoices.due_date >= CURRENT_DATE"), true
+	}
+	return db, false
+}
+
+// buildInvoiceItems converts request items to entities and returns their subtotal
+func buildInvoiceItems(reqItems []dto.InvoiceItemRequest) ([]entity.InvoiceItem, float64) {
+	items := make([]entity.InvoiceItem, 0, len(reqItems))
+	subtotal := 0.0
+	for i, item := range reqItems {
+		amount := roundMoney(item.Quantity * item.UnitPrice)
+		items = append(items, entity.InvoiceItem{
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Amount:      amount,
+			Position:    i + 1,
+		})
+		subtotal += amount
+	}
+	return items, roundMoney(subtotal)
+}
+
+func sumInvoiceItems(items []entity.InvoiceItem) float64 {
+	subtotal := 0.0
+	for _, item := range items {
+		subtotal += item.Amount
+	}
+	return roundMoney(subtotal)
+}
+
+// applyInvoiceTotals sets TaxAmount and Amount. With items the tax is added on
+// top of their subtotal; without items Amount is taken as tax-inclusive.
+func applyInvoiceTotals(invoice *entity.Invoice, items []entity.InvoiceItem) {
+	if len(items) > 0 {
+		subtotal := sumInvoiceItems(items)
+		invoice.TaxAmount = roundMoney(subtotal * invoice.TaxRate / 100)
+		invoice.Amount = roundMoney(subtotal + invoice.TaxAmount)
+		return
+	}
+	invoice.TaxAmount = roundMoney(invoice.Amount - invoice.Amount/(1+invoice.TaxRate/100))
+}
+
+// preloadInvoiceItems loads items in their printed order
+func preloadInvoiceItems(db *gorm.DB) *gorm.DB {
+	return db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	})
+}
+
+// templateExists checks an optional template_id from a request
+func templateExists(id *string) bool {
+	if id == nil || *id == "" {
+		return true
+	}
+	var count int64
+	config.DB.Model(&entity.InvoiceTemplate{}).Where("id = ?", *id).Count(&count)
+	return count > 0
+}
+
+func toInvoiceResponse(invoice entity.Invoice) dto.InvoiceResponse {
+	response := dto.InvoiceResponse{
+		ID:            invoice.ID,
+		CustomerID:    invoice.CustomerID,
+		ProjectID:     invoice.ProjectID,
+		InvoiceNumber: invoice.InvoiceNumber,
+		Amount:        invoice.Amount,
+		TaxRate:       invoice.TaxRate,
+		TaxAmount:     invoice.TaxAmount,
+		IssuedDate:    invoice.IssuedDate,
+		DueDate:       invoice.DueDate,
+		PaidAmount:    invoice.PaidAmount,
+		Balance:       invoiceBalance(invoice),
+		Status:        invoiceStatus(invoice, time.Now()),
+		Notes:         invoice.Notes,
+		TemplateID:    invoice.TemplateID,
+		CreatedAt:     invoice.CreatedAt,
+		UpdatedAt:     invoice.UpdatedAt,
+	}
+	if invoice.Customer.ID != "" {
+		response.Customer = &dto.CustomerResponse{
+			ID:               invoice.Customer.ID,
+			Name:             invoice.Customer.Name,
+			BrandName:        invoice.Customer.BrandName,
+			Code:             invoice.Customer.Code,
+			AccountManagerId: invoice.Customer.AccountManagerId,
+			Status:           invoice.Customer.Status,
+		}
+	}
+	for _, item := range invoice.Items {
+		response.Items = append(response.Items, dto.InvoiceItemResponse{
+			ID:          item.ID,
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Amount:      item.Amount,
+		})
+	}
+	return response
+}
+
+// nextInvoiceNumber atomically increments the counter for the given year and
+// returns the formatted number, e.g. INV-2025-00042. Must run inside tx.
+func nextInvoiceNumber(tx *gorm.DB, year int) (string, error) {
+	var lastNumber int
+	err := tx.Raw(`INSERT INTO invoice_sequences (year, last_number, updated_at) VALUES (?, 1, NOW())
+		ON CONFLICT (year) DO UPDATE SET last_number = invoice_sequences.last_number + 1, updated_at = NOW()
+		RETURNING last_number`, year).Scan(&lastNumber).Error
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("INV-%d-%05d", year, lastNumber), nil
+}
+
+// @Summary Create invoice
+// @Description Create a new invoice, the invoice number is generated per issued year.
+// @Description When items are given the amount is their subtotal plus tax.
+// @Tags Invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param invoice body dto.CreateInvoiceRequest true "Invoice data"
+// @Success 201 {object} dto.InvoiceResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/invoices [post]
+func CreateInvoice(c *gin.Context) {
+	var req dto.CreateInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.DueDate.Before(req.IssuedDate) {
+		sendError(c, http.StatusBadRequest, "due_date must not be before issued_date")
+		return
+	}
+
+	if !templateExists(req.TemplateID) {
+		sendError(c, http.StatusBadRequest, "Invoice template not found")
+		return
+	}
+
+	var customer entity.Customer
+	if err := config.DB.Where("id = ?", req.CustomerID).First(&customer).Error; err != nil {
+		sendError(c, http.StatusBadRequest, "Customer not found")
+		return
+	}
+
+	invoice := buildInvoice(req, customer.ID)
+	if invoice.Amount <= 0 {
+		sendError(c, http.StatusBadRequest, "amount must be greater than 0 (give amount or items)")
+		return
+	}
+
+	// Invoice besar menunggu approval, dibuat setelah level terakhir setuju
+	approval := entity.ApprovalRequest{
+		Action:      entity.ApprovalInvoiceCreate,
+		CustomerID:  customer.ID,
+		Summary:     fmt.Sprintf("Invoice %.2f for %s", invoice.Amount, customer.Name),
+		Value:       invoice.Amount,
+		RequestedBy: c.GetString("user_id"),
+	}
+	held, err := requestApproval(config.DB.WithContext(c), &approval, req)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to request approval")
+		return
+	}
+	if held {
+		sendApprovalRequired(c, &approval)
+		return
+	}
+
+	err = config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		return insertInvoice(tx, &invoice)
+	})
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to create invoice: "+err.Error())
+		return
+	}
+
+	invoice.Customer = customer
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Invoice created successfully",
+		"data":    toInvoiceResponse(invoice),
+	})
+}
+
+// buildInvoice turns a create request into an invoice with items and totals
+func buildInvoice(req dto.CreateInvoiceRequest, customerID string) entity.Invoice {
+	invoice := entity.Invoice{
+		CustomerID: customerID,
+		ProjectID:  req.ProjectID,
+		Amount:     req.Amount,
+		TaxRate:    req.TaxRate,
+		IssuedDate: req.IssuedDate,
+		DueDate:    req.DueDate,
+		Notes:      req.Notes,
+		TemplateID: req.TemplateID,
+	}
+	if invoice.TemplateID != nil && *invoice.TemplateID == "" {
+		invoice.TemplateID = nil
+	}
+
+	items, _ := buildInvoiceItems(req.Items)
+	applyInvoiceTotals(&invoice, items)
+	invoice.Items = items
+	return invoice
+}
+
+// insertInvoice numbers the invoice in its issued year and creates it
+func insertInvoice(tx *gorm.DB, invoice *entity.Invoice) error {
+	number, err := nextInvoiceNumber(tx, invoice.IssuedDate.Year())
+	if err != nil {
+		return err
+	}
+	invoice.InvoiceNumber = number
+	return tx.Create(invoice).Error
+}
+
+var invoiceListSpec = query.Spec{
+	Table: "invoices",
+	Fields: map[string]query.Field{
+		"customer_id":    {},
+		"project_id":     {},
+		"invoice_number": {},
+		"template_id":    {},
+		"amount":         {Type: query.Number},
+		"paid_amount":    {Type: query.Number},
+		"issued_date":    {Type: query.Time},
+		"due_date":       {Type: query.Time},
+		"created_at":     {Type: query.Time},
+	},
+	DefaultSort: "-issued_date",
+}
+
+// @Summary Get invoices
+// @Description Get a page of invoices filtered by customer, issued/due date range and derived status
+// @Tags Invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param customer_id query string false "Customer ID"
+// @Param status query string false "Derived status" Enums(Paid, Partial, Unpaid, Overdue)
+// @Param issued_from query string false "Issued date from (YYYY-MM-DD)"
+// @Param issued_to query string false "Issued date to (YYYY-MM-DD)"
+// @Param due_from query string false "Due date from (YYYY-MM-DD)"
+// @Param due_to query string false "Due date to (YYYY-MM-DD)"
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 20, max 200)"
+// @Param sort query string false "Sort fields, prefix - for descending, e.g. -issued_date"
+// @Param cursor query string false "Cursor pagination on id"
+// @Success 200 {array} dto.InvoiceResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/invoices [get]
+func GetInvoices(c *gin.Context) {
+	params, ok := parseList(c, invoiceListSpec)
+	if !ok {
+		return
+	}
+
+	db := config.DB.WithContext(c)
+
+	dateFilters := []struct {
+		param string
+		cond  string
+	}{
+		{"issued_from", "invoices.issued_date >= ?"},
+		{"issued_to", "invoices.issued_date < ?"},
+		{"due_from", "invoices.due_date >= ?"},
+		{"due_to", "invoices.due_date < ?"},
+	}
+	for _, f := range dateFilters {
+		value := c.Query(f.param)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "Invalid "+f.param+" format, use YYYY-MM-DD")
+			return
+		}
+		// *_to bersifat inklusif: sampai akhir hari tersebut
+		if strings.HasSuffix(f.param, "_to") {
+			date = date.AddDate(0, 0, 1)
+		}
+		db = db.Where(f.cond, date)
+	}
+
+	if status := c.Query("status"); status != "" {
+		var ok bool
+		if db, ok = whereInvoiceStatus(db, status); !ok {
+			sendError(c, http.StatusBadRequest, "Invalid status (must be Paid, Partial, Unpaid or Overdue)")
+			return
+		}
+	}
+
+	var invoices []entity.Invoice
+	page, err := invoiceListSpec.Find(db, params, &invoices, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Customer")
+	})
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch invoices")
+		return
+	}
+
+	responses := make([]dto.InvoiceResponse, 0, len(invoices))
+	for _, invoice := range invoices {
+		responses = append(responses, toInvoiceResponse(invoice))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"message":    "Invoices fetched successfully",
+		"data":       responses,
+		"pagination": page,
+	})
+}
+
+// @Summary Get invoice by ID
+// @Description Get a specific invoice with its balance and derived status
+// @Tags Invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} dto.InvoiceResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/invoices/{id} [get]
+func GetInvoice(c *gin.Context) {
+	id := c.Param("id")
+
+	var invoice entity.Invoice
+	if err := preloadInvoiceItems(config.DB.Preload("Customer")).Where("id = ?", id).First(&invoice).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Invoice not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Invoice fetched successfully",
+		"data":    toInvoiceResponse(invoice),
+	})
+}
+
+// @Summary Update invoice
+// @Description Update invoice fields, the amount cannot go below what has already been paid.
+// @Description Items, when given, replace the existing ones and the amount is recalculated.
+// @Description Raising the amount goes through the invoice_create approval (202) when a workflow covers the new amount.
+// @Tags Invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Param invoice body dto.UpdateInvoiceRequest true "Invoice data"
+// @Success 200 {object} dto.InvoiceResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/invoices/{id} [put]
+func UpdateInvoice(c *gin.Context) {
+	id := c.Param("id")
+
+	var req dto.UpdateInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.TemplateID != nil && !templateExists(req.TemplateID) {
+		sendError(c, http.StatusBadRequest, "Invoice template not found")
+		return
+	}
+
+	var invoice entity.Invoice
+	var approval entity.ApprovalRequest
+	held := false
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		previous, err := lockInvoiceUpdate(tx, id, req, &invoice)
+		if err != nil {
+			return err
+		}
+		if approval.ID = pendingApprovalID(tx, entity.ApprovalInvoiceCreate, invoice.ID); approval.ID != "" {
+			return errInvoiceHeld
+		}
+
+		// Kenaikan amount melewati approval yang sama dengan invoice baru
+		if invoice.Amount > previous {
+			approval = entity.ApprovalRequest{
+				Action:      entity.ApprovalInvoiceCreate,
+				EntityID:    invoice.ID,
+				CustomerID:  invoice.CustomerID,
+				Summary:     fmt.Sprintf("Invoice %s raised from %.2f to %.2f", invoice.InvoiceNumber, previous, invoice.Amount),
+				Value:       invoice.Amount,
+				RequestedBy: c.GetString("user_id"),
+			}
+			if held, err = requestApproval(tx, &approval, req); err != nil || held {
+				return err
+			}
+		}
+		return saveInvoiceUpdate(tx, &invoice, req.Items != nil)
+	})
+
+	var rejected errInvoiceRejected
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		sendError(c, http.StatusNotFound, "Invoice not found")
+		return
+	case errors.Is(err, errInvoiceHeld):
+		sendError(c, http.StatusConflict, "An amount increase of this invoice is already waiting for approval "+approval.ID)
+		return
+	case errors.As(err, &rejected):
+		sendError(c, http.StatusBadRequest, rejected.message)
+		return
+	case err != nil:
+		sendError(c, http.StatusInternalServerError, "Failed to update invoice")
+		return
+	}
+	if held {
+		sendApprovalRequired(c, &approval)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Invoice updated successfully",
+		"data":    toInvoiceResponse(invoice),
+	})
+}
+
+// lockInvoiceUpdate locks invoice id and applies req to it, without saving.
+// Items in req replace invoice.Items. Returns the amount before the change.
+func lockInvoiceUpdate(tx *gorm.DB, id string, req dto.UpdateInvoiceRequest, invoice *entity.Invoice) (float64, error) {
+	// Kunci invoice agar pembayaran yang masuk bersamaan ikut diperhitungkan
+	if err := preloadInvoiceItems(tx.Clauses(clause.Locking{Strength: "UPDATE"})).Where("id = ?", id).First(invoice).Error; err != nil {
+		return 0, err
+	}
+	previous := invoice.Amount
+
+	items := invoice.Items
+	if req.Items != nil {
+		items, _ = buildInvoiceItems(*req.Items)
+	}
+	if msg := applyInvoiceUpdate(invoice, req, items); msg != "" {
+		return 0, errInvoiceRejected{msg}
+	}
+	invoice.Items = items
+	return previous, nil
+}
+
+// saveInvoiceUpdate writes the edited columns of invoice and, when
+// replaceItems is set, swaps its items
+func saveInvoiceUpdate(tx *gorm.DB, invoice *entity.Invoice, replaceItems bool) error {
+	// paid_amount hanya diubah oleh pembayaran
+	columns := []string{"project_id", "amount", "tax_rate", "tax_amount", "template_id", "issued_date", "due_date", "notes"}
+	if err := tx.Model(invoice).Select(columns).Updates(invoice).Error; err != nil {
+		return err
+	}
+	if !replaceItems {
+		return nil
+	}
+	if err := tx.Unscoped().Where("invoice_id = ?", invoice.ID).Delete(&entity.InvoiceItem{}).Error; err != nil {
+		return err
+	}
+	for i := range invoice.Items {
+		invoice.Items[i].InvoiceID = invoice.ID
+	}
+	if len(invoice.Items) > 0 {
+		return tx.Create(&invoice.Items).Error
+	}
+	return nil
+}
+
+// applyInvoiceUpdate applies the request to a locked invoice and returns
+// a validation message when the result is not allowed
+func applyInvoiceUpdate(invoice *entity.Invoice, req dto.UpdateInvoiceRequest, items []entity.InvoiceItem) string {
+	if req.ProjectID != nil {
+		invoice.ProjectID = *req.ProjectID
+	}
+	if req.TaxRate != nil {
+		invoice.TaxRate = *req.TaxRate
+	}
+	if req.Amount != nil {
+		// Invoice dengan item: amount selalu dihitung dari item
+		if len(items) > 0 {
+			return "amount is calculated from items and cannot be set directly"
+		}
+		invoice.Amount = *req.Amount
+	}
+	applyInvoiceTotals(invoice, items)
+	if invoice.Amount < invoice.PaidAmount {
+		return "amount cannot be lower than the paid amount"
+	}
+
+	if req.TemplateID != nil {
+		invoice.TemplateID = req.TemplateID
+		if *req.TemplateID == "" {
+			invoice.TemplateID = nil
+		}
+	}
+	if req.IssuedDate != nil {
+		// Nomor invoice terikat ke tahun terbit
+		if req.IssuedDate.Year() != invoice.IssuedDate.Year() {
+			return "issued_date cannot move the invoice to another year"
+		}
+		invoice.IssuedDate = *req.IssuedDate
+	}
+	if req.DueDate != nil {
+		invoice.DueDate = *req.DueDate
+	}
+	if req.Notes != nil {
+		invoice.Notes = *req.Notes
+	}
+
+	if invoice.DueDate.Before(invoice.IssuedDate) {
+		return "due_date must not be before issued_date"
+	}
+	return ""
+}
+
+// @Summary Delete invoice
+// @Description Delete an invoice that has never received a payment, reversed payments included
+// @Tags Invoices
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} dto.Response
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/invoices/{id} [delete]
+func DeleteInvoice(c *gin.Context) {
+	id := c.Param("id")
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var invoice entity.Invoice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&invoice).Error; err != nil {
+			return err
+		}
+		// Pembayaran yang sudah di-reverse tetap jejak keuangan invoice ini
+		var payments int64
+		if err := tx.Unscoped().Model(&entity.Payment{}).Where("invoice_id = ?", invoice.ID).Count(&payments).Error; err != nil {
+			return err
+		}
+		if payments > 0 {
+			return errInvoiceRejected{"Invoice with payments cannot be deleted, including reversed ones"}
+		}
+		return tx.Delete(&invoice).Error
+	})
+
+	var rejected errInvoiceRejected
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		sendError(c, http.StatusNotFound, "Invoice not found")
+		return
+	case errors.As(err, &rejected):
+		sendError(c, http.StatusBadRequest, rejected.message)
+		return
+	case err != nil:
+		sendError(c, http.StatusInternalServerError, "Failed to delete invoice")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Invoice deleted successfully",
+		"data":    nil,
+	})
+}
@@ -0,0 +1,434 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/entity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// invoicePDF is everything rendered on a printed invoice
+type invoicePDF struct {
+	Invoice  entity.Invoice
+	Customer entity.Customer
+	Address  *entity.Address
+	Contact  *entity.Contact
+	Payments []entity.Payment
+	Template entity.InvoiceTemplate
+}
+
+// resolveInvoiceTemplate picks the invoice's own template, then the default one,
+// then a plain built-in template so printing never fails for lack of configuration
+func resolveInvoiceTemplate(invoice entity.Invoice) entity.InvoiceTemplate {
+	var template entity.InvoiceTemplate
+	if invoice.TemplateID != nil {
+		if err := config.DB.Where("id = ?", *invoice.TemplateID).First(&template).Error; err == nil {
+			return template
+		}
+	}
+	if err := config.DB.Where("is_default = ?", true).First(&template).Error; err == nil {
+		return template
+	}
+	return entity.InvoiceTemplate{PrimaryColor: defaultInvoiceColor}
+}
+
+// hexToRGB parses #RRGGBB, falling back to the default invoice color
+func hexToRGB(hex string) (int, int, int) {
+	if !hexColorPattern.MatchString(hex) {
+		hex = defaultInvoiceColor
+	}
+	value, _ := strconv.ParseUint(hex[1:], 16, 32)
+	return int(value >> 16 & 0xFF), int(value >> 8 & 0xFF), int(value & 0xFF)
+}
+
+// pdfImageType returns the gofpdf image type of a local file, or "" when the
+// file is missing, outside uploads/logos or not a format gofpdf can embed
+func pdfImageType(path string) string {
+	if _, ok := logoPathAllowed(path); !ok {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return "PNG"
+	case ".jpg", ".jpeg":
+		return "JPG"
+	case ".gif":
+		return "GIF"
+	}
+	return ""
+}
+
+// formatMoney formats an amount with thousand separators, e.g. 15,000,000.00
+func formatMoney(v float64) string {
+	text := strconv.FormatFloat(math.Abs(v), 'f', 2, 64)
+	whole, fraction := text[:len(text)-3], text[len(text)-3:]
+	var out []byte
+	for i := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			out = append(out, ',')
+		}
+		out = append(out, whole[i])
+	}
+	if v < 0 {
+		return "-" + string(out) + fraction
+	}
+	return string(out) + fraction
+}
+
+// drawLogo places an image with the given height at (x, y) and returns its width.
+// Unreadable images are skipped instead of failing the whole document.
+func drawLogo(pdf *gofpdf.Fpdf, path string, x, y, h, maxW float64) float64 {
+	imageType := pdfImageType(path)
+	if imageType == "" {
+		return 0
+	}
+	path = filepath.Clean(path)
+	options := gofpdf.ImageOptions{ImageType: imageType, ReadDpi: true}
+	info := pdf.RegisterImageOptions(path, options)
+	if !pdf.Ok() || info == nil || info.Height() == 0 {
+		pdf.ClearError()
+		return 0
+	}
+	w := h * info.Width() / info.Height()
+	if w > maxW {
+		w, h = maxW, maxW*info.Height()/info.Width()
+	}
+	pdf.ImageOptions(path, x, y, w, h, false, options, 0, "")
+	return w
+}
+
+func renderInvoicePDF(data invoicePDF) ([]byte, error) {
+	const (
+		margin    = 15.0
+		lineH     = 4.5
+		bodyWidth = 180.0
+	)
+	invoice, template := data.Invoice, data.Template
+	r, g, b := hexToRGB(template.PrimaryColor)
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AliasNbPages("")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Arial", "I", 8)
+		pdf.SetTextColor(120, 120, 120)
+		footer := fmt.Sprintf("%s - Page %d/{nb}", invoice.InvoiceNumber, pdf.PageNo())
+		if template.FooterText != "" {
+			footer = tr(template.FooterText) + "   |   " + footer
+		}
+		pdf.CellFormat(0, 10, footer, "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	// Header: logo dan identitas perusahaan di kiri, judul di kanan
+	textX := margin
+	if w := drawLogo(pdf, template.LogoPath, margin, margin, 18, 40); w > 0 {
+		textX += w + 4
+	}
+	pdf.SetXY(textX, margin)
+	pdf.SetFont("Arial", "B", 14)
+	pdf.SetTextColor(r, g, b)
+	pdf.CellFormat(125-textX, 7, tr(template.CompanyName), "", 2, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	pdf.SetTextColor(80, 80, 80)
+	companyLines := []string{template.CompanyAddress, template.CompanyPhone, template.CompanyEmail}
+	if template.CompanyTaxID != "" {
+		companyLines = append(companyLines, "NPWP: "+template.CompanyTaxID)
+	}
+	for _, line := range companyLines {
+		if line == "" {
+			continue
+		}
+		pdf.SetX(textX)
+		pdf.MultiCell(125-textX, lineH, tr(line), "", "L", false)
+	}
+	headerBottom := math.Max(pdf.GetY(), margin+18)
+
+	pdf.SetXY(125, margin)
+	pdf.SetFont("Arial", "B", 22)
+	pdf.SetTextColor(r, g, b)
+	pdf.CellFormat(70, 10, "INVOICE", "", 2, "R", false, 0, "")
+	pdf.SetFont("Arial", "B", 10)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.CellFormat(70, 6, invoice.InvoiceNumber, "", 2, "R", false, 0, "")
+
+	y := headerBottom + 4
+	pdf.SetDrawColor(r, g, b)
+	pdf.SetLineWidth(0.6)
+	pdf.Line(margin, y, margin+bodyWidth, y)
+	pdf.SetLineWidth(0.2)
+	pdf.SetDrawColor(180, 180, 180)
+	y += 6
+
+	// Bill To
+	pdf.SetXY(margin, y)
+	pdf.SetFont("Arial", "B", 9)
+	pdf.SetTextColor(r, g, b)
+	pdf.CellFormat(100, 5, "BILL TO", "", 2, "L", false, 0, "")
+	billY := pdf.GetY() + 1
+	billX := margin
+	if w := drawLogo(pdf, data.Customer.Logo, margin, billY, 14, 30); w > 0 {
+		billX += w + 3
+	}
+	pdf.SetXY(billX, billY)
+	pdf.SetFont("Arial", "B", 11)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.MultiCell(margin+100-billX, 5.5, tr(data.Customer.Name), "", "L", false)
+
+	var billLines []string
+	if a := data.Address; a != nil {
+		billLines = append(billLines, joinNonEmpty(", ", a.Street, a.Address))
+		billLines = append(billLines, joinNonEmpty(" ", joinNonEmpty(", ", a.City, a.State), a.PostalCode))
+		billLines = append(billLines, a.Country)
+	}
+	if ct := data.Contact; ct != nil {
+		attn := "Attn: " + ct.Name
+		if ct.Position != "" {
+			attn += " (" + ct.Position + ")"
+		}
+		phone := ct.Phone
+		if phone == "" {
+			phone = ct.Mobile
+		}
+		billLines = append(billLines, attn, ct.Email, phone)
+	}
+	pdf.SetFont("Arial", "", 9)
+	pdf.SetTextColor(60, 60, 60)
+	for _, line := range billLines {
+		if line == "" {
+			continue
+		}
+		pdf.SetX(billX)
+		pdf.MultiCell(margin+100-billX, lineH, tr(line), "", "L", false)
+	}
+	billBottom := math.Max(pdf.GetY(), billY+14)
+
+	// Detail invoice di kanan
+	details := [][2]string{
+		{"Issued Date", invoice.IssuedDate.Format("02 Jan 2006")},
+		{"Due Date", invoice.DueDate.Format("02 Jan 2006")},
+		{"Status", invoiceStatus(invoice, time.Now())},
+	}
+	if invoice.ProjectID != "" {
+		details = append(details, [2]string{"Project", invoice.ProjectID})
+	}
+	pdf.SetY(y)
+	for _, d := range details {
+		pdf.SetX(125)
+		pdf.SetFont("Arial", "", 9)
+		pdf.SetTextColor(100, 100, 100)
+		pdf.CellFormat(30, 5.5, d[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "B", 9)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.CellFormat(40, 5.5, tr(d[1]), "", 1, "R", false, 0, "")
+	}
+
+	pdf.SetY(math.Max(billBottom, pdf.GetY()) + 8)
+
+	// Tabel item
+	widths := []float64{10, 88, 22, 30, 30}
+	headers := []string{"No", "Description", "Qty", "Unit Price", "Amount"}
+	drawItemHeader := func() {
+		pdf.SetFont("Arial", "B", 9)
+		pdf.SetFillColor(r, g, b)
+		pdf.SetTextColor(255, 255, 255)
+		for i, h := range headers {
+			align := "C"
+			if i >= 2 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 7, h, "1", 0, align, true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Arial", "", 9)
+		pdf.SetTextColor(0, 0, 0)
+	}
+	drawItemHeader()
+
+	items := invoice.Items
+	subtotal := sumInvoiceItems(items)
+	if len(items) == 0 {
+		// Invoice lama tanpa item dicetak sebagai satu baris
+		description := invoice.Notes
+		if description == "" {
+			description = "Invoice " + invoice.InvoiceNumber
+		}
+		subtotal = roundMoney(invoice.Amount - invoice.TaxAmount)
+		items = []entity.InvoiceItem{{Description: description, Quantity: 1, UnitPrice: subtotal, Amount: subtotal}}
+	}
+	_, pageHeight := pdf.GetPageSize()
+	for i, item := range items {
+		lines := pdf.SplitLines([]byte(tr(item.Description)), widths[1]-2)
+		h := 6 * math.Max(1, float64(len(lines)))
+		if pdf.GetY()+h > pageHeight-25 {
+			pdf.AddPage()
+			drawItemHeader()
+		}
+		x0, y0 := pdf.GetXY()
+		pdf.CellFormat(widths[0], h, strconv.Itoa(i+1), "1", 0, "C", false, 0, "")
+		pdf.Rect(x0+widths[0], y0, widths[1], h, "D")
+		pdf.SetXY(x0+widths[0], y0)
+		pdf.MultiCell(widths[1], 6, tr(item.Description), "", "L", false)
+		pdf.SetXY(x0+widths[0]+widths[1], y0)
+		pdf.CellFormat(widths[2], h, strconv.FormatFloat(item.Quantity, 'f', -1, 64), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], h, formatMoney(item.UnitPrice), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], h, formatMoney(item.Amount), "1", 0, "R", false, 0, "")
+		pdf.SetXY(x0, y0+h)
+	}
+
+	// Ringkasan total
+	pdf.Ln(4)
+	totals := []struct {
+		label  string
+		value  float64
+		strong bool
+	}{
+		{"Subtotal", subtotal, false},
+		{"Tax (" + strconv.FormatFloat(invoice.TaxRate, 'f', -1, 64) + "%)", invoice.TaxAmount, false},
+		{"Total", invoice.Amount, true},
+		{"Paid", invoice.PaidAmount, false},
+		{"Balance Due", invoiceBalance(invoice), true},
+	}
+	for i, t := range totals {
+		style := ""
+		if t.strong {
+			style = "B"
+		}
+		last := i == len(totals)-1
+		pdf.SetFont("Arial", style, 9)
+		if last {
+			pdf.SetFillColor(r, g, b)
+			pdf.SetTextColor(255, 255, 255)
+		}
+		pdf.SetX(margin + bodyWidth - 70)
+		pdf.CellFormat(40, 6.5, t.label, "", 0, "L", last, 0, "")
+		pdf.CellFormat(30, 6.5, formatMoney(t.value), "", 1, "R", last, 0, "")
+	}
+	pdf.SetTextColor(0, 0, 0)
+
+	// Riwayat pembayaran
+	pdf.Ln(6)
+	pdf.SetFont("Arial", "B", 10)
+	pdf.SetTextColor(r, g, b)
+	pdf.CellFormat(0, 7, "Payment History", "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	if len(data.Payments) == 0 {
+		pdf.SetFont("Arial", "I", 9)
+		pdf.CellFormat(0, 6, "No payments recorded.", "", 1, "L", false, 0, "")
+	} else {
+		payWidths := []float64{35, 35, 70, 40}
+		pdf.SetFont("Arial", "B", 9)
+		pdf.SetFillColor(235, 235, 235)
+		for i, h := range []string{"Date", "Method", "Reference", "Amount"} {
+			align := "L"
+			if i == 3 {
+				align = "R"
+			}
+			pdf.CellFormat(payWidths[i], 6.5, h, "1", 0, align, true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Arial", "", 9)
+		for _, p := range data.Payments {
+			pdf.CellFormat(payWidths[0], 6, p.PaidAt.Format("02 Jan 2006"), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(payWidths[1], 6, tr(p.Method), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(payWidths[2], 6, tr(p.Reference), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(payWidths[3], 6, formatMoney(p.AppliedAmount), "1", 1, "R", false, 0, "")
+		}
+	}
+
+	if template.PaymentInstructions != "" {
+		pdf.Ln(6)
+		pdf.SetFont("Arial", "B", 10)
+		pdf.SetTextColor(r, g, b)
+		pdf.CellFormat(0, 7, "Payment Instructions", "", 1, "L", false, 0, "")
+		pdf.SetFont("Arial", "", 9)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.MultiCell(0, lineH+0.5, tr(template.PaymentInstructions), "", "L", false)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if strings.TrimSpace(part) != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+// @Summary Download invoice PDF
+// @Description Render a printable invoice with company template, customer logo, main address and contact, line items, tax, totals and payment history
+// @Tags Invoices
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Success 200 {file} file
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/invoices/{id}/pdf [get]
+func GetInvoicePDF(c *gin.Context) {
+	id := c.Param("id")
+
+	var invoice entity.Invoice
+	if err := preloadInvoiceItems(config.DB.Preload("Customer")).Where("id = ?", id).First(&invoice).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Invoice not found")
+		return
+	}
+
+	data := invoicePDF{
+		Invoice:  invoice,
+		Customer: invoice.Customer,
+		Template: resolveInvoiceTemplate(invoice),
+	}
+
+	// Alamat dan kontak utama; jika tidak ada yang ditandai main, pakai yang pertama
+	var address entity.Address
+	if err := config.DB.Where("customer_id = ?", invoice.CustomerID).
+		Order("main DESC, created_at ASC").First(&address).Error; err == nil {
+		data.Address = &address
+	}
+	var contact entity.Contact
+	if err := config.DB.Where("customer_id = ?", invoice.CustomerID).
+		Order("main DESC, created_at ASC").First(&contact).Error; err == nil {
+		data.Contact = &contact
+	}
+
+	if err := config.DB.Where("invoice_id = ? AND reversed_at IS NULL", invoice.ID).
+		Order("paid_at ASC").Find(&data.Payments).Error; err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch payments")
+		return
+	}
+
+	file, err := renderInvoicePDF(data)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to create pdf file: "+err.Error())
+		return
+	}
+	sendFile(c, file, invoice.InvoiceNumber+".pdf", mimePDF)
+}
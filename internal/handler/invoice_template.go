@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const defaultInvoiceColor = "#1F4E79"
+
+// Logo yang boleh dibaca renderer PDF hanya file hasil upload
+const logoUploadDir = "uploads/logos"
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// logoPathAllowed cleans a logo path and reports whether it stays inside
+// uploads/logos, so a template or customer cannot embed arbitrary local files
+func logoPathAllowed(path string) (string, bool) {
+	cleaned := filepath.Clean(path)
+	if filepath.IsAbs(cleaned) || !strings.HasPrefix(cleaned, logoUploadDir+string(filepath.Separator)) {
+		return "", false
+	}
+	return cleaned, true
+}
+
+// applyInvoiceTemplateRequest copies request fields onto the template and
+// validates them, returns the error message or "" when valid
+func applyInvoiceTemplateRequest(template *entity.InvoiceTemplate, req dto.InvoiceTemplateRequest) string {
+	if req.PrimaryColor == "" {
+		req.PrimaryColor = defaultInvoiceColor
+	}
+	if !hexColorPattern.MatchString(req.PrimaryColor) {
+		return "primary_color must be a hex color like #1F4E79"
+	}
+	if req.LogoPath != "" {
+		logoPath, ok := logoPathAllowed(req.LogoPath)
+		if !ok {
+			return "logo_path must point to a file in " + logoUploadDir + "/"
+		}
+		req.LogoPath = logoPath
+	}
+	template.Name = req.Name
+	template.CompanyName = req.CompanyName
+	template.CompanyAddress = req.CompanyAddress
+	template.CompanyPhone = req.CompanyPhone
+	template.CompanyEmail = req.CompanyEmail
+	template.CompanyTaxID = req.CompanyTaxID
+	template.LogoPath = req.LogoPath
+	template.PrimaryColor = req.PrimaryColor
+	template.PaymentInstructions = req.PaymentInstructions
+	template.FooterText = req.FooterText
+	template.IsDefault = req.IsDefault
+	return ""
+}
+
+// saveInvoiceTemplate stores the template; only one template can be the default
+func saveInvoiceTemplate(c *gin.Context, template *entity.InvoiceTemplate) error {
+	return config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if template.IsDefault {
+			query := tx.Model(&entity.InvoiceTemplate{}).Where("is_default = ?", true)
+			if template.ID != "" {
+				query = query.Where("id <> ?", template.ID)
+			}
+			if err := query.Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(template).Error
+	})
+}
+
+// @Summary Create invoice template
+// @Description Create a company template used when printing invoice PDFs
+// @Tags Invoice Templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param template body dto.InvoiceTemplateRequest true "Template data"
+// @Success 201 {object} entity.InvoiceTemplate
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/invoice-templates [post]
+func CreateInvoiceTemplate(c *gin.Context) {
+	var req dto.InvoiceTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var template entity.InvoiceTemplate
+	if msg := applyInvoiceTemplateRequest(&template, req); msg != "" {
+		sendError(c, http.StatusBadRequest, msg)
+		return
+	}
+
+	if err := saveInvoiceTemplate(c, &template); err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to create invoice template: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Invoice template created successfully",
+		"data":    template,
+	})
+}
+
+// @Summary Get invoice templates
+// @Description Get all invoice templates, the default template first
+// @Tags Invoice Templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} entity.InvoiceTemplate
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/invoice-templates [get]
+func GetInvoiceTemplates(c *gin.Context) {
+	var templates []entity.InvoiceTemplate
+	if err := config.DB.Order("is_default DESC, name ASC").Find(&templates).Error; err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch invoice templates")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Invoice templates fetched successfully",
+		"data":    templates,
+	})
+}
+
+// @Summary Get invoice template by ID
+// @Description Get a specific invoice template
+// @Tags Invoice Templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Template ID"
+// @Success 200 {object} entity.InvoiceTemplate
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/invoice-templates/{id} [get]
+func GetInvoiceTemplate(c *gin.Context) {
+	var template entity.InvoiceTemplate
+	if err := config.DB.Where("id = ?", c.Param("id")).First(&template).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Invoice template not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Invoice template fetched successfully",
+		"data":    template,
+	})
+}
+
+// @Summary Update invoice template
+// @Description Replace an invoice template, setting is_default unsets the previous default
+// @Tags Invoice Templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Template ID"
+// @Param template body dto.InvoiceTemplateRequest true "Template data"
+// @Success 200 {object} entity.InvoiceTemplate
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/invoice-templates/{id} [put]
+func UpdateInvoiceTemplate(c *gin.Context) {
+	var template entity.InvoiceTemplate
+	if err := config.DB.Where("id = ?", c.Param("id")).First(&template).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Invoice template not found")
+		return
+	}
+
+	var req dto.InvoiceTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if msg := applyInvoiceTemplateRequest(&template, req); msg != "" {
+		sendError(c, http.StatusBadRequest, msg)
+		return
+	}
+
+	if err := saveInvoiceTemplate(c, &template); err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to update invoice template: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Invoice template updated successfully",
+		"data":    template,
+	})
+}
+
+// @Summary Delete invoice template
+// @Description Delete an invoice template that is not assigned to any invoice
+// @Tags Invoice Templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Template ID"
+// @Success 200 {object} dto.Response
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/invoice-templates/{id} [delete]
+func DeleteInvoiceTemplate(c *gin.Context) {
+	var template entity.InvoiceTemplate
+	if err := config.DB.Where("id = ?", c.Param("id")).First(&template).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Invoice template not found")
+		return
+	}
+
+	var used int64
+	config.DB.Model(&entity.Invoice{}).Where("template_id = ?", template.ID).Count(&used)
+	if used > 0 {
+		sendError(c, http.StatusBadRequest, "Invoice template is still used by invoices")
+		return
+	}
+
+	if err := config.DB.WithContext(c).Delete(&template).Error; err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to delete invoice template")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Invoice template deleted successfully",
+		"data":    nil,
+	})
+}
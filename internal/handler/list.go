@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"customer-api/internal/dto"
+	"customer-api/internal/query"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseList reads page, sort and filter parameters for a list endpoint and
+// answers 400 itself when they are invalid
+func parseList(c *gin.Context, spec query.Spec) (query.Params, bool) {
+	params, err := spec.Parse(c.Request.URL.Query())
+	if err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return params, false
+	}
+	return params, true
+}
+
+// setPaginationHeaders exposes the page info for endpoints that answer a bare array
+func setPaginationHeaders(c *gin.Context, page dto.Pagination) {
+	if page.Total != nil {
+		c.Header("X-Total-Count", strconv.FormatInt(*page.Total, 10))
+	}
+	if page.NextCursor != "" {
+		c.Header("X-Next-Cursor", page.NextCursor)
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"customer-api/internal/entity"
 
 	"github.com/gin-gonic/gin"
-	// Hapus import "gorm.io/gorm" karena tidak digunakan
+	"gorm.io/gorm"
 )
 
 type OtherInput struct {
@@ -129,7 +129,7 @@ func GetOther(c *gin.Context) {
 	other.Value = input.Value
 	other.Active = input.Active
 
-	if result := config.DB.Save(&other); result.Error != nil {
+	if result := config.DB.WithContext(c).Save(&other); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update other attribute"})
 		return
 	}
@@ -152,12 +152,18 @@ func DeleteOther(c *gin.Context) {
 	id := c.Param("id")
 	var other entity.Other
 
-	if result := config.DB.First(&other, id); result.Error != nil {
+	if result := config.DB.Where("id = ?", id).First(&other); result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Other attribute not found"})
 		return
 	}
 
-	if result := config.DB.Delete(&other); result.Error != nil {
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&other).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, c.GetString("user_id"), "", &other, nil)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete other attribute"})
 		return
 	}
@@ -245,17 +251,31 @@ func CreateOther(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.CustomerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "customer_id is required"})
+		return
+	}
+
+	var customer entity.Customer
+	if err := config.DB.Where("id = ?", req.CustomerID).First(&customer).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
 
-	// Hapus validasi customer exists karena CustomerID tidak ada di request
 	other := entity.Other{
-		// CustomerID: req.CustomerID, // Akan diset sesuai kebutuhan
+		CustomerID: customer.ID,
 		Key:        req.Key,
 		Value:      req.Value,
 		Active:     req.Active,
 	}
 
-	result := config.DB.Create(&other)
-	if result.Error != nil {
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&other).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, c.GetString("user_id"), "", nil, &other)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create other field"})
 		return
 	}
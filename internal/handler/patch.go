@@ -0,0 +1,433 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"sort"
+	"strings"
+
+	"customer-api/internal/config"
+	"customer-api/internal/entity"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PATCH memakai JSON Merge Patch (RFC 7396): hanya field yang dikirim yang
+// berubah, null mengosongkan field. Field yang boleh diubah dibatasi per
+// entity; id, customer_id, timestamp dan version tidak pernah bisa ditulis.
+
+const mergePatchContentType = "application/merge-patch+json"
+
+type patchSpec struct {
+	name     string      // for messages, e.g. "Customer"
+	model    interface{} // zero value, e.g. &entity.Customer{}
+	writable map[string]bool
+	// validate checks the patched record and returns errors per field
+	validate func(tx *gorm.DB, record interface{}) map[string]string
+	// beforeSave runs in the transaction, e.g. to keep a single main address
+	beforeSave func(tx *gorm.DB, userID string, record interface{}) error
+}
+
+var errInvalidPatch = errors.New("invalid merge patch")
+
+func (s patchSpec) versioned() bool {
+	_, ok := reflect.TypeOf(s.model).Elem().FieldByName("Version")
+	return ok
+}
+
+// applyMergePatch applies a flat merge patch to a struct pointer. Every member
+// must be a writable json field; null resets it to its empty value.
+func applyMergePatch(record interface{}, patch map[string]json.RawMessage, writable map[string]bool) map[string]string {
+	v := reflect.ValueOf(record).Elem()
+	fields := make(map[string]reflect.Value)
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		fields[name] = v.Field(i)
+	}
+
+	errs := make(map[string]string)
+	for name, raw := range patch {
+		field, ok := fields[name]
+		if !ok || !writable[name] {
+			errs[name] = "is not writable"
+			continue
+		}
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+		value := reflect.New(field.Type())
+		if err := json.Unmarshal(raw, value.Interface()); err != nil {
+			errs[name] = "must be " + jsonTypeName(field.Type())
+			continue
+		}
+		field.Set(value.Elem())
+	}
+	return errs
+}
+
+func jsonTypeName(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Struct:
+		return "an RFC 3339 date"
+	}
+	return "a string"
+}
+
+// readMergePatch reads the request body as a merge patch object. Both
+// application/merge-patch+json and application/json are accepted.
+func readMergePatch(c *gin.Context) (map[string]json.RawMessage, bool) {
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType != mergePatchContentType && mediaType != "application/json" {
+		sendError(c, http.StatusUnsupportedMediaType, "Content-Type must be "+mergePatchContentType)
+		return nil, false
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "Failed to read request body")
+		return nil, false
+	}
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		sendError(c, http.StatusBadRequest, "Request body must be a JSON object")
+		return nil, false
+	}
+	return patch, true
+}
+
+func sendFieldErrors(c *gin.Context, message string, errs map[string]string) {
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	c.JSON(http.StatusBadRequest, gin.H{
+		"status":  "failed",
+		"message": message + ": " + strings.Join(names, ", "),
+		"data":    errs,
+	})
+}
+
+// patchRecord handles PATCH /<resource>/:id for a customer or child record
+func patchRecord(c *gin.Context, spec patchSpec) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	record := reflect.New(reflect.TypeOf(spec.model).Elem()).Interface()
+	if err := config.DB.Where("id = ?", id).First(record).Error; err != nil {
+		sendError(c, http.StatusNotFound, spec.name+" not found")
+		return
+	}
+	if spec.versioned() && !checkIfMatch(c, record) {
+		return
+	}
+	patch, ok := readMergePatch(c)
+	if !ok {
+		return
+	}
+
+	before := reflect.New(reflect.TypeOf(record).Elem())
+	before.Elem().Set(reflect.ValueOf(record).Elem())
+	if errs := applyMergePatch(record, patch, spec.writable); len(errs) > 0 {
+		sendFieldErrors(c, "Invalid patch", errs)
+		return
+	}
+	if errs := spec.validate(config.DB, record); len(errs) > 0 {
+		sendFieldErrors(c, "Validation failed", errs)
+		return
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if spec.versioned() {
+			if err := lockVersion(tx, spec.model, id, versionOf(before.Interface())); err != nil {
+				return err
+			}
+		}
+		if spec.beforeSave != nil {
+			if err := spec.beforeSave(tx, userID, record); err != nil {
+				return err
+			}
+		}
+		if err := tx.Omit(clause.Associations).Save(record).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, userID, "", before.Interface(), record)
+	})
+	if errors.Is(err, errVersionConflict) {
+		sendVersionConflict(c, reflect.New(reflect.TypeOf(spec.model).Elem()).Interface(), id)
+		return
+	}
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to update "+strings.ToLower(spec.name))
+		return
+	}
+
+	if spec.versioned() {
+		setETag(c, record)
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+func required(errs map[string]string, field, value string) {
+	if strings.TrimSpace(value) == "" {
+		errs[field] = "is required"
+	}
+}
+
+func validEmail(errs map[string]string, field, value string) {
+	if value == "" {
+		return
+	}
+	if addr, err := mail.ParseAddress(value); err != nil || addr.Address != value {
+		errs[field] = "must be a valid email address"
+	}
+}
+
+var customerPatch = patchSpec{
+	name:  "Customer",
+	model: &entity.Customer{},
+	// Status lewat PUT /customers/:id/status (dengan alasan), logo lewat upload
+	writable: map[string]bool{
+		"name": true, "brand_name": true, "code": true, "account_manager_id": true,
+		"email": true, "phone": true, "website": true, "description": true,
+		"category": true, "rating": true, "average_cost": true,
+	},
+	validate: func(db *gorm.DB, record interface{}) map[string]string {
+		customer := record.(*entity.Customer)
+		errs := make(map[string]string)
+		required(errs, "name", customer.Name)
+		validEmail(errs, "email", customer.Email)
+		if customer.Rating < 0 || customer.Rating > 5 {
+			errs["rating"] = "must be between 0 and 5"
+		}
+		if customer.AverageCost < 0 {
+			errs["average_cost"] = "must not be negative"
+		}
+		if customer.Code != "" {
+			var count int64
+			db.Model(&entity.Customer{}).Where("code = ? AND id <> ?", customer.Code, customer.ID).Count(&count)
+			if count > 0 {
+				errs["code"] = "is already used by another customer"
+			}
+		}
+		return errs
+	},
+}
+
+var addressPatch = patchSpec{
+	name:  "Address",
+	model: &entity.Address{},
+	writable: map[string]bool{
+		"name": true, "street": true, "address": true, "city": true, "state": true,
+		"country": true, "postal_code": true, "main": true, "active": true,
+	},
+	validate: func(db *gorm.DB, record interface{}) map[string]string {
+		address := record.(*entity.Address)
+		errs := make(map[string]string)
+		required(errs, "name", address.Name)
+		required(errs, "address", address.Address)
+		return errs
+	},
+	beforeSave: func(tx *gorm.DB, userID string, record interface{}) error {
+		if address := record.(*entity.Address); address.Main {
+			return demoteMainAddresses(tx, userID, address)
+		}
+		return nil
+	},
+}
+
+var contactPatch = patchSpec{
+	name:  "Contact",
+	model: &entity.Contact{},
+	writable: map[string]bool{
+		"name": true, "birthdate": true, "job_position": true, "position": true, "email": true,
+		"phone": true, "mobile": true, "department": true, "main": true, "active": true,
+	},
+	validate: func(db *gorm.DB, record interface{}) map[string]string {
+		contact := record.(*entity.Contact)
+		errs := make(map[string]string)
+		required(errs, "name", contact.Name)
+		validEmail(errs, "email", contact.Email)
+		return errs
+	},
+}
+
+var sosmedPatch = patchSpec{
+	name:  "Sosmed",
+	model: &entity.Sosmed{},
+	writable: map[string]bool{
+		"name": true, "platform": true, "handle": true, "username": true,
+		"url": true, "followers": true, "active": true,
+	},
+	validate: func(db *gorm.DB, record interface{}) map[string]string {
+		sosmed := record.(*entity.Sosmed)
+		errs := make(map[string]string)
+		required(errs, "platform", sosmed.Platform)
+		required(errs, "handle", sosmed.Handle)
+		if sosmed.Followers < 0 {
+			errs["followers"] = "must not be negative"
+		}
+		return errs
+	},
+}
+
+var structurePatch = patchSpec{
+	name:  "Structure",
+	model: &entity.Structure{},
+	writable: map[string]bool{
+		"name": true, "level": true, "parent_id": true, "address": true, "position": true, "active": true,
+	},
+	validate: func(db *gorm.DB, record interface{}) map[string]string {
+		structure := record.(*entity.Structure)
+		errs := make(map[string]string)
+		required(errs, "name", structure.Name)
+		if structure.Level < 1 {
+			errs["level"] = "must be at least 1"
+		}
+		if structure.ParentID != nil {
+			if msg := validateStructureParent(db, structure); msg != "" {
+				errs["parent_id"] = msg
+			}
+		}
+		return errs
+	},
+}
+
+// validateStructureParent checks that the parent belongs to the same customer
+// and that moving the structure does not create a cycle
+func validateStructureParent(db *gorm.DB, structure *entity.Structure) string {
+	parentID := *structure.ParentID
+	for depth := 0; parentID != ""; depth++ {
+		if parentID == structure.ID || depth > 100 {
+			return "would create a cycle"
+		}
+		var parent entity.Structure
+		if err := db.Where("id = ?", parentID).First(&parent).Error; err != nil {
+			return "parent structure not found"
+		}
+		if parent.CustomerID != structure.CustomerID {
+			return "must belong to the same customer"
+		}
+		if parent.ParentID == nil {
+			break
+		}
+		parentID = *parent.ParentID
+	}
+	return ""
+}
+
+// @Summary Patch customer
+// @Description Partial update with JSON Merge Patch (RFC 7396): only the members sent are changed, null clears a field.
+// @Description Writable: name, brand_name, code, account_manager_id, email, phone, website, description, category, rating, average_cost.
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Param If-Match header string true "ETag from the last GET"
+// @Param patch body object true "Merge patch, e.g. {\"phone\": \"021-555\", \"website\": null}"
+// @Success 200 {object} entity.Customer
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 415 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Router /api/customers/{id} [patch]
+func PatchCustomer(c *gin.Context) {
+	patchRecord(c, customerPatch)
+}
+
+// @Summary Patch address
+// @Description Partial update with JSON Merge Patch (RFC 7396). Writable: name, street, address, city, state, country,
+// @Description postal_code, main, active. Setting main to true clears it on the customer's other addresses.
+// @Tags Addresses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Address ID"
+// @Param If-Match header string true "ETag from the last GET"
+// @Param patch body object true "Merge patch"
+// @Success 200 {object} entity.Address
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 415 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Router /api/addresses/{id} [patch]
+func PatchAddress(c *gin.Context) {
+	patchRecord(c, addressPatch)
+}
+
+// @Summary Patch contact
+// @Description Partial update with JSON Merge Patch (RFC 7396). Writable: name, birthdate, job_position, position,
+// @Description email, phone, mobile, department, main, active.
+// @Tags Contacts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Contact ID"
+// @Param If-Match header string true "ETag from the last GET"
+// @Param patch body object true "Merge patch"
+// @Success 200 {object} entity.Contact
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 415 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Router /api/contacts/{id} [patch]
+func PatchContact(c *gin.Context) {
+	patchRecord(c, contactPatch)
+}
+
+// @Summary Patch sosmed
+// @Description Partial update with JSON Merge Patch (RFC 7396). Writable: name, platform, handle, username, url, followers, active.
+// @Tags Social Media
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Sosmed ID"
+// @Param patch body object true "Merge patch"
+// @Success 200 {object} entity.Sosmed
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 415 {object} dto.ErrorResponse
+// @Router /api/sosmeds/{id} [patch]
+func PatchSosmed(c *gin.Context) {
+	patchRecord(c, sosmedPatch)
+}
+
+// @Summary Patch structure
+// @Description Partial update with JSON Merge Patch (RFC 7396). Writable: name, level, parent_id, address, position, active.
+// @Description The parent must belong to the same customer and must not create a cycle.
+// @Tags Structures
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Structure ID"
+// @Param patch body object true "Merge patch"
+// @Success 200 {object} entity.Structure
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 415 {object} dto.ErrorResponse
+// @Router /api/structures/{id} [patch]
+func PatchStructure(c *gin.Context) {
+	patchRecord(c, structurePatch)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"customer-api/internal/entity"
+)
+
+var patchSpecs = []patchSpec{customerPatch, addressPatch, contactPatch, sosmedPatch, structurePatch}
+
+// Kolom yang dikelola server; tidak boleh bisa diubah lewat PATCH entity apa pun
+var protectedPatchFields = []string{"id", "customer_id", "version", "created_at", "updated_at", "deleted_at"}
+
+func TestPatchSpecsDoNotExposeProtectedFields(t *testing.T) {
+	for _, spec := range patchSpecs {
+		for _, name := range protectedPatchFields {
+			if spec.writable[name] {
+				t.Errorf("%s: %s is writable", spec.name, name)
+			}
+		}
+	}
+	// Status dan logo customer punya endpoint sendiri
+	for _, name := range []string{"status", "logo", "logo_small"} {
+		if customerPatch.writable[name] {
+			t.Errorf("Customer: %s is writable", name)
+		}
+	}
+}
+
+func TestApplyMergePatchRejectsProtectedFields(t *testing.T) {
+	patch := map[string]json.RawMessage{
+		"id":          json.RawMessage(`"01HIJACK"`),
+		"customer_id": json.RawMessage(`"01OTHER"`),
+		"version":     json.RawMessage(`99`),
+		"created_at":  json.RawMessage(`"2020-01-01T00:00:00Z"`),
+		"updated_at":  json.RawMessage(`null`),
+		"children":    json.RawMessage(`[]`),
+	}
+	for _, spec := range patchSpecs {
+		t.Run(spec.name, func(t *testing.T) {
+			record := reflect.New(reflect.TypeOf(spec.model).Elem())
+			errs := applyMergePatch(record.Interface(), patch, spec.writable)
+			for name := range patch {
+				if errs[name] != "is not writable" {
+					t.Errorf("%s: error = %q, want \"is not writable\"", name, errs[name])
+				}
+			}
+			if !record.Elem().IsZero() {
+				t.Errorf("record changed: %+v", record.Elem().Interface())
+			}
+		})
+	}
+}
+
+func TestApplyMergePatchAppliesWritableFields(t *testing.T) {
+	customer := entity.Customer{Name: "PT Lama", Website: "https://lama.co.id", Status: "Active"}
+	errs := applyMergePatch(&customer, map[string]json.RawMessage{
+		"phone":   json.RawMessage(`"021-555"`),
+		"rating":  json.RawMessage(`4.5`),
+		"website": json.RawMessage(`null`),
+		"status":  json.RawMessage(`"Inactive"`),
+	}, customerPatch.writable)
+	if len(errs) != 1 || errs["status"] != "is not writable" {
+		t.Errorf("errors = %v, want only status", errs)
+	}
+	want := entity.Customer{Name: "PT Lama", Phone: "021-555", Rating: 4.5, Status: "Active"}
+	if !reflect.DeepEqual(customer, want) {
+		t.Errorf("customer = %+v, want %+v", customer, want)
+	}
+}
+
+func TestApplyMergePatchTypeErrors(t *testing.T) {
+	var sosmed entity.Sosmed
+	errs := applyMergePatch(&sosmed, map[string]json.RawMessage{
+		"followers": json.RawMessage(`"banyak"`),
+		"active":    json.RawMessage(`1`),
+	}, sosmedPatch.writable)
+	want := map[string]string{"followers": "must be an integer", "active": "must be a boolean"}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("errors = %v, want %v", errs, want)
+	}
+}
@@ -0,0 +1,464 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+	"customer-api/internal/query"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errPaymentRejected carries a validation failure out of a payment transaction
+type errPaymentRejected struct {
+	message string
+}
+
+func (e errPaymentRejected) Error() string {
+	return e.message
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func toPaymentResponse(payment entity.Payment) dto.PaymentResponse {
+	response := dto.PaymentResponse{
+		ID:             payment.ID,
+		InvoiceID:      payment.InvoiceID,
+		CustomerID:     payment.CustomerID,
+		Amount:         payment.Amount,
+		AppliedAmount:  payment.AppliedAmount,
+		CreditAmount:   payment.CreditAmount,
+		PaidAt:         payment.PaidAt,
+		Method:         payment.Method,
+		Reference:      payment.Reference,
+		Notes:          payment.Notes,
+		CreatedBy:      payment.CreatedBy,
+		ReversedAt:     payment.ReversedAt,
+		ReversedBy:     payment.ReversedBy,
+		ReversalReason: payment.ReversalReason,
+		CreatedAt:      payment.CreatedAt,
+		UpdatedAt:      payment.UpdatedAt,
+	}
+	if payment.Invoice.ID != "" {
+		invoice := toInvoiceResponse(payment.Invoice)
+		response.Invoice = &invoice
+	}
+	return response
+}
+
+// @Summary Record payment
+// @Description Apply a payment to an invoice. Overpayments are rejected unless allow_credit is set, in which case the excess becomes customer credit
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Param payment body dto.CreatePaymentRequest true "Payment data"
+// @Success 201 {object} dto.PaymentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/invoices/{id}/payments [post]
+func CreateInvoicePayment(c *gin.Context) {
+	invoiceID := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var req dto.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var payment entity.Payment
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// Kunci baris invoice agar dua pembayaran bersamaan tidak melebihi saldo
+		var invoice entity.Invoice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", invoiceID).First(&invoice).Error; err != nil {
+			return err
+		}
+
+		amount := roundMoney(req.Amount)
+		balance := math.Max(invoiceBalance(invoice), 0)
+		applied := math.Min(amount, balance)
+		credit := roundMoney(amount - applied)
+
+		if credit > 0 && !req.AllowCredit {
+			return errPaymentRejected{fmt.Sprintf("Payment exceeds the invoice balance of %.2f", balance)}
+		}
+
+		payment = entity.Payment{
+			InvoiceID:     invoice.ID,
+			CustomerID:    invoice.CustomerID,
+			Amount:        amount,
+			AppliedAmount: applied,
+			CreditAmount:  credit,
+			PaidAt:        req.PaidAt,
+			Method:        req.Method,
+			Reference:     req.Reference,
+			Notes:         req.Notes,
+			CreatedBy:     userID,
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+
+		invoice.PaidAmount = roundMoney(invoice.PaidAmount + applied)
+		if err := tx.Model(&invoice).Update("paid_amount", invoice.PaidAmount).Error; err != nil {
+			return err
+		}
+		payment.Invoice = invoice
+		return nil
+	})
+
+	var rejected errPaymentRejected
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		sendError(c, http.StatusNotFound, "Invoice not found")
+		return
+	case errors.As(err, &rejected):
+		sendError(c, http.StatusBadRequest, rejected.message)
+		return
+	case err != nil:
+		sendError(c, http.StatusInternalServerError, "Failed to record payment: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Payment recorded successfully",
+		"data":    toPaymentResponse(payment),
+	})
+}
+
+// @Summary Get invoice payments
+// @Description Get all payments of an invoice, including reversed ones
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Success 200 {array} dto.PaymentResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/invoices/{id}/payments [get]
+func GetInvoicePayments(c *gin.Context) {
+	invoiceID := c.Param("id")
+
+	var invoice entity.Invoice
+	if err := config.DB.Where("id = ?", invoiceID).First(&invoice).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Invoice not found")
+		return
+	}
+
+	var payments []entity.Payment
+	if err := config.DB.Where("invoice_id = ?", invoice.ID).Order("paid_at, created_at").Find(&payments).Error; err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch payments")
+		return
+	}
+
+	responses := make([]dto.PaymentResponse, 0, len(payments))
+	for _, payment := range payments {
+		responses = append(responses, toPaymentResponse(payment))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Payments fetched successfully",
+		"data": gin.H{
+			"invoice":  toInvoiceResponse(invoice),
+			"payments": responses,
+		},
+	})
+}
+
+var paymentListSpec = query.Spec{
+	Table: "payments",
+	Fields: map[string]query.Field{
+		"customer_id":    {},
+		"invoice_id":     {},
+		"method":         {},
+		"reference":      {},
+		"created_by":     {},
+		"amount":         {Type: query.Number},
+		"applied_amount": {Type: query.Number},
+		"paid_at":        {Type: query.Time},
+		"created_at":     {Type: query.Time},
+	},
+	DefaultSort: "-paid_at",
+}
+
+// @Summary Get payments
+// @Description Get payments filtered by customer, invoice and paid date range
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param customer_id query string false "Customer ID"
+// @Param invoice_id query string false "Invoice ID"
+// @Param paid_from query string false "Paid date from (YYYY-MM-DD)"
+// @Param paid_to query string false "Paid date to (YYYY-MM-DD)"
+// @Param include_reversed query bool false "Include reversed payments (default true)"
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 20, max 200)"
+// @Param sort query string false "Sort fields, prefix - for descending, e.g. -paid_at"
+// @Param cursor query string false "Cursor pagination on id"
+// @Success 200 {array} dto.PaymentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /api/payments [get]
+func GetPayments(c *gin.Context) {
+	params, ok := parseList(c, paymentListSpec)
+	if !ok {
+		return
+	}
+
+	db := config.DB.WithContext(c)
+	if from := c.Query("paid_from"); from != "" {
+		date, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "Invalid paid_from format, use YYYY-MM-DD")
+			return
+		}
+		db = db.Where("paid_at >= ?", date)
+	}
+	if to := c.Query("paid_to"); to != "" {
+		date, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "Invalid paid_to format, use YYYY-MM-DD")
+			return
+		}
+		db = db.Where("paid_at < ?", date.AddDate(0, 0, 1))
+	}
+	if c.Query("include_reversed") == "false" {
+		db = db.Where("reversed_at IS NULL")
+	}
+
+	var payments []entity.Payment
+	page, err := paymentListSpec.Find(db, params, &payments)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch payments")
+		return
+	}
+
+	responses := make([]dto.PaymentResponse, 0, len(payments))
+	for _, payment := range payments {
+		responses = append(responses, toPaymentResponse(payment))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"message":    "Payments fetched successfully",
+		"data":       responses,
+		"pagination": page,
+	})
+}
+
+// @Summary Get payment by ID
+// @Description Get a specific payment with its invoice
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Payment ID"
+// @Success 200 {object} dto.PaymentResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/payments/{id} [get]
+func GetPayment(c *gin.Context) {
+	id := c.Param("id")
+
+	var payment entity.Payment
+	if err := config.DB.Preload("Invoice").Where("id = ?", id).First(&payment).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Payment not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Payment fetched successfully",
+		"data":    toPaymentResponse(payment),
+	})
+}
+
+// @Summary Reverse payment
+// @Description Reverse a payment: its applied amount is taken off the invoice and its credit is cancelled
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Payment ID"
+// @Param reversal body dto.ReversePaymentRequest true "Reversal reason"
+// @Success 200 {object} dto.PaymentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/payments/{id}/reverse [post]
+func ReversePayment(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("user_id")
+
+	var req dto.ReversePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var payment entity.Payment
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&payment).Error; err != nil {
+			return err
+		}
+		if payment.ReversedAt != nil {
+			return errPaymentRejected{"Payment has already been reversed"}
+		}
+
+		var invoice entity.Invoice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", payment.InvoiceID).First(&invoice).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		payment.ReversedAt = &now
+		payment.ReversedBy = userID
+		payment.ReversalReason = req.Reason
+		if err := tx.Model(&payment).Updates(map[string]interface{}{
+			"reversed_at":     payment.ReversedAt,
+			"reversed_by":     payment.ReversedBy,
+			"reversal_reason": payment.ReversalReason,
+		}).Error; err != nil {
+			return err
+		}
+
+		invoice.PaidAmount = roundMoney(math.Max(invoice.PaidAmount-payment.AppliedAmount, 0))
+		if err := tx.Model(&invoice).Update("paid_amount", invoice.PaidAmount).Error; err != nil {
+			return err
+		}
+		payment.Invoice = invoice
+		return nil
+	})
+
+	var rejected errPaymentRejected
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		sendError(c, http.StatusNotFound, "Payment not found")
+		return
+	case errors.As(err, &rejected):
+		sendError(c, http.StatusBadRequest, rejected.message)
+		return
+	case err != nil:
+		sendError(c, http.StatusInternalServerError, "Failed to reverse payment: "+err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Payment reversed successfully",
+		"data":    toPaymentResponse(payment),
+	})
+}
+
+// @Summary Get customer ledger
+// @Description Chronological ledger of invoices, payments and reversals for a customer with running balance
+// @Tags Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Customer ID"
+// @Success 200 {object} dto.LedgerResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/customers/{id}/ledger [get]
+func GetCustomerLedger(c *gin.Context) {
+	customerID := c.Param("id")
+
+	var customer entity.Customer
+	if err := config.DB.Where("id = ?", customerID).First(&customer).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Customer not found")
+		return
+	}
+
+	var invoices []entity.Invoice
+	if err := config.DB.Where("customer_id = ?", customer.ID).Find(&invoices).Error; err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch invoices")
+		return
+	}
+
+	var payments []entity.Payment
+	if err := config.DB.Where("customer_id = ?", customer.ID).Find(&payments).Error; err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch payments")
+		return
+	}
+
+	ledger := dto.LedgerResponse{CustomerID: customer.ID, Entries: []dto.LedgerEntry{}}
+	invoiceNumbers := make(map[string]string)
+	for _, invoice := range invoices {
+		invoiceNumbers[invoice.ID] = invoice.InvoiceNumber
+		ledger.TotalInvoiced += invoice.Amount
+		ledger.OutstandingTotal += math.Max(invoiceBalance(invoice), 0)
+		ledger.Entries = append(ledger.Entries, dto.LedgerEntry{
+			Date:        invoice.IssuedDate,
+			Type:        "Invoice",
+			Reference:   invoice.InvoiceNumber,
+			InvoiceID:   invoice.ID,
+			Description: "Invoice " + invoice.InvoiceNumber,
+			Debit:       invoice.Amount,
+		})
+	}
+
+	for _, payment := range payments {
+		ledger.Entries = append(ledger.Entries, dto.LedgerEntry{
+			Date:        payment.PaidAt,
+			Type:        "Payment",
+			Reference:   payment.Reference,
+			InvoiceID:   payment.InvoiceID,
+			PaymentID:   payment.ID,
+			Description: "Payment for " + invoiceNumbers[payment.InvoiceID],
+			Credit:      payment.Amount,
+		})
+		if payment.ReversedAt != nil {
+			ledger.Entries = append(ledger.Entries, dto.LedgerEntry{
+				Date:        *payment.ReversedAt,
+				Type:        "Reversal",
+				Reference:   payment.Reference,
+				InvoiceID:   payment.InvoiceID,
+				PaymentID:   payment.ID,
+				Description: "Reversal: " + payment.ReversalReason,
+				Debit:       payment.Amount,
+			})
+			continue
+		}
+		ledger.TotalPaid += payment.Amount
+		ledger.CreditBalance += payment.CreditAmount
+	}
+
+	sort.SliceStable(ledger.Entries, func(i, j int) bool {
+		return ledger.Entries[i].Date.Before(ledger.Entries[j].Date)
+	})
+
+	var balance float64
+	for i := range ledger.Entries {
+		balance = roundMoney(balance + ledger.Entries[i].Debit - ledger.Entries[i].Credit)
+		ledger.Entries[i].Balance = balance
+	}
+	ledger.TotalInvoiced = roundMoney(ledger.TotalInvoiced)
+	ledger.TotalPaid = roundMoney(ledger.TotalPaid)
+	ledger.CreditBalance = roundMoney(ledger.CreditBalance)
+	ledger.OutstandingTotal = roundMoney(ledger.OutstandingTotal)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Customer ledger fetched successfully",
+		"data":    ledger,
+	})
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"net/http"
+
+	"customer-api/internal/config"
+	"customer-api/internal/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RolePermissionsInput struct {
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+type UserRoleInput struct {
+	RoleID string `json:"role_id" binding:"required"`
+}
+
+// GetPermissions returns every permission known to the API
+func GetPermissions(c *gin.Context) {
+	var permissions []entity.Permission
+	if result := config.DB.Order("name").Find(&permissions); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "failed",
+			"message": "Gagal mengambil data permissions",
+			"data":    result.Error.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Permissions fetched successfully",
+		"data":    permissions,
+	})
+}
+
+// GetRolePermissions returns the permissions assigned to a role
+func GetRolePermissions(c *gin.Context) {
+	id := c.Param("id")
+
+	var role entity.Role
+	if result := config.DB.Preload("Permissions").Where("id = ?", id).First(&role); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "failed",
+			"message": "Role tidak ditemukan",
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Role permissions fetched successfully",
+		"data":    role.Permissions,
+	})
+}
+
+// UpdateRolePermissions replaces the permission set of a role
+func UpdateRolePermissions(c *gin.Context) {
+	id := c.Param("id")
+
+	var role entity.Role
+	if result := config.DB.Where("id = ?", id).First(&role); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "failed",
+			"message": "Role tidak ditemukan",
+			"data":    nil,
+		})
+		return
+	}
+
+	var input RolePermissionsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "failed",
+			"message": "Invalid input",
+			"data":    err.Error(),
+		})
+		return
+	}
+
+	var permissions []entity.Permission
+	if len(input.Permissions) > 0 {
+		if err := config.DB.Where("name IN ?", input.Permissions).Find(&permissions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "failed",
+				"message": "Gagal mengambil permissions",
+				"data":    err.Error(),
+			})
+			return
+		}
+	}
+
+	// Tolak permission yang tidak dikenal agar typo tidak diam-diam diabaikan
+	if len(permissions) != len(uniqueStrings(input.Permissions)) {
+		known := make(map[string]bool)
+		for _, p := range permissions {
+			known[p.Name] = true
+		}
+		var unknown []string
+		for _, name := range input.Permissions {
+			if !known[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "failed",
+			"message": "Permission tidak dikenal",
+			"data":    unknown,
+		})
+		return
+	}
+
+	if err := config.DB.WithContext(c).Model(&role).Association("Permissions").Replace(permissions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "failed",
+			"message": "Gagal memperbarui permissions role",
+			"data":    err.Error(),
+		})
+		return
+	}
+
+	role.Permissions = permissions
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Role permissions berhasil diperbarui",
+		"data":    role,
+	})
+}
+
+// UpdateUserRole assigns a role to a user
+func UpdateUserRole(c *gin.Context) {
+	id := c.Param("id")
+
+	var user entity.User
+	if result := config.DB.Where("id = ?", id).First(&user); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "failed",
+			"message": "User tidak ditemukan",
+			"data":    nil,
+		})
+		return
+	}
+
+	var input UserRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "failed",
+			"message": "Invalid input",
+			"data":    err.Error(),
+		})
+		return
+	}
+
+	var role entity.Role
+	if result := config.DB.Where("id = ?", input.RoleID).First(&role); result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "failed",
+			"message": "Role ID tidak valid",
+			"data":    nil,
+		})
+		return
+	}
+
+	if result := config.DB.WithContext(c).Model(&user).Update("role_id", role.ID); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "failed",
+			"message": "Gagal memperbarui role user",
+			"data":    result.Error.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Role user berhasil diperbarui",
+		"data":    user,
+	})
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
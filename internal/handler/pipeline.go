@@ -0,0 +1,530 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+	"customer-api/internal/query"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Pipeline: item (customer atau opportunity) ditempatkan di sebuah workflow
+// dan berjalan melewati step stage-nya (stages_details aktif, urut
+// flow_order). Setiap kunjungan step dicatat di pipeline_steps dengan waktu
+// masuk dan keluar.
+
+const defaultBoardLimit = 50
+
+var (
+	errPipelineCompleted = errors.New("pipeline item is already completed")
+	errPipelineFirstStep = errors.New("pipeline item is already at the first step")
+	errPipelineStepGone  = errors.New("current step is no longer part of the workflow")
+)
+
+var pipelineListSpec = query.Spec{
+	Table: "pipeline_items",
+	Fields: map[string]query.Field{
+		"workflow_id":     {},
+		"stage_id":        {},
+		"customer_id":     {},
+		"current_step_id": {},
+		"status":          {},
+		"type":            {},
+		"owner_id":        {},
+		"title":           {},
+		"value":           {Type: query.Number},
+		"step_entered_at": {Type: query.Time},
+		"created_at":      {Type: query.Time},
+	},
+	DefaultSort: "-created_at",
+}
+
+// pipelineSteps returns the active steps of a stage in flow order
+func pipelineSteps(db *gorm.DB, stageID string) ([]entity.StagesDetail, error) {
+	var steps []entity.StagesDetail
+	err := db.Where("stage_id = ? AND is_active = ?", stageID, true).Order("flow_order, created_at").Find(&steps).Error
+	return steps, err
+}
+
+func stepIndex(steps []entity.StagesDetail, id string) int {
+	for i, step := range steps {
+		if step.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// enterPipelineStep closes the open step visit of item and moves it to step,
+// or completes the item when step is nil. The due time of the new step comes
+// from its SLA on the business calendar.
+func enterPipelineStep(tx *gorm.DB, item *entity.PipelineItem, step *entity.StagesDetail, action, userID, notes string) error {
+	now := time.Now()
+	err := tx.Model(&entity.PipelineStep{}).Where("pipeline_item_id = ? AND exited_at IS NULL", item.ID).
+		Updates(map[string]interface{}{"exited_at": now, "exited_by": userID}).Error
+	if err != nil {
+		return err
+	}
+
+	if step == nil {
+		item.Status = entity.PipelineCompleted
+		item.CompletedAt = &now
+		item.StepDueAt = nil
+		return tx.Omit(clause.Associations).Save(item).Error
+	}
+
+	due := slaCalendar(tx).Due(now, step.Sla, step.Uom)
+	item.CurrentStepID = step.ID
+	item.StepEnteredAt = now
+	item.StepDueAt = due
+	item.Status = entity.PipelineOpen
+	item.CompletedAt = nil
+	if err := tx.Omit(clause.Associations).Save(item).Error; err != nil {
+		return err
+	}
+	visit := entity.PipelineStep{
+		PipelineItemID: item.ID,
+		StepID:         step.ID,
+		StepName:       step.Name,
+		FlowOrder:      step.FlowOrder,
+		Action:         action,
+		EnteredAt:      now,
+		EnteredBy:      userID,
+		DueAt:          due,
+		Notes:          notes,
+	}
+	return tx.Create(&visit).Error
+}
+
+// @Summary Add to pipeline
+// @Description Place a customer or opportunity on the first step of a workflow (or on step_id). Without
+// @Description workflow_id the workflow is resolved from workflow_type and value (see POST /api/workflows/resolve).
+// @Tags Pipeline
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreatePipelineItemRequest true "Pipeline item"
+// @Success 201 {object} entity.PipelineItem
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /api/pipeline [post]
+func CreatePipelineItem(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req dto.CreatePipelineItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	itemType := strings.ToLower(strings.TrimSpace(req.Type))
+	switch itemType {
+	case "":
+		itemType = entity.PipelineTypeCustomer
+	case entity.PipelineTypeCustomer:
+	case entity.PipelineTypeOpportunity:
+		if strings.TrimSpace(req.Title) == "" {
+			sendError(c, http.StatusBadRequest, "Title is required for an opportunity")
+			return
+		}
+	default:
+		sendError(c, http.StatusBadRequest, "Invalid type, use customer or opportunity")
+		return
+	}
+
+	var workflow entity.Workflows
+	switch {
+	case req.WorkflowID != "":
+		if err := config.DB.Where("id = ? AND is_active = ?", req.WorkflowID, true).First(&workflow).Error; err != nil {
+			sendError(c, http.StatusNotFound, "Workflow not found")
+			return
+		}
+	case req.WorkflowType != "":
+		resolved, _, err := resolveWorkflow(config.DB, req.WorkflowType, "", req.Value)
+		if errors.Is(err, errNoWorkflowMatch) {
+			sendError(c, http.StatusNotFound, "No active "+req.WorkflowType+" workflow covers the value")
+			return
+		}
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, "Failed to resolve workflow")
+			return
+		}
+		workflow = *resolved
+	default:
+		sendError(c, http.StatusBadRequest, "workflow_id or workflow_type is required")
+		return
+	}
+	var customer entity.Customer
+	if err := config.DB.Where("id = ?", req.CustomerID).First(&customer).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Customer not found")
+		return
+	}
+
+	steps, err := pipelineSteps(config.DB, workflow.StageID)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch workflow steps")
+		return
+	}
+	if len(steps) == 0 {
+		sendError(c, http.StatusBadRequest, "Stage of the workflow has no active steps")
+		return
+	}
+	start := &steps[0]
+	if req.StepID != "" {
+		i := stepIndex(steps, req.StepID)
+		if i < 0 {
+			sendError(c, http.StatusBadRequest, "step_id is not an active step of the workflow")
+			return
+		}
+		start = &steps[i]
+	}
+
+	// Customer hanya boleh sekali berjalan di workflow yang sama, opportunity bebas
+	if itemType == entity.PipelineTypeCustomer {
+		var open int64
+		config.DB.Model(&entity.PipelineItem{}).
+			Where("workflow_id = ? AND customer_id = ? AND type = ? AND status = ?", workflow.ID, customer.ID, itemType, entity.PipelineOpen).
+			Count(&open)
+		if open > 0 {
+			sendError(c, http.StatusConflict, "Customer is already in this workflow")
+			return
+		}
+	}
+
+	item := entity.PipelineItem{
+		WorkflowID: workflow.ID,
+		StageID:    workflow.StageID,
+		CustomerID: customer.ID,
+		Type:       itemType,
+		Title:      strings.TrimSpace(req.Title),
+		Value:      req.Value,
+		Status:     entity.PipelineOpen,
+		OwnerID:    userID,
+	}
+	err = config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		item.CurrentStepID = start.ID
+		item.StepEnteredAt = time.Now()
+		if err := tx.Omit(clause.Associations).Create(&item).Error; err != nil {
+			return err
+		}
+		return enterPipelineStep(tx, &item, start, "start", userID, "")
+	})
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to add to pipeline")
+		return
+	}
+
+	item.CurrentStep = start
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Pipeline item created successfully",
+		"data":    item,
+	})
+}
+
+// @Summary List pipeline items
+// @Tags Pipeline
+// @Produce json
+// @Security BearerAuth
+// @Param workflow_id query string false "Filter by workflow"
+// @Param customer_id query string false "Filter by customer"
+// @Param current_step_id query string false "Filter by current step"
+// @Param status query string false "Filter by status" Enums(open, completed)
+// @Param type query string false "Filter by type" Enums(customer, opportunity)
+// @Param value[gte] query number false "Minimum value"
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 20, max 200)"
+// @Param sort query string false "Sort, e.g. -value"
+// @Success 200 {array} entity.PipelineItem
+// @Failure 400 {object} dto.ErrorResponse
+// @Router /api/pipeline [get]
+func GetPipelineItems(c *gin.Context) {
+	params, ok := parseList(c, pipelineListSpec)
+	if !ok {
+		return
+	}
+	var items []entity.PipelineItem
+	page, err := pipelineListSpec.Find(config.DB, params, &items, func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Customer").Preload("CurrentStep")
+	})
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch pipeline items")
+		return
+	}
+	setSLAStates(slaCalendar(config.DB), items, time.Now())
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"message":    "Pipeline items fetched successfully",
+		"data":       items,
+		"pagination": page,
+	})
+}
+
+// @Summary Get pipeline item
+// @Description Pipeline item with every step visit (entered/exited timestamps), oldest first
+// @Tags Pipeline
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Pipeline item ID"
+// @Success 200 {object} entity.PipelineItem
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/pipeline/{id} [get]
+func GetPipelineItem(c *gin.Context) {
+	var item entity.PipelineItem
+	err := config.DB.Preload("Customer").Preload("Workflow").Preload("CurrentStep").
+		Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("entered_at") }).
+		Where("id = ?", c.Param("id")).First(&item).Error
+	if err != nil {
+		sendError(c, http.StatusNotFound, "Pipeline item not found")
+		return
+	}
+	items := []entity.PipelineItem{item}
+	setSLAStates(slaCalendar(config.DB), items, time.Now())
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Pipeline item fetched successfully",
+		"data":    items[0],
+	})
+}
+
+// movePipelineItem advances (forward) or reverts an item by one step.
+// Advancing past the last step completes the item, reverting a completed
+// item reopens it on the last step.
+func movePipelineItem(c *gin.Context, forward bool) {
+	userID := c.GetString("user_id")
+	var req dto.PipelineMoveRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			sendError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	var item entity.PipelineItem
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", c.Param("id")).First(&item).Error; err != nil {
+			return err
+		}
+		steps, err := pipelineSteps(tx, item.StageID)
+		if err != nil {
+			return err
+		}
+		i := stepIndex(steps, item.CurrentStepID)
+		if i < 0 {
+			return errPipelineStepGone
+		}
+
+		completed := item.Status == entity.PipelineCompleted
+		switch {
+		case forward && completed:
+			return errPipelineCompleted
+		case forward && i == len(steps)-1:
+			return enterPipelineStep(tx, &item, nil, "advance", userID, req.Notes)
+		case forward:
+			return enterPipelineStep(tx, &item, &steps[i+1], "advance", userID, req.Notes)
+		case completed:
+			return enterPipelineStep(tx, &item, &steps[i], "revert", userID, req.Notes)
+		case i == 0:
+			return errPipelineFirstStep
+		default:
+			return enterPipelineStep(tx, &item, &steps[i-1], "revert", userID, req.Notes)
+		}
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		sendError(c, http.StatusNotFound, "Pipeline item not found")
+		return
+	case errors.Is(err, errPipelineCompleted), errors.Is(err, errPipelineFirstStep):
+		sendError(c, http.StatusConflict, "Pipeline item cannot move: "+err.Error())
+		return
+	case errors.Is(err, errPipelineStepGone):
+		sendError(c, http.StatusConflict, "Current step was removed or deactivated from the workflow stage")
+		return
+	case err != nil:
+		sendError(c, http.StatusInternalServerError, "Failed to move pipeline item")
+		return
+	}
+
+	config.DB.Where("id = ?", item.CurrentStepID).First(&item.CurrentStep)
+	message := "Pipeline item moved to " + item.CurrentStep.Name
+	if item.Status == entity.PipelineCompleted {
+		message = "Pipeline item completed"
+	}
+	items := []entity.PipelineItem{item}
+	setSLAStates(slaCalendar(config.DB), items, time.Now())
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": message,
+		"data":    items[0],
+	})
+}
+
+// @Summary Advance pipeline item
+// @Description Move the item to the next step; advancing from the last step completes it
+// @Tags Pipeline
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Pipeline item ID"
+// @Param request body dto.PipelineMoveRequest false "Optional notes"
+// @Success 200 {object} entity.PipelineItem
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /api/pipeline/{id}/advance [post]
+func AdvancePipelineItem(c *gin.Context) {
+	movePipelineItem(c, true)
+}
+
+// @Summary Revert pipeline item
+// @Description Move the item back to the previous step; a completed item is reopened on the last step
+// @Tags Pipeline
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Pipeline item ID"
+// @Param request body dto.PipelineMoveRequest false "Optional notes"
+// @Success 200 {object} entity.PipelineItem
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /api/pipeline/{id}/revert [post]
+func RevertPipelineItem(c *gin.Context) {
+	movePipelineItem(c, false)
+}
+
+// @Summary Delete pipeline item
+// @Tags Pipeline
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Pipeline item ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/pipeline/{id} [delete]
+func DeletePipelineItem(c *gin.Context) {
+	result := config.DB.WithContext(c).Where("id = ?", c.Param("id")).Delete(&entity.PipelineItem{})
+	if result.Error != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to delete pipeline item")
+		return
+	}
+	if result.RowsAffected == 0 {
+		sendError(c, http.StatusNotFound, "Pipeline item not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Pipeline item deleted successfully",
+		"data":    nil,
+	})
+}
+
+// @Summary Pipeline board
+// @Description Kanban board of a workflow: one column per step with the open items, longest in the step first
+// @Tags Pipeline
+// @Produce json
+// @Security BearerAuth
+// @Param workflow_id query string true "Workflow ID"
+// @Param type query string false "Filter by type" Enums(customer, opportunity)
+// @Param owner_id query string false "Filter by owner"
+// @Param limit query int false "Items per column (default 50); count and total_value always cover all items"
+// @Success 200 {object} dto.PipelineBoard
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/pipeline/board [get]
+func GetPipelineBoard(c *gin.Context) {
+	workflowID := c.Query("workflow_id")
+	if workflowID == "" {
+		sendError(c, http.StatusBadRequest, "workflow_id is required")
+		return
+	}
+	limit := defaultBoardLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			sendError(c, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	var workflow entity.Workflows
+	if err := config.DB.Where("id = ?", workflowID).First(&workflow).Error; err != nil {
+		sendError(c, http.StatusNotFound, "Workflow not found")
+		return
+	}
+	steps, err := pipelineSteps(config.DB, workflow.StageID)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch workflow steps")
+		return
+	}
+
+	filter := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("workflow_id = ?", workflow.ID)
+		if v := c.Query("type"); v != "" {
+			db = db.Where("type = ?", v)
+		}
+		if v := c.Query("owner_id"); v != "" {
+			db = db.Where("owner_id = ?", v)
+		}
+		return db
+	}
+
+	var items []entity.PipelineItem
+	err = config.DB.Scopes(filter).Where("status = ?", entity.PipelineOpen).
+		Preload("Customer", func(db *gorm.DB) *gorm.DB { return db.Unscoped().Select("id", "name") }).
+		Order("step_entered_at").Find(&items).Error
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch pipeline items")
+		return
+	}
+
+	setSLAStates(slaCalendar(config.DB), items, time.Now())
+
+	board := dto.PipelineBoard{WorkflowID: workflow.ID, Workflow: workflow.Name, Columns: make([]dto.PipelineBoardColumn, len(steps))}
+	columns := make(map[string]*dto.PipelineBoardColumn, len(steps))
+	for i, step := range steps {
+		board.Columns[i] = dto.PipelineBoardColumn{StepID: step.ID, Name: step.Name, FlowOrder: step.FlowOrder, Items: []dto.PipelineBoardItem{}}
+		columns[step.ID] = &board.Columns[i]
+	}
+	for _, item := range items {
+		column, ok := columns[item.CurrentStepID]
+		if !ok {
+			// Step sudah dinonaktifkan; item tetap terlihat lewat GET /pipeline
+			continue
+		}
+		column.Count++
+		column.TotalValue += item.Value
+		if len(column.Items) >= limit {
+			continue
+		}
+		card := dto.PipelineBoardItem{
+			ID:            item.ID,
+			CustomerID:    item.CustomerID,
+			Type:          item.Type,
+			Title:         item.Title,
+			Value:         item.Value,
+			OwnerID:       item.OwnerID,
+			StepEnteredAt: item.StepEnteredAt,
+			DueAt:         item.StepDueAt,
+			SLAState:      item.SLAState,
+		}
+		if item.Customer != nil {
+			card.CustomerName = item.Customer.Name
+		}
+		column.Items = append(column.Items, card)
+	}
+	config.DB.Model(&entity.PipelineItem{}).Scopes(filter).Where("status = ?", entity.PipelineCompleted).Count(&board.Completed)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Pipeline board fetched successfully",
+		"data":    board,
+	})
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// agingInvoice is an invoice with its balance as of the report date
+type agingInvoice struct {
+	ID         string
+	CustomerID string
+	Amount     float64
+	DueDate    time.Time
+	Paid       float64
+}
+
+// addToBucket puts an outstanding amount into the bucket matching its days past due
+func addToBucket(b *dto.AgingBuckets, daysPastDue int, amount float64) {
+	switch {
+	case daysPastDue <= 0:
+		b.Current = roundMoney(b.Current + amount)
+	case daysPastDue <= 30:
+		b.Days1To30 = roundMoney(b.Days1To30 + amount)
+	case daysPastDue <= 60:
+		b.Days31To60 = roundMoney(b.Days31To60 + amount)
+	case daysPastDue <= 90:
+		b.Days61To90 = roundMoney(b.Days61To90 + amount)
+	default:
+		b.Days90Plus = roundMoney(b.Days90Plus + amount)
+	}
+	b.Total = roundMoney(b.Total + amount)
+}
+
+// buildAgingReport computes AR aging as of the given day, grouped by
+// "customer" or "account_manager". Payments made or reversed after asOf are ignored.
+func buildAgingReport(asOf time.Time, groupBy string) (dto.AgingReportResponse, error) {
+	report := dto.AgingReportResponse{AsOf: asOf.Format("2006-01-02"), GroupBy: groupBy, Rows: []dto.AgingRow{}}
+	endOfDay := asOf.AddDate(0, 0, 1)
+
+	var invoices []agingInvoice
+	err := config.DB.Raw(`SELECT invoices.id, invoices.customer_id, invoices.amount, invoices.due_date,
+			COALESCE((SELECT SUM(p.applied_amount) FROM payments p
+				WHERE p.invoice_id = invoices.id AND p.deleted_at IS NULL AND p.paid_at < ?
+				AND (p.reversed_at IS NULL OR p.reversed_at >= ?)), 0) AS paid
+		FROM invoices
+		WHERE invoices.deleted_at IS NULL AND invoices.issued_date < ?`, endOfDay, endOfDay, endOfDay).
+		Scan(&invoices).Error
+	if err != nil {
+		return report, err
+	}
+
+	customerIDs := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, inv := range invoices {
+		if !seen[inv.CustomerID] {
+			seen[inv.CustomerID] = true
+			customerIDs = append(customerIDs, inv.CustomerID)
+		}
+	}
+
+	customers := make(map[string]entity.Customer)
+	if len(customerIDs) > 0 {
+		var list []entity.Customer
+		if err := config.DB.Where("id IN ?", customerIDs).Find(&list).Error; err != nil {
+			return report, err
+		}
+		for _, cust := range list {
+			customers[cust.ID] = cust
+		}
+	}
+
+	rows := make(map[string]*dto.AgingRow)
+	for _, inv := range invoices {
+		balance := roundMoney(inv.Amount - inv.Paid)
+		if balance <= 0 {
+			continue
+		}
+		cust := customers[inv.CustomerID]
+
+		key := inv.CustomerID
+		if groupBy == "account_manager" {
+			key = cust.AccountManagerId
+		}
+		row, ok := rows[key]
+		if !ok {
+			row = &dto.AgingRow{Key: key}
+			if groupBy == "customer" {
+				row.Name = cust.Name
+				row.Code = cust.Code
+				row.AccountManagerID = cust.AccountManagerId
+			}
+			rows[key] = row
+		}
+
+		daysPastDue := int(asOf.Sub(truncateToDay(inv.DueDate)).Hours() / 24)
+		row.InvoiceCount++
+		addToBucket(&row.AgingBuckets, daysPastDue, balance)
+		addToBucket(&report.Totals, daysPastDue, balance)
+	}
+
+	// Nama account manager diambil dari tabel users jika ID-nya cocok
+	if groupBy == "account_manager" {
+		var managerIDs []string
+		for key := range rows {
+			if key != "" {
+				managerIDs = append(managerIDs, key)
+			}
+		}
+		if len(managerIDs) > 0 {
+			var users []entity.User
+			config.DB.Where("id IN ?", managerIDs).Find(&users)
+			for _, u := range users {
+				rows[u.ID].Name = u.Username
+			}
+		}
+		if row, ok := rows[""]; ok {
+			row.Name = "Unassigned"
+		}
+	}
+
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	// Saldo terbesar di atas
+	sort.Slice(report.Rows, func(i, j int) bool {
+		return report.Rows[i].Total > report.Rows[j].Total
+	})
+	return report, nil
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// @Summary Accounts-receivable aging report
+// @Description Outstanding invoice balances bucketed by days past due (current, 1-30, 31-60, 61-90, 90+), grouped by customer or account manager
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param group_by query string false "Grouping" Enums(customer, account_manager)
+// @Param as_of query string false "Report date (YYYY-MM-DD), default today"
+// @Param format query string false "Output format" Enums(json, excel, pdf)
+// @Success 200 {object} dto.AgingReportResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/reports/ar-aging [get]
+func GetARAgingReport(c *gin.Context) {
+	groupBy := c.DefaultQuery("group_by", "customer")
+	if groupBy != "customer" && groupBy != "account_manager" {
+		sendError(c, http.StatusBadRequest, "Invalid group_by (must be 'customer' or 'account_manager')")
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "excel" && format != "pdf" {
+		sendError(c, http.StatusBadRequest, "Invalid format (must be 'json', 'excel' or 'pdf')")
+		return
+	}
+
+	asOf := truncateToDay(time.Now())
+	if value := c.Query("as_of"); value != "" {
+		date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "Invalid as_of format, use YYYY-MM-DD")
+			return
+		}
+		asOf = date
+	}
+
+	report, err := buildAgingReport(asOf, groupBy)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to build aging report: "+err.Error())
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "AR aging report fetched successfully",
+			"data":    report,
+		})
+		return
+	}
+
+	label := "Customer"
+	if groupBy == "account_manager" {
+		label = "Account Manager"
+	}
+	headers := []string{label, "Invoices", "Current", "1-30", "31-60", "61-90", "90+", "Total"}
+	rows := make([][]interface{}, 0, len(report.Rows)+1)
+	for _, row := range report.Rows {
+		name := row.Name
+		if name == "" {
+			name = row.Key
+		}
+		rows = append(rows, []interface{}{name, row.InvoiceCount, row.Current, row.Days1To30, row.Days31To60, row.Days61To90, row.Days90Plus, row.Total})
+	}
+	t := report.Totals
+	rows = append(rows, []interface{}{"TOTAL", "", t.Current, t.Days1To30, t.Days31To60, t.Days61To90, t.Days90Plus, t.Total})
+
+	filename := "ar-aging-" + report.AsOf
+	switch format {
+	case "excel":
+		file, err := createExcelTable("AR Aging", headers, rows)
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, "Failed to create excel file: "+err.Error())
+			return
+		}
+		sendFile(c, file, filename+".xlsx", mimeExcel)
+
+	case "pdf":
+		file, err := createPDFTable("AR Aging as of "+report.AsOf, "L", headers, rows)
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, "Failed to create pdf file: "+err.Error())
+			return
+		}
+		sendFile(c, file, filename+".pdf", mimePDF)
+	}
+}
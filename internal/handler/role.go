@@ -6,6 +6,7 @@ import (
 
 	"customer-api/internal/config"
 	"customer-api/internal/entity"
+	"customer-api/internal/query"
 	"github.com/gin-gonic/gin"
 )
 
@@ -32,7 +33,7 @@ func CreateRole(c *gin.Context) {
 		RoleName: input.RoleName,
 	}
 
-	if result := config.DB.Create(&role); result.Error != nil {
+	if result := config.DB.WithContext(c).Create(&role); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat role"})
 		return
 	}
@@ -43,23 +44,44 @@ func CreateRole(c *gin.Context) {
 	})
 }
 
+var roleListSpec = query.Spec{
+	Table: "roles",
+	Fields: map[string]query.Field{
+		"role_name":  {},
+		"created_at": {Type: query.Time},
+	},
+	DefaultSort:     "role_name",
+	DefaultPageSize: 100,
+}
+
 // @Summary Get all roles
-// @Description Get list of all roles
+// @Description Get list of roles; total count is returned in the X-Total-Count header
 // @Tags Roles
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 100, max 200)"
+// @Param sort query string false "Sort fields, prefix - for descending"
 // @Success 200 {array} entity.Role
+// @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/roles [get]
 func GetRoles(c *gin.Context) {
+	params, ok := parseList(c, roleListSpec)
+	if !ok {
+		return
+	}
+
 	var roles []entity.Role
-	if result := config.DB.Find(&roles); result.Error != nil {
+	page, err := roleListSpec.Find(config.DB, params, &roles)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data roles"})
 		return
 	}
 
+	setPaginationHeaders(c, page)
 	c.JSON(http.StatusOK, roles)
 }
 
@@ -112,7 +134,7 @@ func UpdateRole(c *gin.Context) {
 	// Update role
 	role.RoleName = input.RoleName
 
-	if result := config.DB.Save(&role); result.Error != nil {
+	if result := config.DB.WithContext(c).Save(&role); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengupdate role"})
 		return
 	}
@@ -148,7 +170,7 @@ func DeleteRole(c *gin.Context) {
 		return
 	}
 
-	if result := config.DB.Delete(&role); result.Error != nil {
+	if result := config.DB.WithContext(c).Delete(&role); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus role"})
 		return
 	}
@@ -167,7 +189,7 @@ func SetupDefaultRoles(c *gin.Context) {
 	for _, role := range defaultRoles {
 		var existingRole entity.Role
 		if result := config.DB.First(&existingRole, role.ID); result.Error != nil {
-			config.DB.Create(&role)
+			config.DB.WithContext(c).Create(&role)
 		}
 	}
 
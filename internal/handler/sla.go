@@ -0,0 +1,416 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+	"customer-api/internal/sla"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SLANotifier receives a notification for every step that passes its due
+// time. main replaces it with sla.NotifierFromEnv().
+var SLANotifier sla.Notifier = sla.LogNotifier{}
+
+// Monitor SLA memeriksa breach setiap menit
+const slaMonitorInterval = time.Minute
+
+var (
+	slaBaseOnce     sync.Once
+	slaBaseCalendar sla.Calendar
+)
+
+// slaCalendar returns the configured business calendar with the holidays
+// stored in the database
+func slaCalendar(db *gorm.DB) sla.Calendar {
+	slaBaseOnce.Do(func() {
+		var err error
+		if slaBaseCalendar, err = sla.CalendarFromEnv(); err != nil {
+			log.Printf("sla: %v", err)
+		}
+	})
+	cal := slaBaseCalendar
+	cal.Holidays = make(map[string]bool)
+	var holidays []entity.Holiday
+	db.Where("date >= ?", time.Now().AddDate(-1, 0, 0)).Find(&holidays)
+	for _, holiday := range holidays {
+		cal.Holidays[holiday.Date.Format("2006-01-02")] = true
+	}
+	return cal
+}
+
+// setSLAStates fills SLAState of open items and of their loaded steps
+func setSLAStates(cal sla.Calendar, items []entity.PipelineItem, now time.Time) {
+	for i := range items {
+		item := &items[i]
+		if item.Status == entity.PipelineOpen {
+			item.SLAState = cal.State(item.StepEnteredAt, item.StepDueAt, nil, now)
+		}
+		for j := range item.Steps {
+			step := &item.Steps[j]
+			step.SLAState = cal.State(step.EnteredAt, step.DueAt, step.ExitedAt, now)
+		}
+	}
+}
+
+// checkSLABreaches marks open step visits that passed their due time and
+// notifies each breach once. A failed notification is retried next run.
+func checkSLABreaches(db *gorm.DB, notifier sla.Notifier, now time.Time) (int, error) {
+	var visits []entity.PipelineStep
+	err := db.Where("exited_at IS NULL AND due_at <= ? AND notified_at IS NULL", now).
+		Where("pipeline_item_id IN (?)", db.Model(&entity.PipelineItem{}).Select("id").Where("status = ?", entity.PipelineOpen)).
+		Order("due_at").Find(&visits).Error
+	if err != nil || len(visits) == 0 {
+		return 0, err
+	}
+
+	ids := make([]string, 0, len(visits))
+	for _, visit := range visits {
+		ids = append(ids, visit.PipelineItemID)
+	}
+	var items []entity.PipelineItem
+	db.Preload("Customer", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Where("id IN ?", ids).Find(&items)
+	byID := make(map[string]entity.PipelineItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	notified := 0
+	for _, visit := range visits {
+		if visit.BreachedAt == nil {
+			db.Model(&entity.PipelineStep{}).Where("id = ?", visit.ID).Update("breached_at", now)
+		}
+		item := byID[visit.PipelineItemID]
+		breach := sla.Breach{
+			ItemID:     visit.PipelineItemID,
+			CustomerID: item.CustomerID,
+			Title:      item.Title,
+			WorkflowID: item.WorkflowID,
+			StepID:     visit.StepID,
+			Step:       visit.StepName,
+			OwnerID:    item.OwnerID,
+			EnteredAt:  visit.EnteredAt,
+			DueAt:      *visit.DueAt,
+			DetectedAt: now,
+		}
+		if item.Customer != nil {
+			breach.CustomerName = item.Customer.Name
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := notifier.NotifyBreach(ctx, breach)
+		cancel()
+		if err != nil {
+			log.Printf("sla monitor: notify breach of item %s: %v", visit.PipelineItemID, err)
+			continue
+		}
+		db.Model(&entity.PipelineStep{}).Where("id = ?", visit.ID).Update("notified_at", now)
+		notified++
+	}
+	return notified, nil
+}
+
+// StartSLAMonitor flags breached pipeline steps in the background and sends
+// them to SLANotifier
+func StartSLAMonitor() {
+	go func() {
+		ticker := time.NewTicker(slaMonitorInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := checkSLABreaches(config.DB, SLANotifier, time.Now()); err != nil {
+				log.Printf("sla monitor: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func slaItem(item entity.PipelineItem) dto.SLAItem {
+	card := dto.SLAItem{
+		ID:            item.ID,
+		CustomerID:    item.CustomerID,
+		Title:         item.Title,
+		WorkflowID:    item.WorkflowID,
+		StepID:        item.CurrentStepID,
+		OwnerID:       item.OwnerID,
+		StepEnteredAt: item.StepEnteredAt,
+		DueAt:         item.StepDueAt,
+		SLAState:      item.SLAState,
+	}
+	if item.Customer != nil {
+		card.CustomerName = item.Customer.Name
+	}
+	if item.CurrentStep != nil {
+		card.Step = item.CurrentStep.Name
+	}
+	return card
+}
+
+// @Summary SLA dashboard
+// @Description SLA state of every open pipeline item (on track, at risk, breached) and per-step results of the
+// @Description step visits completed since from. Durations are working hours on the SLA calendar.
+// @Tags SLA
+// @Produce json
+// @Security BearerAuth
+// @Param workflow_id query string false "Filter by workflow"
+// @Param stage_id query string false "Filter by stage"
+// @Param owner_id query string false "Filter by owner"
+// @Param from query string false "Start of the completed-visit period (default 30 days ago), e.g. 2025-01-01"
+// @Param limit query int false "Items listed per state (default 50)"
+// @Success 200 {object} dto.SLADashboard
+// @Failure 400 {object} dto.ErrorResponse
+// @Router /api/sla/dashboard [get]
+func GetSLADashboard(c *gin.Context) {
+	now := time.Now()
+	from := now.AddDate(0, 0, -30)
+	if v := c.Query("from"); v != "" {
+		t, err := parseStatusTime(v)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "Invalid from, use RFC3339 or YYYY-MM-DD")
+			return
+		}
+		from = *t
+	}
+	limit := defaultBoardLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			sendError(c, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	filter := func(db *gorm.DB) *gorm.DB {
+		for _, column := range []string{"workflow_id", "stage_id", "owner_id"} {
+			if v := c.Query(column); v != "" {
+				db = db.Where(column+" = ?", v)
+			}
+		}
+		return db
+	}
+
+	var items []entity.PipelineItem
+	err := config.DB.Scopes(filter).Where("status = ?", entity.PipelineOpen).
+		Preload("Customer", func(db *gorm.DB) *gorm.DB { return db.Unscoped().Select("id", "name") }).
+		Preload("CurrentStep", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("step_due_at").Find(&items).Error
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch pipeline items")
+		return
+	}
+	var visits []entity.PipelineStep
+	err = config.DB.Where("exited_at >= ? AND pipeline_item_id IN (?)", from,
+		config.DB.Model(&entity.PipelineItem{}).Unscoped().Scopes(filter).Select("id")).
+		Find(&visits).Error
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch step history")
+		return
+	}
+
+	cal := slaCalendar(config.DB)
+	setSLAStates(cal, items, now)
+
+	stats := make(map[string]*dto.SLAStepStats)
+	statFor := func(stepID, name string) *dto.SLAStepStats {
+		s, ok := stats[stepID]
+		if !ok {
+			s = &dto.SLAStepStats{StepID: stepID, Name: name}
+			stats[stepID] = s
+		}
+		return s
+	}
+
+	board := dto.SLADashboard{
+		GeneratedAt: now,
+		From:        from,
+		AtRisk:      []dto.SLAItem{},
+		Breached:    []dto.SLAItem{},
+	}
+	for _, item := range items {
+		name := ""
+		if item.CurrentStep != nil {
+			name = item.CurrentStep.Name
+		}
+		s := statFor(item.CurrentStepID, name)
+		s.Open++
+		board.Summary.Open++
+		switch item.SLAState {
+		case sla.StateOnTrack:
+			s.OnTrack++
+			board.Summary.OnTrack++
+		case sla.StateAtRisk:
+			s.AtRisk++
+			board.Summary.AtRisk++
+			if len(board.AtRisk) < limit {
+				board.AtRisk = append(board.AtRisk, slaItem(item))
+			}
+		case sla.StateBreached:
+			s.Breached++
+			board.Summary.Breached++
+			if len(board.Breached) < limit {
+				board.Breached = append(board.Breached, slaItem(item))
+			}
+		default:
+			board.Summary.NoSLA++
+		}
+	}
+
+	worked := make(map[string]time.Duration)
+	for _, visit := range visits {
+		s := statFor(visit.StepID, visit.StepName)
+		s.Completed++
+		worked[visit.StepID] += cal.Between(visit.EnteredAt, *visit.ExitedAt)
+		switch cal.State(visit.EnteredAt, visit.DueAt, visit.ExitedAt, now) {
+		case sla.StateMet:
+			s.Met++
+		case sla.StateBreached:
+			s.Missed++
+		}
+	}
+
+	// Nama, SLA dan urutan diambil dari definisi step
+	stepIDs := make([]string, 0, len(stats))
+	for id := range stats {
+		stepIDs = append(stepIDs, id)
+	}
+	var steps []entity.StagesDetail
+	config.DB.Unscoped().Where("id IN ?", stepIDs).Find(&steps)
+	order := make(map[string]entity.StagesDetail, len(steps))
+	for _, step := range steps {
+		order[step.ID] = step
+		s := stats[step.ID]
+		s.Name, s.Sla, s.Uom, s.FlowOrder = step.Name, step.Sla, step.Uom, step.FlowOrder
+	}
+	for id, s := range stats {
+		if s.Completed > 0 {
+			s.AvgWorkingHours = worked[id].Hours() / float64(s.Completed)
+		}
+		if s.Met+s.Missed > 0 {
+			s.ComplianceRate = float64(s.Met) / float64(s.Met+s.Missed) * 100
+		}
+		board.Steps = append(board.Steps, *s)
+	}
+	sort.Slice(board.Steps, func(i, j int) bool {
+		a, b := order[board.Steps[i].StepID], order[board.Steps[j].StepID]
+		if a.StageID != b.StageID {
+			return a.StageID < b.StageID
+		}
+		return a.FlowOrder < b.FlowOrder
+	})
+	if board.Steps == nil {
+		board.Steps = []dto.SLAStepStats{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "SLA dashboard fetched successfully",
+		"data":    board,
+	})
+}
+
+// @Summary List holidays
+// @Description Holidays of the SLA calendar; working time does not run on these dates
+// @Tags SLA
+// @Produce json
+// @Security BearerAuth
+// @Param year query int false "Only holidays in this year"
+// @Success 200 {array} entity.Holiday
+// @Router /api/sla/holidays [get]
+func GetHolidays(c *gin.Context) {
+	db := config.DB.Order("date")
+	if v := c.Query("year"); v != "" {
+		year, err := strconv.Atoi(v)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, "Invalid year")
+			return
+		}
+		db = db.Where("EXTRACT(YEAR FROM date) = ?", year)
+	}
+	var holidays []entity.Holiday
+	if err := db.Find(&holidays).Error; err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch holidays")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Holidays fetched successfully",
+		"data":    holidays,
+	})
+}
+
+// @Summary Create holiday
+// @Description Add a holiday to the SLA calendar. Due times computed before are not moved.
+// @Tags SLA
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.HolidayRequest true "Holiday"
+// @Success 201 {object} entity.Holiday
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /api/sla/holidays [post]
+func CreateHoliday(c *gin.Context) {
+	var req dto.HolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, "Invalid date, use YYYY-MM-DD")
+		return
+	}
+	var count int64
+	config.DB.Model(&entity.Holiday{}).Where("date = ?", req.Date).Count(&count)
+	if count > 0 {
+		sendError(c, http.StatusConflict, "Holiday already exists on "+req.Date)
+		return
+	}
+
+	holiday := entity.Holiday{Date: date, Name: strings.TrimSpace(req.Name)}
+	if err := config.DB.WithContext(c).Create(&holiday).Error; err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to create holiday")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Holiday created successfully",
+		"data":    holiday,
+	})
+}
+
+// @Summary Delete holiday
+// @Tags SLA
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Holiday ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/sla/holidays/{id} [delete]
+func DeleteHoliday(c *gin.Context) {
+	result := config.DB.WithContext(c).Where("id = ?", c.Param("id")).Delete(&entity.Holiday{})
+	if result.Error != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to delete holiday")
+		return
+	}
+	if result.RowsAffected == 0 {
+		sendError(c, http.StatusNotFound, "Holiday not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Holiday deleted successfully",
+		"data":    nil,
+	})
+}
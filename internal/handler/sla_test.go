@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"customer-api/internal/entity"
+	"customer-api/internal/sla"
+)
+
+type recordingNotifier struct {
+	err      error
+	breaches []sla.Breach
+}
+
+func (n *recordingNotifier) NotifyBreach(_ context.Context, b sla.Breach) error {
+	n.breaches = append(n.breaches, b)
+	return n.err
+}
+
+// slaStore answers the statements of checkSLABreaches from the open step
+// visits of one item
+type slaStore struct {
+	item     entity.PipelineItem
+	customer entity.Customer
+	steps    []*entity.PipelineStep
+}
+
+func (s *slaStore) handle(query string, args []driver.Value) (*fakeRows, error) {
+	switch {
+	case strings.HasPrefix(query, `SELECT * FROM "pipeline_steps"`):
+		now := args[0].(time.Time)
+		var due []interface{}
+		for _, step := range s.steps {
+			if step.NotifiedAt == nil && !step.DueAt.After(now) {
+				due = append(due, *step)
+			}
+		}
+		return rowsOf(due...), nil
+	case strings.HasPrefix(query, `SELECT * FROM "pipeline_items"`):
+		return rowsOf(s.item), nil
+	case strings.HasPrefix(query, `SELECT * FROM "customers"`):
+		return rowsOf(s.customer), nil
+	case strings.HasPrefix(query, `UPDATE "pipeline_steps" SET "breached_at"=$1 WHERE id = $2`),
+		strings.HasPrefix(query, `UPDATE "pipeline_steps" SET "notified_at"=$1 WHERE id = $2`):
+		for _, step := range s.steps {
+			if step.ID == args[1] {
+				at := args[0].(time.Time)
+				if strings.Contains(query, "breached_at") {
+					step.BreachedAt = &at
+				} else {
+					step.NotifiedAt = &at
+				}
+				return &fakeRows{affected: 1}, nil
+			}
+		}
+		return &fakeRows{}, nil
+	}
+	return nil, errors.New("unexpected statement: " + query)
+}
+
+func TestCheckSLABreaches(t *testing.T) {
+	clock := func(hour, minute int) time.Time {
+		return time.Date(2026, time.October, 19, hour, minute, 0, 0, time.UTC)
+	}
+	dueLate, dueNoon := clock(10, 0), clock(12, 0)
+	store := &slaStore{
+		item:     entity.PipelineItem{ID: "item1", CustomerID: "cust1", Title: "Renewal", Status: entity.PipelineOpen},
+		customer: entity.Customer{ID: "cust1", Name: "PT Maju"},
+		steps: []*entity.PipelineStep{
+			{ID: "late", PipelineItemID: "item1", StepName: "Proposal", DueAt: &dueLate},
+			{ID: "noon", PipelineItemID: "item1", StepName: "Negotiation", DueAt: &dueNoon},
+		},
+	}
+	late, noon := store.steps[0], store.steps[1]
+	db := openFakeDB(t, store.handle)
+	notifier := &recordingNotifier{err: errors.New("webhook down")}
+
+	// Notifikasi gagal: step ditandai breach tapi belum notified
+	if n, err := checkSLABreaches(db, notifier, clock(11, 0)); err != nil || n != 0 {
+		t.Fatalf("first run = %d, %v; want 0 notified", n, err)
+	}
+	if late.BreachedAt == nil || !late.BreachedAt.Equal(clock(11, 0)) || late.NotifiedAt != nil {
+		t.Fatalf("after failed notification: breached %v, notified %v", late.BreachedAt, late.NotifiedAt)
+	}
+	if len(notifier.breaches) != 1 || notifier.breaches[0].Step != "Proposal" || notifier.breaches[0].CustomerName != "PT Maju" {
+		t.Fatalf("breaches sent = %+v", notifier.breaches)
+	}
+
+	// Run berikutnya mencoba lagi tanpa menggeser breached_at
+	notifier.err = nil
+	if n, err := checkSLABreaches(db, notifier, clock(11, 1)); err != nil || n != 1 {
+		t.Fatalf("retry = %d, %v; want 1 notified", n, err)
+	}
+	if !late.BreachedAt.Equal(clock(11, 0)) || late.NotifiedAt == nil || !late.NotifiedAt.Equal(clock(11, 1)) {
+		t.Errorf("after retry: breached %v, notified %v", late.BreachedAt, late.NotifiedAt)
+	}
+
+	// Sudah terkirim: tidak dikirim ulang
+	if n, _ := checkSLABreaches(db, notifier, clock(11, 2)); n != 0 || len(notifier.breaches) != 2 {
+		t.Errorf("third run notified %d, %d notifications in total; want no new one", n, len(notifier.breaches))
+	}
+
+	if n, _ := checkSLABreaches(db, notifier, clock(12, 30)); n != 1 || noon.NotifiedAt == nil {
+		t.Errorf("noon step: notified %d, notified_at %v", n, noon.NotifiedAt)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // @Summary Create sosmed for customer
@@ -28,7 +29,7 @@ func CreateSosmed(c *gin.Context) {
 
 	// Check if customer exists
 	var customer entity.Customer
-	if err := config.DB.First(&customer, customerID).Error; err != nil {
+	if err := config.DB.Where("id = ?", customerID).First(&customer).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
 		return
 	}
@@ -40,10 +41,16 @@ func CreateSosmed(c *gin.Context) {
 	}
 
 	// Set customer ID
+	sosmed.ID = ""
 	sosmed.CustomerID = customer.ID
 
-	result := config.DB.Create(&sosmed)
-	if result.Error != nil {
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&sosmed).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, c.GetString("user_id"), "", nil, &sosmed)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create sosmed"})
 		return
 	}
@@ -116,18 +123,30 @@ func UpdateSosmed(c *gin.Context) {
 	id := c.Param("id")
 
 	var sosmed entity.Sosmed
-	result := config.DB.First(&sosmed, id)
+	result := config.DB.Where("id = ?", id).First(&sosmed)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Sosmed not found"})
 		return
 	}
+	before := sosmed
 
 	if err := c.ShouldBindJSON(&sosmed); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	config.DB.Save(&sosmed)
+	sosmed.ID = before.ID
+	sosmed.CreatedAt = before.CreatedAt
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&sosmed).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, c.GetString("user_id"), "", &before, &sosmed)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update sosmed"})
+		return
+	}
 	c.JSON(http.StatusOK, sosmed)
 }
 
@@ -145,14 +164,20 @@ func UpdateSosmed(c *gin.Context) {
 func DeleteSosmed(c *gin.Context) {
 	id := c.Param("id")
 
-	result := config.DB.Delete(&entity.Sosmed{}, id)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sosmed"})
+	var sosmed entity.Sosmed
+	if err := config.DB.Where("id = ?", id).First(&sosmed).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sosmed not found"})
 		return
 	}
 
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Sosmed not found"})
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&sosmed).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, c.GetString("user_id"), "", &sosmed, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sosmed"})
 		return
 	}
 
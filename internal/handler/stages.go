@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"gorm.io/gorm"
 	"customer-api/internal/config"
 	"customer-api/internal/entity"
+	"customer-api/internal/query"
 	"github.com/gin-gonic/gin"
 )
 
@@ -48,7 +51,7 @@ func CreateStage(c *gin.Context) {
 		Name: input.Name,
 	}
 
-	if result := config.DB.Create(&stage); result.Error != nil {
+	if result := config.DB.WithContext(c).Create(&stage); result.Error != nil {
 	c.JSON(http.StatusInternalServerError, gin.H{
 		"status":  "failed",
 		"message": "Gagal membuat stage",
@@ -64,17 +67,35 @@ func CreateStage(c *gin.Context) {
 	})
 }
 
+var stageListSpec = query.Spec{
+	Table: "stages",
+	Fields: map[string]query.Field{
+		"name":       {},
+		"is_active":  {Type: query.Bool},
+		"created_at": {Type: query.Time},
+	},
+	DefaultSort:     "created_at",
+	DefaultPageSize: 100,
+}
+
 func GetStages(c *gin.Context) {
+	params, ok := parseList(c, stageListSpec)
+	if !ok {
+		return
+	}
+
 	var stages []entity.Stages
-	if result := config.DB.Find(&stages); result.Error != nil {
+	page, err := stageListSpec.Find(config.DB, params, &stages)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data stages"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Stages fetched successfully",
-		"data":    stages,
+		"status":     "success",
+		"message":    "Stages fetched successfully",
+		"data":       stages,
+		"pagination": page,
 	})
 }
 
@@ -89,6 +110,7 @@ func GetStage(c *gin.Context) {
 		return
 	}
 
+	setETag(c, &stage)
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Stage fetched successfully",
@@ -106,6 +128,9 @@ func UpdateStage(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Stage not found"})
 		return
 	}
+	if !checkIfMatch(c, &stage) {
+		return
+	}
 
 	var input StageInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -130,11 +155,22 @@ func UpdateStage(c *gin.Context) {
 
 	stage.Name = input.Name
 
-	if result := config.DB.Save(&stage); result.Error != nil {
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := lockVersion(tx, &entity.Stages{}, stage.ID, stage.Version); err != nil {
+			return err
+		}
+		return tx.Save(&stage).Error
+	})
+	if errors.Is(err, errVersionConflict) {
+		sendVersionConflict(c, &entity.Stages{}, id)
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui stage"})
 		return
 	}
 
+	setETag(c, &stage)
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Stage berhasil diperbarui",
@@ -153,8 +189,21 @@ func DeleteStage(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Stage not found"})
 		return
 	}
+	if !checkIfMatch(c, &stage) {
+		return
+	}
 
-	if result := config.DB.Delete(&stage); result.Error != nil {
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := lockVersion(tx, &entity.Stages{}, stage.ID, stage.Version); err != nil {
+			return err
+		}
+		return tx.Delete(&stage).Error
+	})
+	if errors.Is(err, errVersionConflict) {
+		sendVersionConflict(c, &entity.Stages{}, id)
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus stage"})
 		return
 	}
@@ -169,9 +218,10 @@ func CreateStageDetail(c *gin.Context) {
 	stageID := c.Param("id")
 
 	var input struct {
-		Name string `json:"name" binding:"required"`
-		Sla  int    `json:"sla" binding:"required"`
-		Uom  string `json:"uom" binding:"required"`
+		Name      string `json:"name" binding:"required"`
+		Sla       int    `json:"sla" binding:"required"`
+		Uom       string `json:"uom" binding:"required"`
+		FlowOrder *int   `json:"flow_order"` // urutan di pipeline, default setelah step terakhir
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -197,8 +247,14 @@ func CreateStageDetail(c *gin.Context) {
 		Sla:     input.Sla,
 		Uom:     input.Uom,
 	}
+	if input.FlowOrder != nil {
+		detail.FlowOrder = *input.FlowOrder
+	} else {
+		config.DB.Model(&entity.StagesDetail{}).Where("stage_id = ?", stage.ID).
+			Select("COALESCE(MAX(flow_order), 0) + 1").Row().Scan(&detail.FlowOrder)
+	}
 
-	if result := config.DB.Create(&detail); result.Error != nil {
+	if result := config.DB.WithContext(c).Create(&detail); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "failed", "message": "Gagal membuat stage detail", "data": result.Error.Error()})
 		return
 	}
@@ -209,7 +265,7 @@ func CreateStageDetail(c *gin.Context) {
 func GetStageDetails(c *gin.Context) {
 	stageID := c.Param("id")
 	var details []entity.StagesDetail
-	if result := config.DB.Where("stage_id = ?", stageID).Find(&details); result.Error != nil {
+	if result := config.DB.Where("stage_id = ?", stageID).Order("flow_order, created_at").Find(&details); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "failed", "message": "Gagal mengambil data stage details"})
 		return
 	}
@@ -239,9 +295,10 @@ func UpdateStageDetail(c *gin.Context) {
 	}
 
 	var input struct {
-		Name string `json:"name" binding:"required"`
-		Sla  int    `json:"sla" binding:"required"`
-		Uom  string `json:"uom" binding:"required"`
+		Name      string `json:"name" binding:"required"`
+		Sla       int    `json:"sla" binding:"required"`
+		Uom       string `json:"uom" binding:"required"`
+		FlowOrder *int   `json:"flow_order"` // urutan di pipeline, default setelah step terakhir
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -258,8 +315,11 @@ func UpdateStageDetail(c *gin.Context) {
 	detail.Name = input.Name
 	detail.Sla = input.Sla
 	detail.Uom = input.Uom
+	if input.FlowOrder != nil {
+		detail.FlowOrder = *input.FlowOrder
+	}
 
-	if result := config.DB.Save(&detail); result.Error != nil {
+	if result := config.DB.WithContext(c).Save(&detail); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "failed", "message": "Gagal memperbarui stage detail"})
 		return
 	}
@@ -277,7 +337,7 @@ func DeleteStageDetail(c *gin.Context) {
 		return
 	}
 
-	if result := config.DB.Delete(&detail); result.Error != nil {
+	if result := config.DB.WithContext(c).Delete(&detail); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "failed", "message": "Gagal menghapus stage detail"})
 		return
 	}
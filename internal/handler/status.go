@@ -4,37 +4,47 @@ import (
 	"customer-api/internal/config"
 	"customer-api/internal/dto"
 	"customer-api/internal/entity"
+	"customer-api/internal/query"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+var statusListSpec = query.Spec{
+	Table: "statuses",
+	Fields: map[string]query.Field{
+		"status_name": {},
+		"created_at":  {Type: query.Time},
+	},
+	DefaultSort:     "status_name",
+	DefaultPageSize: 100,
+}
+
 // @Summary Get all statuses
-// @Description Get list of all statuses (master data)
+// @Description Get list of statuses (master data); total count is returned in the X-Total-Count header
 // @Tags Statuses
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param active query bool false "Filter by active status"
+// @Param status_name query string false "Filter by name, status_name[like]= for partial match"
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 100, max 200)"
+// @Param sort query string false "Sort fields, prefix - for descending"
 // @Success 200 {array} dto.StatusResponse
+// @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/statuses [get]
 func GetStatuses(c *gin.Context) {
-	var statuses []entity.Status
-	query := config.DB
-
-	// Filter by active status if provided
-	if activeParam := c.Query("active"); activeParam != "" {
-		if active, err := strconv.ParseBool(activeParam); err == nil {
-			// Assuming we add Active field to Status model later
-			_ = active // placeholder for now
-		}
+	params, ok := parseList(c, statusListSpec)
+	if !ok {
+		return
 	}
 
-	if result := query.Find(&statuses); result.Error != nil {
+	var statuses []entity.Status
+	page, err := statusListSpec.Find(config.DB, params, &statuses)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statuses"})
 		return
 	}
@@ -50,6 +60,7 @@ func GetStatuses(c *gin.Context) {
 		})
 	}
 
+	setPaginationHeaders(c, page)
 	c.JSON(http.StatusOK, statusResponses)
 }
 
@@ -118,7 +129,7 @@ func CreateStatus(c *gin.Context) {
 		StatusName: req.StatusName,
 	}
 
-	if result := config.DB.Create(&status); result.Error != nil {
+	if result := config.DB.WithContext(c).Create(&status); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create status"})
 		return
 	}
@@ -176,7 +187,7 @@ func UpdateStatus(c *gin.Context) {
 	// Update status
 	status.StatusName = req.StatusName
 
-	if result := config.DB.Save(&status); result.Error != nil {
+	if result := config.DB.WithContext(c).Save(&status); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status"})
 		return
 	}
@@ -226,7 +237,7 @@ func DeleteStatus(c *gin.Context) {
 	}
 
 	// Soft delete
-	if result := config.DB.Delete(&status); result.Error != nil {
+	if result := config.DB.WithContext(c).Delete(&status); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete status"})
 		return
 	}
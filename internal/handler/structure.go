@@ -42,18 +42,32 @@ func CreateStructure(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.CustomerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "customer_id is required"})
+		return
+	}
+
+	var customer entity.Customer
+	if err := config.DB.Where("id = ?", req.CustomerID).First(&customer).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
 
-	// Hapus validasi customer exists karena CustomerID tidak ada di request
 	structure := entity.Structure{
-		// CustomerID: customer.ID, // Akan diset sesuai kebutuhan
-		Name:    req.Name,
-		Level:   req.Level,
-		Address: req.Address,
-		Active:  req.Active,
+		CustomerID: customer.ID,
+		Name:       req.Name,
+		Level:      req.Level,
+		Address:    req.Address,
+		Active:     req.Active,
 	}
 
-	result := config.DB.Create(&structure)
-	if result.Error != nil {
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&structure).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, c.GetString("user_id"), "", nil, &structure)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create structure"})
 		return
 	}
@@ -163,18 +177,30 @@ func UpdateStructure(c *gin.Context) {
 	id := c.Param("id")
 
 	var structure entity.Structure
-	result := config.DB.First(&structure, id)
+	result := config.DB.Where("id = ?", id).First(&structure)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Structure not found"})
 		return
 	}
+	before := structure
 
 	if err := c.ShouldBindJSON(&structure); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	config.DB.Save(&structure)
+	structure.ID = before.ID
+	structure.CreatedAt = before.CreatedAt
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&structure).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, c.GetString("user_id"), "", &before, &structure)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update structure"})
+		return
+	}
 	c.JSON(http.StatusOK, structure)
 }
 
@@ -192,14 +218,20 @@ func UpdateStructure(c *gin.Context) {
 func DeleteStructure(c *gin.Context) {
 	id := c.Param("id")
 
-	result := config.DB.Delete(&entity.Structure{}, id)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete structure"})
+	var structure entity.Structure
+	if err := config.DB.Where("id = ?", id).First(&structure).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Structure not found"})
 		return
 	}
 
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Structure not found"})
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&structure).Error; err != nil {
+			return err
+		}
+		return recordCustomerChange(tx, c.GetString("user_id"), "", &structure, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete structure"})
 		return
 	}
 
@@ -3,26 +3,47 @@ package handler
 import (
 	"customer-api/internal/config"
 	"customer-api/internal/entity"
+	"customer-api/internal/query"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+var teamListSpec = query.Spec{
+	Table: "teams",
+	Fields: map[string]query.Field{
+		"name":       {},
+		"team_lead":  {},
+		"industry":   {},
+		"is_active":  {Type: query.Bool},
+		"created_at": {Type: query.Time},
+	},
+	DefaultSort:     "name",
+	DefaultPageSize: 100,
+}
+
 func GetTeams(c *gin.Context) {
+	params, ok := parseList(c, teamListSpec)
+	if !ok {
+		return
+	}
+
 	var teams []entity.Teams
-	if result := config.DB.Find(&teams); result.Error != nil {
+	page, err := teamListSpec.Find(config.DB, params, &teams)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal mendapatkan teams",
-			"data":    result.Error.Error(),
+			"data":    err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Teams berhasil ditemukan",
-		"data":    teams,
+		"status":     "success",
+		"message":    "Teams berhasil ditemukan",
+		"data":       teams,
+		"pagination": page,
 	})
 }
 
@@ -37,7 +58,7 @@ func CreateTeam(c *gin.Context) {
 		})
 		return
 	}
-	if result := config.DB.Create(&team); result.Error != nil {
+	if result := config.DB.WithContext(c).Create(&team); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal membuat team",
@@ -65,7 +86,7 @@ func UpdateTeam(c *gin.Context) {
 		})
 		return
 	}
-	if result := config.DB.Save(&team); result.Error != nil {
+	if result := config.DB.WithContext(c).Save(&team); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal memperbarui team",
@@ -93,7 +114,7 @@ func DeleteTeam(c *gin.Context) {
 
 		return
 	}
-	if result := config.DB.Delete(&team); result.Error != nil {
+	if result := config.DB.WithContext(c).Delete(&team); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal menghapus team",
@@ -157,7 +178,7 @@ func CreateTeamDetail(c *gin.Context) {
 		return
 	}
 
-	if result := config.DB.Create(&teamDetail); result.Error != nil {
+	if result := config.DB.WithContext(c).Create(&teamDetail); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal membuat team detail",
@@ -186,7 +207,7 @@ func UpdateTeamDetail(c *gin.Context) {
 		return
 	}
 
-	if result := config.DB.Save(&teamDetail); result.Error != nil {
+	if result := config.DB.WithContext(c).Save(&teamDetail); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal memperbarui team detail",
@@ -215,7 +236,7 @@ func DeleteTeamDetail(c *gin.Context) {
 		return
 	}
 
-	if result := config.DB.Delete(&teamDetail); result.Error != nil {
+	if result := config.DB.WithContext(c).Delete(&teamDetail); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal menghapus team detail",
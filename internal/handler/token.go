@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/entity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// tokenPair is what Login and Refresh hand back to the client
+type tokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// durationFromEnv reads a Go duration (e.g. "15m") from env, falling back to def
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return def
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueTokens signs a short-lived access token and stores a new refresh token
+func issueTokens(tx *gorm.DB, c *gin.Context, userID string) (tokenPair, *entity.RefreshToken, error) {
+	now := time.Now()
+	accessTTL := durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": userID,
+		"jti":     ulid.Make().String(),
+		"iat":     now.Unix(),
+		"exp":     now.Add(accessTTL).Unix(),
+	})
+	accessToken, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		return tokenPair{}, nil, err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return tokenPair{}, nil, err
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(raw)
+
+	record := entity.RefreshToken{
+		UserID:    userID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(durationFromEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)),
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return tokenPair{}, nil, err
+	}
+
+	return tokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(accessTTL.Seconds()),
+	}, &record, nil
+}
+
+// revokeAllSessions revokes every refresh token of a user and invalidates
+// all access tokens issued before now. Dibulatkan ke detik karena iat di
+// JWT juga hanya sampai detik, token baru yang terbit di detik yang sama tetap
+// berlaku.
+func revokeAllSessions(tx *gorm.DB, userID string) error {
+	now := time.Now().Truncate(time.Second)
+	if err := tx.Model(&entity.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	return tx.Model(&entity.User{}).Where("id = ?", userID).Update("tokens_valid_after", now).Error
+}
+
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and a rotated refresh token
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.LoginResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /refresh [post]
+func Refresh(c *gin.Context) {
+	var input RefreshInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var stored entity.RefreshToken
+	if err := config.DB.Where("token_hash = ?", hashToken(input.RefreshToken)).First(&stored).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	// Refresh token yang sudah di-rotate dipakai lagi: kemungkinan dicuri,
+	// cabut semua sesi user tersebut.
+	if stored.RevokedAt != nil {
+		revokeAllSessions(config.DB, stored.UserID)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token has been revoked"})
+		return
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token expired"})
+		return
+	}
+
+	var pair tokenPair
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var next *entity.RefreshToken
+		var err error
+		pair, next, err = issueTokens(tx, c, stored.UserID)
+		if err != nil {
+			return err
+		}
+
+		// Guard against two concurrent refreshes with the same token
+		result := tx.Model(&entity.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", stored.ID).
+			Updates(map[string]interface{}{"revoked_at": time.Now(), "replaced_by_id": next.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token has been revoked"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+// @Summary Logout
+// @Description Revoke the current access token and, if given, its refresh token
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /logout [post]
+func Logout(c *gin.Context) {
+	var input LogoutInput
+	// Body bersifat opsional
+	_ = c.ShouldBindJSON(&input)
+
+	userID := c.GetString("user_id")
+	jti := c.GetString("jti")
+
+	if jti != "" {
+		expiresAt, _ := c.Get("token_expires_at")
+		exp, ok := expiresAt.(time.Time)
+		if !ok {
+			exp = time.Now().Add(durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL))
+		}
+		revoked := entity.RevokedToken{JTI: jti, UserID: userID, ExpiresAt: exp}
+		if err := config.DB.WithContext(c).Create(&revoked).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+			return
+		}
+	}
+
+	if input.RefreshToken != "" {
+		config.DB.Model(&entity.RefreshToken{}).
+			Where("token_hash = ? AND user_id = ? AND revoked_at IS NULL", hashToken(input.RefreshToken), userID).
+			Update("revoked_at", time.Now())
+	}
+
+	// Bersihkan revocation list dari token yang sudah kedaluwarsa
+	config.DB.WithContext(c).Where("expires_at < ?", time.Now()).Delete(&entity.RevokedToken{})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// RevokeUserSessions revokes every refresh and access token of a user
+func RevokeUserSessions(c *gin.Context) {
+	id := c.Param("id")
+
+	var user entity.User
+	if result := config.DB.Where("id = ?", id).First(&user); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "failed",
+			"message": "User tidak ditemukan",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err := revokeAllSessions(config.DB, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "failed",
+			"message": "Gagal mencabut sesi user",
+			"data":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Semua sesi user berhasil dicabut",
+		"data":    nil,
+	})
+}
@@ -0,0 +1,453 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+	"customer-api/internal/query"
+	"customer-api/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Data turunan customer yang ikut dihapus dan dipulihkan bersama customer
+var customerChildTables = []struct {
+	name  string
+	model interface{}
+}{
+	{"addresses", &entity.Address{}},
+	{"contacts", &entity.Contact{}},
+	{"sosmeds", &entity.Sosmed{}},
+	{"structures", &entity.Structure{}},
+	{"others", &entity.Other{}},
+}
+
+// Data yang membuat customer tidak bisa dipurge; harus dipindah atau dihapus dulu
+var customerPurgeBlockers = []struct {
+	name  string
+	model interface{}
+}{
+	{"activities", &entity.Activity{}},
+	{"events", &entity.Event{}},
+	{"documents", &entity.Document{}},
+	{"invoices", &entity.Invoice{}},
+	{"payments", &entity.Payment{}},
+	{"pipeline_items", &entity.PipelineItem{}},
+	{"assessment_runs", &entity.AssessmentRun{}},
+}
+
+type trashType struct {
+	resource string // permission resource, also the table name
+	model    interface{}
+}
+
+var trashTypes = map[string]trashType{
+	"customers":  {"customers", &entity.Customer{}},
+	"addresses":  {"addresses", &entity.Address{}},
+	"contacts":   {"contacts", &entity.Contact{}},
+	"sosmeds":    {"sosmeds", &entity.Sosmed{}},
+	"structures": {"structures", &entity.Structure{}},
+	"others":     {"others", &entity.Other{}},
+}
+
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+const trashPurgeInterval = time.Hour
+
+var (
+	errPurgeBlocked    = errors.New("customer still has related records")
+	errCustomerDeleted = errors.New("customer is deleted")
+)
+
+func trashListSpec(table string) query.Spec {
+	spec := query.Spec{
+		Table: table,
+		Fields: map[string]query.Field{
+			"deleted_at": {Type: query.Time},
+		},
+		DefaultSort: "-deleted_at",
+	}
+	if table != "customers" {
+		spec.Fields["customer_id"] = query.Field{}
+	}
+	return spec
+}
+
+// trashTypeFor resolves the type and checks the given action on its resource
+func trashTypeFor(c *gin.Context, name, action string) (trashType, bool) {
+	tt, ok := trashTypes[name]
+	if !ok {
+		sendError(c, http.StatusBadRequest, "Invalid type, must be one of: customers, addresses, contacts, sosmeds, structures, others")
+		return tt, false
+	}
+	middleware.RequirePermission(config.PermissionName(tt.resource, action))(c)
+	return tt, !c.IsAborted()
+}
+
+func (tt trashType) newRecord() interface{} {
+	return reflect.New(reflect.TypeOf(tt.model).Elem()).Interface()
+}
+
+func deletedAtOf(record interface{}) gorm.DeletedAt {
+	return reflect.Indirect(reflect.ValueOf(record)).FieldByName("DeletedAt").Interface().(gorm.DeletedAt)
+}
+
+func toTrashItem(record interface{}) dto.TrashItem {
+	entityType, id, customerID, label, _ := historySubject(record)
+	item := dto.TrashItem{Type: entityType, ID: id, Label: strings.TrimSpace(label)}
+	if entityType != "customer" {
+		item.CustomerID = customerID
+	}
+	if deletedAt := deletedAtOf(record); deletedAt.Valid {
+		item.DeletedAt = deletedAt.Time
+	}
+	return item
+}
+
+// findTrashed loads a soft-deleted record by id
+func findTrashed(db *gorm.DB, tt trashType, id string) (interface{}, error) {
+	record := tt.newRecord()
+	err := db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(record).Error
+	return record, err
+}
+
+// @Summary List trash
+// @Description Soft-deleted customers or child records of one type, most recently deleted first.
+// @Description Requires read permission on the type.
+// @Tags Trash
+// @Produce json
+// @Security BearerAuth
+// @Param type query string false "Record type (default customers)" Enums(customers, addresses, contacts, sosmeds, structures, others)
+// @Param customer_id query string false "Filter child records by customer"
+// @Param deleted_at[gte] query string false "Deleted from, e.g. 2025-01-01"
+// @Param deleted_at[lte] query string false "Deleted until (inclusive)"
+// @Param page query int false "Page (default 1)"
+// @Param page_size query int false "Page size (default 20, max 200)"
+// @Success 200 {array} dto.TrashItem
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /api/trash [get]
+func GetTrash(c *gin.Context) {
+	tt, ok := trashTypeFor(c, c.DefaultQuery("type", "customers"), config.ActionRead)
+	if !ok {
+		return
+	}
+	spec := trashListSpec(tt.resource)
+	params, ok := parseList(c, spec)
+	if !ok {
+		return
+	}
+
+	rows := reflect.New(reflect.SliceOf(reflect.TypeOf(tt.model).Elem()))
+	db := config.DB.Unscoped().Where(tt.resource + ".deleted_at IS NOT NULL")
+	page, err := spec.Find(db, params, rows.Interface())
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to fetch trash")
+		return
+	}
+
+	data := make([]dto.TrashItem, 0, rows.Elem().Len())
+	for i := 0; i < rows.Elem().Len(); i++ {
+		data = append(data, toTrashItem(rows.Elem().Index(i).Addr().Interface()))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"message":    "Trash fetched successfully",
+		"data":       data,
+		"pagination": page,
+	})
+}
+
+// @Summary Restore from trash
+// @Description Restores a soft-deleted record. A customer comes back together with the child records that were
+// @Description deleted with it; a child record can only be restored while its customer is active.
+// @Tags Trash
+// @Produce json
+// @Security BearerAuth
+// @Param type path string true "Record type" Enums(customers, addresses, contacts, sosmeds, structures, others)
+// @Param id path string true "Record ID"
+// @Success 200 {object} dto.TrashItem
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /api/trash/{type}/{id}/restore [post]
+func RestoreTrash(c *gin.Context) {
+	tt, ok := trashTypeFor(c, c.Param("type"), config.ActionWrite)
+	if !ok {
+		return
+	}
+	userID := c.GetString("user_id")
+
+	var restored map[string]int64
+	var item dto.TrashItem
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		record, err := findTrashed(tx, tt, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		_, id, customerID, _, _ := historySubject(record)
+		deletedAt := deletedAtOf(record)
+
+		if tt.resource != "customers" {
+			var count int64
+			if err := tx.Model(&entity.Customer{}).Where("id = ?", customerID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return errCustomerDeleted
+			}
+		}
+
+		before := tt.newRecord()
+		reflect.ValueOf(before).Elem().Set(reflect.ValueOf(record).Elem())
+		if err := tx.Unscoped().Model(tt.newRecord()).Where("id = ?", id).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		reflect.ValueOf(record).Elem().FieldByName("DeletedAt").Set(reflect.ValueOf(gorm.DeletedAt{}))
+
+		if tt.resource == "customers" {
+			restored = make(map[string]int64)
+			for _, child := range customerChildTables {
+				result := tx.Unscoped().Model(child.model).
+					Where("customer_id = ? AND deleted_at = ?", id, deletedAt.Time).
+					Update("deleted_at", nil)
+				if result.Error != nil {
+					return result.Error
+				}
+				restored[child.name] = result.RowsAffected
+			}
+		}
+
+		item = toTrashItem(before)
+		return recordCustomerAction(tx, userID, HistoryRestore, "", before, record)
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		sendError(c, http.StatusNotFound, "Record not found in trash")
+		return
+	case errors.Is(err, errCustomerDeleted):
+		sendError(c, http.StatusConflict, "Customer of this record is deleted, restore the customer first")
+		return
+	case err != nil:
+		sendError(c, http.StatusInternalServerError, "Failed to restore record")
+		return
+	}
+
+	response := gin.H{
+		"status":  "success",
+		"message": "Record restored successfully",
+		"data":    item,
+	}
+	if restored != nil {
+		response["restored"] = restored
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Purge from trash
+// @Description Permanently deletes one soft-deleted record. A customer is purged together with its child records,
+// @Description groups, history and status reasons; customers that still have activities, events, documents,
+// @Description invoices or payments cannot be purged. Requires trash:delete.
+// @Tags Trash
+// @Produce json
+// @Security BearerAuth
+// @Param type path string true "Record type" Enums(customers, addresses, contacts, sosmeds, structures, others)
+// @Param id path string true "Record ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /api/trash/{type}/{id} [delete]
+func PurgeTrashItem(c *gin.Context) {
+	middleware.RequirePermission(config.PermissionName("trash", config.ActionDelete))(c)
+	if c.IsAborted() {
+		return
+	}
+	tt, ok := trashTypes[c.Param("type")]
+	if !ok {
+		sendError(c, http.StatusBadRequest, "Invalid type, must be one of: customers, addresses, contacts, sosmeds, structures, others")
+		return
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		record, err := findTrashed(tx, tt, c.Param("id"))
+		if err != nil {
+			return err
+		}
+		_, id, _, _, _ := historySubject(record)
+		if tt.resource == "customers" {
+			return purgeCustomer(tx, id)
+		}
+		if tt.resource == "structures" {
+			if err := tx.Unscoped().Model(&entity.Structure{}).Where("parent_id = ?", id).Update("parent_id", nil).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(record).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		sendError(c, http.StatusNotFound, "Record not found in trash")
+		return
+	case errors.Is(err, errPurgeBlocked):
+		sendError(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		sendError(c, http.StatusInternalServerError, "Failed to purge record")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Record purged permanently"})
+}
+
+// @Summary Purge old trash
+// @Description Permanently deletes everything that has been in the trash longer than the retention period
+// @Description (TRASH_RETENTION, default 720h). Customers that cannot be purged are skipped. Requires trash:delete.
+// @Tags Trash
+// @Produce json
+// @Security BearerAuth
+// @Param older_than query string false "Override the retention, Go duration e.g. 168h"
+// @Success 200 {object} dto.TrashPurgeResult
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /api/trash/purge [post]
+func PurgeTrash(c *gin.Context) {
+	middleware.RequirePermission(config.PermissionName("trash", config.ActionDelete))(c)
+	if c.IsAborted() {
+		return
+	}
+	retention := durationFromEnv("TRASH_RETENTION", defaultTrashRetention)
+	if v := c.Query("older_than"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			sendError(c, http.StatusBadRequest, "Invalid older_than, use a duration such as 168h")
+			return
+		}
+		retention = d
+	}
+
+	result, err := purgeTrash(config.DB.WithContext(c), time.Now().Add(-retention))
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to purge trash")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Trash purged successfully",
+		"data":    result,
+	})
+}
+
+// purgeCustomer permanently deletes a customer and everything that only
+// belongs to it. Records with business meaning of their own block the purge.
+func purgeCustomer(tx *gorm.DB, id string) error {
+	var blockers []string
+	for _, table := range customerPurgeBlockers {
+		var count int64
+		if err := tx.Unscoped().Model(table.model).Where("customer_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			blockers = append(blockers, table.name)
+		}
+	}
+	if len(blockers) > 0 {
+		return fmt.Errorf("%w: %s", errPurgeBlocked, strings.Join(blockers, ", "))
+	}
+
+	// Parent structure dilepas dulu agar urutan hapus tidak melanggar foreign key
+	if err := tx.Unscoped().Model(&entity.Structure{}).Where("customer_id = ? AND parent_id IS NOT NULL", id).Update("parent_id", nil).Error; err != nil {
+		return err
+	}
+	for _, child := range customerChildTables {
+		if err := tx.Unscoped().Where("customer_id = ?", id).Delete(child.model).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Exec("DELETE FROM customer_groups WHERE customer_id = ?", id).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("customer_id = ?", id).Delete(&entity.StatusReasons{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("customer_id = ?", id).Delete(&entity.CustomerStatusChange{}).Error; err != nil {
+		return err
+	}
+	// Riwayat perubahan (history_customers) sengaja tidak dihapus
+	return tx.Unscoped().Where("id = ?", id).Delete(&entity.Customer{}).Error
+}
+
+// purgeTrash permanently deletes everything soft-deleted before cutoff. Each
+// customer is purged in its own transaction so one blocked customer does not
+// stop the rest.
+func purgeTrash(db *gorm.DB, cutoff time.Time) (dto.TrashPurgeResult, error) {
+	result := dto.TrashPurgeResult{Cutoff: cutoff, Purged: map[string]int64{}, Skipped: []string{}}
+
+	var ids []string
+	if err := db.Unscoped().Model(&entity.Customer{}).Where("deleted_at < ?", cutoff).Pluck("id", &ids).Error; err != nil {
+		return result, err
+	}
+	for _, id := range ids {
+		err := db.Transaction(func(tx *gorm.DB) error { return purgeCustomer(tx, id) })
+		switch {
+		case errors.Is(err, errPurgeBlocked):
+			result.Skipped = append(result.Skipped, id)
+		case err != nil:
+			// Satu customer yang gagal tidak menghentikan purge yang lain
+			log.Printf("trash purger: customer %s: %v", id, err)
+			result.Skipped = append(result.Skipped, id)
+		default:
+			result.Purged["customers"]++
+		}
+	}
+
+	// Data turunan milik customer yang masih di trash (termasuk yang baru saja
+	// dilewati) harus tetap ada supaya bisa ikut di-restore
+	err := db.Transaction(func(tx *gorm.DB) error {
+		trashed := tx.Unscoped().Model(&entity.Customer{}).Select("id").Where("deleted_at IS NOT NULL")
+		purged := tx.Unscoped().Model(&entity.Structure{}).Select("id").
+			Where("deleted_at < ? AND customer_id NOT IN (?)", cutoff, trashed)
+		if err := tx.Unscoped().Model(&entity.Structure{}).Where("parent_id IN (?)", purged).Update("parent_id", nil).Error; err != nil {
+			return err
+		}
+		for _, child := range customerChildTables {
+			deleted := tx.Unscoped().Where("deleted_at < ? AND customer_id NOT IN (?)", cutoff, trashed).Delete(child.model)
+			if deleted.Error != nil {
+				return deleted.Error
+			}
+			result.Purged[child.name] += deleted.RowsAffected
+		}
+		return nil
+	})
+	return result, err
+}
+
+// StartTrashPurger permanently deletes trash older than TRASH_RETENTION in the
+// background, once at start-up and then every hour
+func StartTrashPurger() {
+	go func() {
+		ticker := time.NewTicker(trashPurgeInterval)
+		defer ticker.Stop()
+		for {
+			cutoff := time.Now().Add(-durationFromEnv("TRASH_RETENTION", defaultTrashRetention))
+			result, err := purgeTrash(config.DB, cutoff)
+			if err != nil {
+				log.Printf("trash purger: %v", err)
+			} else if len(result.Skipped) > 0 {
+				log.Printf("trash purger: %d customer(s) skipped, still have related records or failed", len(result.Skipped))
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"customer-api/internal/config"
+	"customer-api/internal/dto"
+	"customer-api/internal/entity"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Routing workflow: workflow dengan type dan stage yang sama membagi nilai
+// (deal size, amount invoice, skor assessment) ke rentang threshold
+// [thres_from, thres_to). thres_to 0 berarti tanpa batas atas. Rentang satu
+// grup tidak boleh tumpang tindih atau berlubang.
+
+var errNoWorkflowMatch = errors.New("no workflow matches the value")
+
+// thresholdMatches reports whether value falls in the range of w
+func thresholdMatches(w entity.Workflows, value float64) bool {
+	return value >= float64(w.ThresFrom) && (w.ThresTo == 0 || value < float64(w.ThresTo))
+}
+
+func thresholdRange(w entity.Workflows) string {
+	if w.ThresTo == 0 {
+		return fmt.Sprintf("%d and above", w.ThresFrom)
+	}
+	return fmt.Sprintf("%d-%d", w.ThresFrom, w.ThresTo)
+}
+
+// validateThresholds checks the ranges of one type/stage group and returns
+// every overlap and gap. Each range is compared with the range that reaches
+// furthest so far (unbounded reaches furthest), not only with its
+// predecessor, so a range nested in a wider one is reported as an overlap.
+func validateThresholds(group []entity.Workflows) []string {
+	var issues []string
+	sorted := append([]entity.Workflows(nil), group...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ThresFrom < sorted[j].ThresFrom })
+	var reach *entity.Workflows
+	for i, w := range sorted {
+		if w.ThresTo != 0 && w.ThresTo <= w.ThresFrom {
+			issues = append(issues, fmt.Sprintf("%s: thres_to must be greater than thres_from (or 0 for no upper limit)", w.Name))
+			continue
+		}
+		switch {
+		case reach == nil:
+		case reach.ThresTo == 0 || w.ThresFrom < reach.ThresTo:
+			issues = append(issues, fmt.Sprintf("%s (%s) overlaps %s (%s)", w.Name, thresholdRange(w), reach.Name, thresholdRange(*reach)))
+		case w.ThresFrom > reach.ThresTo:
+			issues = append(issues, fmt.Sprintf("gap between %s (%s) and %s (%s): %d-%d is not routed",
+				reach.Name, thresholdRange(*reach), w.Name, thresholdRange(w), reach.ThresTo, w.ThresFrom))
+		}
+		if reach == nil || (reach.ThresTo != 0 && (w.ThresTo == 0 || w.ThresTo > reach.ThresTo)) {
+			reach = &sorted[i]
+		}
+	}
+	return issues
+}
+
+// thresholdGroup loads the active workflows of the type/stage of w, with w
+// itself replaced by its new values
+func thresholdGroup(db *gorm.DB, w entity.Workflows) ([]entity.Workflows, error) {
+	var group []entity.Workflows
+	query := db.Where("type = ? AND stage_id = ? AND is_active = ?", w.Type, w.StageID, true)
+	if w.ID != "" {
+		query = query.Where("id <> ?", w.ID)
+	}
+	if err := query.Find(&group).Error; err != nil {
+		return nil, err
+	}
+	return append(group, w), nil
+}
+
+// checkWorkflowThresholds validates w against the other workflows of its
+// group and responds 400 with the issues when the ranges are invalid
+func checkWorkflowThresholds(c *gin.Context, db *gorm.DB, w entity.Workflows) bool {
+	group, err := thresholdGroup(db, w)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Gagal memeriksa threshold workflow")
+		return false
+	}
+	if issues := validateThresholds(group); len(issues) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "failed",
+			"message": "Rentang threshold tidak valid untuk type " + w.Type,
+			"data":    issues,
+		})
+		return false
+	}
+	return true
+}
+
+// resolveWorkflow selects the active workflow of workflowType whose range
+// contains value. Without stageID every stage is considered and the lowest
+// flow_order wins; candidates lists every match.
+func resolveWorkflow(db *gorm.DB, workflowType, stageID string, value float64) (*entity.Workflows, []entity.Workflows, error) {
+	query := db.Where("type = ? AND is_active = ?", workflowType, true)
+	if stageID != "" {
+		query = query.Where("stage_id = ?", stageID)
+	}
+	var workflows []entity.Workflows
+	if err := query.Order("flow_order, name").Find(&workflows).Error; err != nil {
+		return nil, nil, err
+	}
+	var candidates []entity.Workflows
+	for _, w := range workflows {
+		if thresholdMatches(w, value) {
+			candidates = append(candidates, w)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil, errNoWorkflowMatch
+	}
+	return &candidates[0], candidates, nil
+}
+
+// @Summary Resolve workflow
+// @Description Dry run of the threshold routing: the workflow that would handle a value of the given type
+// @Tags Workflows
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.ResolveWorkflowRequest true "Type and value"
+// @Success 200 {object} dto.ResolveWorkflowResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /api/workflows/resolve [post]
+func ResolveWorkflow(c *gin.Context) {
+	var req dto.ResolveWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	workflow, candidates, err := resolveWorkflow(config.DB, strings.TrimSpace(req.Type), req.StageID, *req.Value)
+	if errors.Is(err, errNoWorkflowMatch) {
+		sendError(c, http.StatusNotFound, fmt.Sprintf("No active %s workflow covers value %g", req.Type, *req.Value))
+		return
+	}
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, "Failed to resolve workflow")
+		return
+	}
+
+	var details []entity.WorkflowsDetail
+	config.DB.Where("workflows_id = ? AND is_active = ?", workflow.ID, true).Order("flow_order, created_at").Find(&details)
+
+	ids := make([]string, 0, len(candidates))
+	for _, w := range candidates {
+		ids = append(ids, w.ID)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Workflow resolved",
+		"data": dto.ResolveWorkflowResponse{
+			WorkflowID:   workflow.ID,
+			Name:         workflow.Name,
+			StageID:      workflow.StageID,
+			ThresFrom:    workflow.ThresFrom,
+			ThresTo:      workflow.ThresTo,
+			Steps:        len(details),
+			CandidateIDs: ids,
+		},
+	})
+}
+
+// @Summary Update workflow thresholds
+// @Description Change the ranges of several workflows at once, e.g. to move the boundary between two neighbours.
+// @Description Every type/stage group touched must be free of overlaps and gaps afterwards.
+// @Description Each entry carries the version (ETag) of the workflow it was read at.
+// @Tags Workflows
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.UpdateThresholdsRequest true "New ranges"
+// @Success 200 {array} entity.Workflows
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Router /api/workflows/thresholds [put]
+func UpdateWorkflowThresholds(c *gin.Context) {
+	var req dto.UpdateThresholdsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Kunci dengan urutan id tetap agar dua update bersamaan tidak deadlock
+	sort.Slice(req.Workflows, func(i, j int) bool { return req.Workflows[i].ID < req.Workflows[j].ID })
+
+	var updated []entity.Workflows
+	var issues []string
+	var conflictID string
+	errInvalid := errors.New("invalid thresholds")
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		groups := make(map[[2]string]bool)
+		for _, r := range req.Workflows {
+			var w entity.Workflows
+			if err := tx.Where("id = ?", r.ID).First(&w).Error; err != nil {
+				return err
+			}
+			if err := lockVersion(tx, &entity.Workflows{}, w.ID, r.Version); err != nil {
+				conflictID = w.ID
+				return err
+			}
+			// Hanya rentang yang diubah, field lain milik editor workflow
+			if err := tx.Model(&w).Updates(map[string]interface{}{"thres_from": r.ThresFrom, "thres_to": r.ThresTo}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id = ?", w.ID).First(&w).Error; err != nil {
+				return err
+			}
+			updated = append(updated, w)
+			groups[[2]string{w.Type, w.StageID}] = true
+		}
+		for key := range groups {
+			var group []entity.Workflows
+			if err := tx.Where("type = ? AND stage_id = ? AND is_active = ?", key[0], key[1], true).Find(&group).Error; err != nil {
+				return err
+			}
+			issues = append(issues, validateThresholds(group)...)
+		}
+		if len(issues) > 0 {
+			return errInvalid
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		sendError(c, http.StatusNotFound, "Workflow not found")
+		return
+	case errors.Is(err, errVersionConflict):
+		sendVersionConflict(c, &entity.Workflows{}, conflictID)
+		return
+	case errors.Is(err, errInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "failed",
+			"message": "Invalid threshold ranges",
+			"data":    issues,
+		})
+		return
+	case err != nil:
+		sendError(c, http.StatusInternalServerError, "Failed to update thresholds")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Workflow thresholds updated",
+		"data":    updated,
+	})
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"customer-api/internal/entity"
+)
+
+func TestValidateThresholds(t *testing.T) {
+	w := func(name string, from, to int) entity.Workflows {
+		return entity.Workflows{Name: name, ThresFrom: from, ThresTo: to}
+	}
+	tests := map[string]struct {
+		group []entity.Workflows
+		want  []string
+	}{
+		"contiguous ranges in any order": {
+			group: []entity.Workflows{w("Large", 500, 0), w("Small", 0, 100), w("Medium", 100, 500)},
+		},
+		"single unbounded range": {
+			group: []entity.Workflows{w("All", 0, 0)},
+		},
+		"overlap": {
+			group: []entity.Workflows{w("Small", 0, 150), w("Large", 100, 0)},
+			want:  []string{"Large (100 and above) overlaps Small (0-150)"},
+		},
+		"range after an unbounded one": {
+			group: []entity.Workflows{w("All", 0, 0), w("Medium", 100, 500)},
+			want:  []string{"Medium (100-500) overlaps All (0 and above)"},
+		},
+		"ranges nested in a wider one": {
+			group: []entity.Workflows{w("Wide", 0, 100), w("Low", 10, 20), w("Mid", 50, 60)},
+			want: []string{
+				"Low (10-20) overlaps Wide (0-100)",
+				"Mid (50-60) overlaps Wide (0-100)",
+			},
+		},
+		"ranges nested in an unbounded one": {
+			group: []entity.Workflows{w("Mid", 50, 60), w("All", 0, 0), w("Low", 10, 20)},
+			want: []string{
+				"Low (10-20) overlaps All (0 and above)",
+				"Mid (50-60) overlaps All (0 and above)",
+			},
+		},
+		"gap after a nested range": {
+			group: []entity.Workflows{w("Wide", 0, 100), w("Low", 10, 20), w("Large", 150, 0)},
+			want: []string{
+				"Low (10-20) overlaps Wide (0-100)",
+				"gap between Wide (0-100) and Large (150 and above): 100-150 is not routed",
+			},
+		},
+		"gap": {
+			group: []entity.Workflows{w("Small", 0, 100), w("Large", 120, 0)},
+			want:  []string{"gap between Small (0-100) and Large (120 and above): 100-120 is not routed"},
+		},
+		"empty range": {
+			group: []entity.Workflows{w("Small", 0, 100), w("Broken", 100, 100)},
+			want:  []string{"Broken: thres_to must be greater than thres_from (or 0 for no upper limit)"},
+		},
+	}
+	for name, tt := range tests {
+		if got := validateThresholds(tt.group); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", name, got, tt.want)
+		}
+	}
+}
+
+func TestThresholdMatchesIsHalfOpen(t *testing.T) {
+	small := entity.Workflows{ThresFrom: 0, ThresTo: 100}
+	large := entity.Workflows{ThresFrom: 100}
+	for _, value := range []float64{0, 50, 99.99, 100, 1e9} {
+		if thresholdMatches(small, value) == thresholdMatches(large, value) {
+			t.Errorf("value %v is routed to both or neither of [0,100) and [100,∞)", value)
+		}
+	}
+	if thresholdMatches(small, -1) {
+		t.Errorf("-1 matches [0,100)")
+	}
+}
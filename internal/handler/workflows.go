@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"time"
 	"gorm.io/gorm"
 	"customer-api/internal/config"
 	"customer-api/internal/entity"
+	"customer-api/internal/query"
 	"github.com/gin-gonic/gin"
 	
 )
@@ -44,6 +46,9 @@ type WorkflowsDetail struct {
 	Sla     int    `json:"sla"`
 	Uom     string `json:"uom"`
 	IsActive bool   `json:"is_active"`
+	FlowOrder *int  `json:"flow_order"` // urutan level approval, default setelah step terakhir
+	ApproverRoleID string `json:"approver_role_id"`
+	ApproverTeamID string `json:"approver_team_id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -80,7 +85,10 @@ func CreateWorkflows(c *gin.Context) {
 		ThresTo: input.ThresTo,
 		Type: input.Type,
 	}
-	if result := config.DB.Create(&workflow); result.Error != nil {
+	if !checkWorkflowThresholds(c, config.DB, workflow) {
+		return
+	}
+	if result := config.DB.WithContext(c).Create(&workflow); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal membuat workflow",
@@ -97,21 +105,44 @@ func CreateWorkflows(c *gin.Context) {
 }
 
 
+var workflowListSpec = query.Spec{
+	Table: "workflows",
+	Fields: map[string]query.Field{
+		"name":       {},
+		"stage_id":   {},
+		"type":       {},
+		"flow_order": {Type: query.Number},
+		"thres_from": {Type: query.Number},
+		"thres_to":   {Type: query.Number},
+		"is_active":  {Type: query.Bool},
+		"created_at": {Type: query.Time},
+	},
+	DefaultSort:     "flow_order",
+	DefaultPageSize: 100,
+}
+
 func GetWorkflows(c *gin.Context) {
+	params, ok := parseList(c, workflowListSpec)
+	if !ok {
+		return
+	}
+
 	var workflows []entity.Workflows
-	if result := config.DB.Find(&workflows); result.Error != nil {
+	page, err := workflowListSpec.Find(config.DB, params, &workflows)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal mendapatkan workflows",
-			"data":    result.Error.Error(),
+			"data":    err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Berhasil mendapatkan workflows",
-		"data":    workflows,
+		"status":     "success",
+		"message":    "Berhasil mendapatkan workflows",
+		"data":       workflows,
+		"pagination": page,
 	})
 }
 
@@ -129,6 +160,7 @@ func GetWorkflow(c *gin.Context) {
 		return
 	}
 
+	setETag(c, &workflow)
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Berhasil mendapatkan workflow",
@@ -149,6 +181,9 @@ func UpdateWorkflow(c *gin.Context) {
 		})
 		return
 	}
+	if !checkIfMatch(c, &workflow) {
+		return
+	}
 
 	var input Workflows
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -177,16 +212,30 @@ func UpdateWorkflow(c *gin.Context) {
 	workflow.ThresFrom = input.ThresFrom
 	workflow.ThresTo = input.ThresTo
 	workflow.Type = input.Type
+	if !checkWorkflowThresholds(c, config.DB, workflow) {
+		return
+	}
 
-	if result := config.DB.Save(&workflow); result.Error != nil {
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := lockVersion(tx, &entity.Workflows{}, workflow.ID, workflow.Version); err != nil {
+			return err
+		}
+		return tx.Save(&workflow).Error
+	})
+	if errors.Is(err, errVersionConflict) {
+		sendVersionConflict(c, &entity.Workflows{}, id)
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal memperbarui workflow",
-			"data":    result.Error.Error(),
+			"data":    err.Error(),
 		})
 		return
 	}
 
+	setETag(c, &workflow)
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Workflow berhasil diperbarui",
@@ -207,12 +256,25 @@ func DeleteWorkflow(c *gin.Context) {
 		})
 		return
 	}
+	if !checkIfMatch(c, &workflow) {
+		return
+	}
 
-	if result := config.DB.Delete(&workflow); result.Error != nil {
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := lockVersion(tx, &entity.Workflows{}, workflow.ID, workflow.Version); err != nil {
+			return err
+		}
+		return tx.Delete(&workflow).Error
+	})
+	if errors.Is(err, errVersionConflict) {
+		sendVersionConflict(c, &entity.Workflows{}, id)
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal menghapus workflow",
-			"data":    result.Error.Error(),
+			"data":    err.Error(),
 		})
 		return
 	}
@@ -233,6 +295,17 @@ func CreateWorkflowDetail(c *gin.Context) {
 		})
 		return
 	}
+	input.WorkflowsID = c.Param("id")
+
+	var workflow entity.Workflows
+	if result := config.DB.Where("id = ?", input.WorkflowsID).First(&workflow); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "failed",
+			"message": "Workflow tidak ditemukan",
+			"data": nil,
+		})
+		return
+	}
 
 	// Check if workflow detail name already exists
 	var existingDetail entity.WorkflowsDetail
@@ -244,14 +317,30 @@ func CreateWorkflowDetail(c *gin.Context) {
 		})
 		return
 	}
+	if msg := validateApprover(config.DB, workflow.Type, input.ApproverRoleID, input.ApproverTeamID); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "failed",
+			"message": msg,
+			"data": nil,
+		})
+		return
+	}
 	detail := entity.WorkflowsDetail{
 		WorkflowsID: input.WorkflowsID,
 		Name: input.Name,
 		Sla: input.Sla,
 		Uom: input.Uom,
 		IsActive: input.IsActive,
+		ApproverRoleID: input.ApproverRoleID,
+		ApproverTeamID: input.ApproverTeamID,
+	}
+	if input.FlowOrder != nil {
+		detail.FlowOrder = *input.FlowOrder
+	} else {
+		config.DB.Model(&entity.WorkflowsDetail{}).Where("workflows_id = ?", input.WorkflowsID).
+			Select("COALESCE(MAX(flow_order), 0) + 1").Row().Scan(&detail.FlowOrder)
 	}
-	if result := config.DB.Create(&detail); result.Error != nil {
+	if result := config.DB.WithContext(c).Create(&detail); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal membuat workflow detail",
@@ -269,7 +358,7 @@ func CreateWorkflowDetail(c *gin.Context) {
 
 func GetWorkflowDetails(c *gin.Context) {
 	var details []entity.WorkflowsDetail
-	if result := config.DB.Find(&details); result.Error != nil {
+	if result := config.DB.Where("workflows_id = ?", c.Param("id")).Order("flow_order, created_at").Find(&details); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal mendapatkan workflow details",
@@ -286,11 +375,10 @@ func GetWorkflowDetails(c *gin.Context) {
 }
 
 func GetWorkflowDetail(c *gin.Context) {
-	idParam := c.Param("id")
-	id := idParam
+	id := c.Param("detail_id")
 
 	var detail entity.WorkflowsDetail
-	if result := config.DB.Where("id = ?", id).First(&detail); result.Error != nil {
+	if result := config.DB.Where("id = ? AND workflows_id = ?", id, c.Param("id")).First(&detail); result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"status":  "failed",
 			"message": "Workflow detail tidak ditemukan",
@@ -307,11 +395,10 @@ func GetWorkflowDetail(c *gin.Context) {
 }
 
 func UpdateWorkflowDetail(c *gin.Context) {
-	idParam := c.Param("id")
-	id := idParam
+	id := c.Param("detail_id")
 
 	var detail entity.WorkflowsDetail
-	if result := config.DB.Where("id = ?", id).First(&detail); result.Error != nil {
+	if result := config.DB.Where("id = ? AND workflows_id = ?", id, c.Param("id")).First(&detail); result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"status":  "failed",
 			"message": "Workflow detail tidak ditemukan",
@@ -340,14 +427,28 @@ func UpdateWorkflowDetail(c *gin.Context) {
 		})
 		return
 	}
+	var workflow entity.Workflows
+	config.DB.Select("id", "type").Where("id = ?", detail.WorkflowsID).First(&workflow)
+	if msg := validateApprover(config.DB, workflow.Type, input.ApproverRoleID, input.ApproverTeamID); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "failed",
+			"message": msg,
+			"data": nil,
+		})
+		return
+	}
 
-	detail.WorkflowsID = input.WorkflowsID
 	detail.Name = input.Name
 	detail.Sla = input.Sla
 	detail.Uom = input.Uom
 	detail.IsActive = input.IsActive
+	detail.ApproverRoleID = input.ApproverRoleID
+	detail.ApproverTeamID = input.ApproverTeamID
+	if input.FlowOrder != nil {
+		detail.FlowOrder = *input.FlowOrder
+	}
 
-	if result := config.DB.Save(&detail); result.Error != nil {
+	if result := config.DB.WithContext(c).Save(&detail); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal memperbarui workflow detail",
@@ -364,11 +465,10 @@ func UpdateWorkflowDetail(c *gin.Context) {
 }
 
 func DeleteWorkflowDetail(c *gin.Context) {
-	idParam := c.Param("id")
-	id := idParam
+	id := c.Param("detail_id")
 
 	var detail entity.WorkflowsDetail
-	if result := config.DB.Where("id = ?", id).First(&detail); result.Error != nil {
+	if result := config.DB.Where("id = ? AND workflows_id = ?", id, c.Param("id")).First(&detail); result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"status":  "failed",
 			"message": "Workflow detail tidak ditemukan",
@@ -377,7 +477,7 @@ func DeleteWorkflowDetail(c *gin.Context) {
 		return
 	}
 
-	if result := config.DB.Delete(&detail); result.Error != nil {
+	if result := config.DB.WithContext(c).Delete(&detail); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "failed",
 			"message": "Gagal menghapus workflow detail",
@@ -0,0 +1,569 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Skema awal: semua tabel yang sebelumnya dibuat oleh AutoMigrate saat boot.
+// Aman dijalankan pada database lama karena AutoMigrate tidak menghapus data.
+//
+// Model di bawah adalah salinan beku entity pada saat migrasi ini dibuat,
+// sehingga perubahan entity berikutnya tidak ikut mengubah 0001 (kolom baru
+// ditambahkan oleh migrasi setelahnya). Relasi tidak ikut disalin: tipe id
+// baseline (mis. customer_id uint) tidak cocok dengan customers.id, jadi
+// foreign key-nya memang tidak bisa dibuat. Tabel many2many dibuat eksplisit.
+// Migrasi ini tidak bisa di-rollback.
+func init() {
+	register(Migration{
+		Version: "0001",
+		Name:    "initial_schema",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(initialSchema()...)
+		},
+		// Tanpa Down: rollback skema awal berarti menghapus semua data
+	})
+}
+
+func initialSchema() []interface{} {
+	return []interface{}{
+		&baselineActivityAttendee{},
+		&baselineActivityCheckin{},
+		&baselineActivityType{},
+		&baselineAddress{},
+		&baselineContact{},
+		&baselineCustomer{},
+		&baselineDocument{},
+		&baselineHistoryCustomer{},
+		&baselineEvent{},
+		&baselineEventAttendee{},
+		&baselineGroup{},
+		&baselineInvoice{},
+		&baselineOther{},
+		&baselinePayment{},
+		&baselineProject{},
+		&baselineRole{},
+		&baselineSosmed{},
+		&baselineStatus{},
+		&baselineStatusReasons{},
+		&baselineStructure{},
+		&baselineUser{},
+		&baselineStages{},
+		&baselineStagesDetail{},
+		&baselineWorkflows{},
+		&baselineWorkflowsDetail{},
+		&baselineGroupConfig{},
+		&baselineGroupConfigDetail{},
+		&baselineActivity{},
+		&baselinePermission{},
+		&baselineRefreshToken{},
+		&baselineRevokedToken{},
+		&baselineCustomerGroup{},
+		&baselineRolePermission{},
+	}
+}
+
+// Tabel many2many Customer.Groups dan Role.Permissions
+type baselineCustomerGroup struct {
+	CustomerID string `gorm:"primaryKey;size:26"`
+	GroupID    string `gorm:"primaryKey;size:26"`
+}
+
+func (baselineCustomerGroup) TableName() string {
+	return "customer_groups"
+}
+
+type baselineRolePermission struct {
+	RoleID       string `gorm:"primaryKey;type:char(36)"`
+	PermissionID string `gorm:"primaryKey;size:26"`
+}
+
+func (baselineRolePermission) TableName() string {
+	return "role_permissions"
+}
+
+type baselineActivityAttendee struct {
+	ID         string    `json:"id" gorm:"type:char(36);primary_key"`
+	ActivityID uint      `json:"activity_id" gorm:"primaryKey;not null"`
+	UserID     uint      `json:"user_id" gorm:"primaryKey;not null"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (baselineActivityAttendee) TableName() string {
+	return "activity_attendees"
+}
+
+type baselineActivityCheckin struct {
+	ID          string         `json:"id" gorm:"primaryKey;size:26"`
+	ActivityID  uint           `json:"activity_id" gorm:"not null"`
+	UserID      uint           `json:"user_id" gorm:"not null"`
+	CheckedInAt time.Time      `json:"checked_in_at" gorm:"not null"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineActivityCheckin) TableName() string {
+	return "activity_checkins"
+}
+
+type baselineActivityType struct {
+	ID   string `json:"id" gorm:"type:char(36);primary_key"`
+	Name string `json:"name" gorm:"not null"`
+}
+
+func (baselineActivityType) TableName() string {
+	return "activity_types"
+}
+
+type baselineAddress struct {
+	ID         string         `json:"id" gorm:"primaryKey;size:26"`
+	CustomerID string         `json:"customer_id" gorm:"not null"`
+	Name       string         `json:"name" gorm:"not null"`
+	Street     string         `json:"street"`
+	Address    string         `json:"address" gorm:"not null"`
+	City       string         `json:"city"`
+	State      string         `json:"state"`
+	Country    string         `json:"country"`
+	PostalCode string         `json:"postal_code"`
+	Main       bool           `json:"main" gorm:"default:false"`
+	Active     bool           `json:"active" gorm:"default:true"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineAddress) TableName() string {
+	return "addresses"
+}
+
+type baselineContact struct {
+	ID          string         `json:"id" gorm:"primaryKey;size:26"`
+	CustomerID  string         `json:"customer_id" gorm:"not null"`
+	Name        string         `json:"name" gorm:"not null"`
+	Birthdate   *time.Time     `json:"birthdate"`
+	JobPosition string         `json:"job_position"`
+	Position    string         `json:"position"`
+	Email       string         `json:"email"`
+	Phone       string         `json:"phone"`
+	Mobile      string         `json:"mobile"`
+	Department  string         `json:"department"`
+	Main        bool           `json:"main" gorm:"default:false"`
+	Active      bool           `json:"active" gorm:"default:true"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineContact) TableName() string {
+	return "contacts"
+}
+
+type baselineCustomer struct {
+	ID               string         `json:"id" gorm:"primaryKey;size:26"`
+	Name             string         `json:"name" gorm:"not null"`
+	BrandName        string         `json:"brand_name"`
+	Code             string         `json:"code" gorm:"unique"`
+	AccountManagerId string         `json:"account_manager_id"`
+	Email            string         `json:"email"`
+	Phone            string         `json:"phone"`
+	Website          string         `json:"website"`
+	Description      string         `json:"description"`
+	Logo             string         `json:"logo"`
+	Status           string         `json:"status" gorm:"default:'Active'"`
+	Category         string         `json:"category"`
+	Rating           float64        `json:"rating" gorm:"default:0"`
+	AverageCost      float64        `json:"average_cost" gorm:"default:0"`
+	LogoSmall        string         `json:"logo_small"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineCustomer) TableName() string {
+	return "customers"
+}
+
+type baselineDocument struct {
+	ID         string         `json:"id" gorm:"primaryKey;size:26"`
+	CustomerID string         `json:"customer_id" gorm:"not null"`
+	Notes      string         `json:"notes" gorm:"not null"`
+	Type       string         `json:"type" gorm:"not null"`
+	URLFile    string         `json:"url_file" gorm:"not null"`
+	UserID     string         `json:"user_id" gorm:"not null"`
+	IsActive   bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineDocument) TableName() string {
+	return "documents"
+}
+
+type baselineHistoryCustomer struct {
+	ID         string    `json:"id" gorm:"primaryKey;size:26"`
+	CustomerID string    `json:"customer_id" gorm:"not null"`
+	UserID     string    `json:"user_id" gorm:"not null"`
+	Status     string    `json:"status" gorm:"default:'Active'"`
+	Notes      string    `json:"notes"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (baselineHistoryCustomer) TableName() string {
+	return "history_customers"
+}
+
+type baselineEvent struct {
+	ID             string         `json:"id" gorm:"primaryKey;size:26"`
+	ActivityTypeId uint           `json:"activity_type_id" gorm:"not null"`
+	ScheduledAt    time.Time      `json:"scheduled_at" gorm:"not null"`
+	ScheduledTime  time.Time      `json:"scheduled_time" gorm:"not null"`
+	CustomerID     uint           `json:"customer_id" gorm:"not null"`
+	ProjectID      uint           `json:"project_id" gorm:"not null"`
+	Location       string         `json:"location"`
+	Agenda         string         `json:"agenda"`
+	Status         string         `json:"status" gorm:"default:'upcoming'"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+	IsActive       bool           `json:"is_active" gorm:"default:true"`
+}
+
+func (baselineEvent) TableName() string {
+	return "events"
+}
+
+type baselineEventAttendee struct {
+	EventID   uint      `json:"event_id" gorm:"primaryKey;not null"`
+	UserID    uint      `json:"user_id" gorm:"primaryKey;not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (baselineEventAttendee) TableName() string {
+	return "event_attendees"
+}
+
+type baselineGroup struct {
+	ID        string         `json:"id" gorm:"primaryKey;size:26"`
+	NameGroup string         `json:"name_group" gorm:"not null"`
+	Value     string         `json:"value"`
+	Active    bool           `json:"active" gorm:"default:true"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineGroup) TableName() string {
+	return "groups"
+}
+
+type baselineInvoice struct {
+	ID            string         `json:"id" gorm:"type:char(26);primary_key"`
+	CustomerID    uint           `json:"customer_id" gorm:"not null"`
+	ProjectID     string         `json:"project_id"`
+	InvoiceNumber string         `json:"invoice_number" gorm:"unique;not null"`
+	Amount        float64        `json:"amount" gorm:"not null"`
+	IssuedDate    time.Time      `json:"issued_date" gorm:"not null"`
+	DueDate       time.Time      `json:"due_date" gorm:"not null"`
+	PaidAmount    float64        `json:"paid_amount" gorm:"default:0"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineInvoice) TableName() string {
+	return "invoices"
+}
+
+type baselineOther struct {
+	ID         string         `json:"id" gorm:"type:char(36);primary_key"`
+	CustomerID string         `json:"customer_id" gorm:"not null"`
+	Key        string         `json:"key" gorm:"not null"`
+	Value      *string        `json:"value"`
+	Active     bool           `json:"active" gorm:"default:true"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineOther) TableName() string {
+	return "others"
+}
+
+type baselinePayment struct {
+	ID        string         `json:"id" gorm:"type:char(26);primary_key"`
+	InvoiceID uint           `json:"invoice_id" gorm:"not null"`
+	Amount    float64        `json:"amount" gorm:"not null"`
+	PaidAt    time.Time      `json:"paid_at" gorm:"not null"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselinePayment) TableName() string {
+	return "payments"
+}
+
+type baselineProject struct {
+	ID          string         `json:"id" gorm:"type:char(26);primary_key"`
+	Name        string         `json:"name" gorm:"not null"`
+	Description string         `json:"description"`
+	IsActive    bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineProject) TableName() string {
+	return "projects"
+}
+
+type baselineRole struct {
+	ID        string         `json:"id" gorm:"type:char(36);primary_key"`
+	RoleName  string         `json:"role_name" gorm:"unique;not null"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineRole) TableName() string {
+	return "roles"
+}
+
+type baselineSosmed struct {
+	ID         string         `json:"id" gorm:"type:char(26);primary_key"`
+	CustomerID string         `json:"customer_id" gorm:"not null"`
+	Name       string         `json:"name" gorm:"not null"`
+	Platform   string         `json:"platform" gorm:"not null"`
+	Handle     string         `json:"handle" gorm:"not null"`
+	Username   string         `json:"username"`
+	URL        string         `json:"url"`
+	Followers  int            `json:"followers" gorm:"default:0"`
+	Active     bool           `json:"active" gorm:"default:true"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineSosmed) TableName() string {
+	return "sosmeds"
+}
+
+type baselineStatus struct {
+	ID         string         `json:"id" gorm:"primaryKey;size:26"`
+	StatusName string         `json:"status_name" gorm:"not null"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+func (baselineStatus) TableName() string {
+	return "statuses"
+}
+
+type baselineStatusReasons struct {
+	ID         string         `json:"id" gorm:"primaryKey;size:26"`
+	CustomerID string         `json:"customer_id" gorm:"not null"`
+	Reason     string         `json:"reason" gorm:"not null"`
+	Status     string         `json:"status" gorm:"type:varchar(20);check:status IN ('active','blocked');not null"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	IsActive   bool           `json:"is_active" gorm:"default:true"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineStatusReasons) TableName() string {
+	return "status_reasons"
+}
+
+type baselineStructure struct {
+	ID         string         `json:"id" gorm:"primaryKey;size:26"`
+	CustomerID string         `json:"customer_id" gorm:"not null"`
+	Name       string         `json:"name" gorm:"not null"`
+	Level      int            `json:"level" gorm:"not null"`
+	ParentID   *string        `json:"parent_id"`
+	Address    string         `json:"address"`
+	Position   int            `json:"position" gorm:"default:0"`
+	Active     bool           `json:"active" gorm:"default:true"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineStructure) TableName() string {
+	return "structures"
+}
+
+type baselineUser struct {
+	ID               string         `json:"id" gorm:"primaryKey;size:26"`
+	Username         string         `json:"username" gorm:"unique;not null"`
+	Email            string         `json:"email" gorm:"unique;not null"`
+	Password         string         `json:"-" gorm:"not null"`
+	RoleID           string         `json:"role_id" gorm:"default:2"`
+	TokensValidAfter *time.Time     `json:"-"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineUser) TableName() string {
+	return "users"
+}
+
+type baselineStages struct {
+	ID        string         `json:"id" gorm:"primaryKey;size:26"`
+	Name      string         `json:"name" gorm:"not null;unique"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	IsActive  bool           `json:"is_active" gorm:"default:true"`
+}
+
+func (baselineStages) TableName() string {
+	return "stages"
+}
+
+type baselineStagesDetail struct {
+	ID        string         `json:"id" gorm:"primaryKey;size:26"`
+	StageID   string         `json:"stage_id" gorm:"not null"`
+	Name      string         `json:"name" gorm:"not null;unique"`
+	Sla       int            `json:"sla" gorm:"not null"`
+	Uom       string         `json:"uom" gorm:"not null"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	IsActive  bool           `json:"is_active" gorm:"default:true"`
+}
+
+func (baselineStagesDetail) TableName() string {
+	return "stages_details"
+}
+
+type baselineWorkflows struct {
+	ID        string         `json:"id" gorm:"primaryKey;size:26"`
+	Name      string         `json:"name" gorm:"not null;unique"`
+	StageID   string         `json:"stage_id" gorm:"not null"`
+	FlowOrder int            `json:"flow_order" gorm:"not null"`
+	ThresFrom int            `json:"thres_from" gorm:"not null"`
+	ThresTo   int            `json:"thres_to" gorm:"not null"`
+	Type      string         `json:"type" gorm:"not null"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	IsActive  bool           `json:"is_active" gorm:"default:true"`
+}
+
+func (baselineWorkflows) TableName() string {
+	return "workflows"
+}
+
+type baselineWorkflowsDetail struct {
+	ID          string         `json:"id" gorm:"primaryKey;size:26"`
+	WorkflowsID string         `json:"workflows_id" gorm:"not null"`
+	Name        string         `json:"name" gorm:"not null;unique"`
+	Sla         int            `json:"sla" gorm:"not null"`
+	Uom         string         `json:"uom" gorm:"not null"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	IsActive    bool           `json:"is_active" gorm:"default:true"`
+}
+
+func (baselineWorkflowsDetail) TableName() string {
+	return "workflows_details"
+}
+
+type baselineGroupConfig struct {
+	ID        string         `json:"id" gorm:"primaryKey;size:26"`
+	Name      string         `json:"name" gorm:"not null;unique"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	IsActive  bool           `json:"is_active" gorm:"default:true"`
+	IsDeleted bool           `json:"is_deleted" gorm:"default:false"`
+}
+
+func (baselineGroupConfig) TableName() string {
+	return "group_configs"
+}
+
+type baselineGroupConfigDetail struct {
+	ID            string         `json:"id" gorm:"primaryKey;size:26"`
+	GroupConfigID string         `json:"group_config_id" gorm:"not null"`
+	Name          string         `json:"name" gorm:"not null;unique"`
+	IsActive      bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineGroupConfigDetail) TableName() string {
+	return "group_config_details"
+}
+
+type baselineActivity struct {
+	ID           string         `json:"id" gorm:"primaryKey;size:26"`
+	CustomerID   uint           `json:"customer_id" gorm:"not null"`
+	Title        string         `json:"title" gorm:"not null"`
+	Type         string         `json:"type" gorm:"not null"`
+	Agenda       string         `json:"agenda"`
+	StartTime    time.Time      `json:"start_time" gorm:"not null"`
+	EndTime      time.Time      `json:"end_time" gorm:"not null"`
+	LocationName string         `json:"location_name"`
+	Status       string         `json:"status" gorm:"default:'Scheduled'"`
+	CreatedBy    uint           `json:"created_by" gorm:"not null"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselineActivity) TableName() string {
+	return "activities"
+}
+
+type baselinePermission struct {
+	ID          string         `json:"id" gorm:"primaryKey;size:26"`
+	Name        string         `json:"name" gorm:"not null;unique"`
+	Description string         `json:"description"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (baselinePermission) TableName() string {
+	return "permissions"
+}
+
+type baselineRefreshToken struct {
+	ID           string     `json:"id" gorm:"primaryKey;size:26"`
+	UserID       string     `json:"user_id" gorm:"not null;index"`
+	TokenHash    string     `json:"-" gorm:"not null;unique"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ReplacedByID string     `json:"replaced_by_id"`
+	UserAgent    string     `json:"user_agent"`
+	IP           string     `json:"ip"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (baselineRefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+type baselineRevokedToken struct {
+	ID        string    `json:"id" gorm:"primaryKey;size:26"`
+	JTI       string    `json:"jti" gorm:"not null;unique"`
+	UserID    string    `json:"user_id" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (baselineRevokedToken) TableName() string {
+	return "revoked_tokens"
+}
@@ -0,0 +1,63 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Invoice.CustomerID sebelumnya uint padahal Customer.ID adalah ULID string,
+// plus tabel counter untuk penomoran invoice per tahun.
+func init() {
+	register(Migration{
+		Version: "0002",
+		Name:    "invoices",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec(`ALTER TABLE invoices ALTER COLUMN customer_id TYPE varchar(26) USING customer_id::text`).Error; err != nil {
+				return err
+			}
+			if err := addColumns(tx, &invoice0002{}, "Notes"); err != nil {
+				return err
+			}
+			if !tx.Migrator().HasIndex(&invoice0002{}, "CustomerID") {
+				if err := tx.Migrator().CreateIndex(&invoice0002{}, "CustomerID"); err != nil {
+					return err
+				}
+			}
+			return tx.AutoMigrate(&invoiceSequence0002{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&invoiceSequence0002{}); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropIndex(&invoice0002{}, "CustomerID"); err != nil {
+				return err
+			}
+			if err := dropColumns(tx, &invoice0002{}, "Notes"); err != nil {
+				return err
+			}
+			// Gagal jika sudah ada invoice yang menunjuk ke customer ULID
+			return tx.Exec(`ALTER TABLE invoices ALTER COLUMN customer_id TYPE bigint USING customer_id::bigint`).Error
+		},
+	})
+}
+
+// Kolom invoices yang disentuh migrasi ini, salinan beku entity.Invoice
+type invoice0002 struct {
+	CustomerID string `gorm:"size:26;not null;index"`
+	Notes      string
+}
+
+func (invoice0002) TableName() string {
+	return "invoices"
+}
+
+type invoiceSequence0002 struct {
+	Year       int `gorm:"primaryKey;autoIncrement:false"`
+	LastNumber int `gorm:"not null;default:0"`
+	UpdatedAt  time.Time
+}
+
+func (invoiceSequence0002) TableName() string {
+	return "invoice_sequences"
+}
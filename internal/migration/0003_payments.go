@@ -0,0 +1,65 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var paymentColumns = []string{
+	"CustomerID", "AppliedAmount", "CreditAmount", "Method", "Reference",
+	"Notes", "CreatedBy", "ReversedAt", "ReversedBy", "ReversalReason",
+}
+
+// Payment.InvoiceID sebelumnya uint padahal Invoice.ID adalah ULID string,
+// plus kolom untuk rekonsiliasi, kredit customer dan reversal.
+func init() {
+	register(Migration{
+		Version: "0003",
+		Name:    "payments",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec(`ALTER TABLE payments ALTER COLUMN invoice_id TYPE varchar(26) USING invoice_id::text`).Error; err != nil {
+				return err
+			}
+			if err := addColumns(tx, &payment0003{}, paymentColumns...); err != nil {
+				return err
+			}
+			for _, field := range []string{"InvoiceID", "CustomerID"} {
+				if !tx.Migrator().HasIndex(&payment0003{}, field) {
+					if err := tx.Migrator().CreateIndex(&payment0003{}, field); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := dropColumns(tx, &payment0003{}, paymentColumns...); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropIndex(&payment0003{}, "InvoiceID"); err != nil {
+				return err
+			}
+			return tx.Exec(`ALTER TABLE payments ALTER COLUMN invoice_id TYPE bigint USING invoice_id::bigint`).Error
+		},
+	})
+}
+
+// Kolom payments yang disentuh migrasi ini, salinan beku entity.Payment
+type payment0003 struct {
+	InvoiceID      string  `gorm:"size:26;not null;index"`
+	CustomerID     string  `gorm:"size:26;not null;index"`
+	AppliedAmount  float64 `gorm:"not null;default:0"`
+	CreditAmount   float64 `gorm:"not null;default:0"`
+	Method         string
+	Reference      string
+	Notes          string
+	CreatedBy      string
+	ReversedAt     *time.Time
+	ReversedBy     string
+	ReversalReason string
+}
+
+func (payment0003) TableName() string {
+	return "payments"
+}
@@ -0,0 +1,79 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var invoicePrintColumns = []string{"TaxRate", "TaxAmount", "TemplateID"}
+
+// Item, pajak dan template cetak untuk PDF invoice
+func init() {
+	register(Migration{
+		Version: "0004",
+		Name:    "invoice_items_templates",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumns(tx, &invoice0004{}, invoicePrintColumns...); err != nil {
+				return err
+			}
+			return tx.AutoMigrate(&invoiceItem0004{}, &invoiceTemplate0004{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&invoiceItem0004{}, &invoiceTemplate0004{}); err != nil {
+				return err
+			}
+			return dropColumns(tx, &invoice0004{}, invoicePrintColumns...)
+		},
+	})
+}
+
+// Kolom invoices yang ditambahkan migrasi ini, salinan beku entity.Invoice
+type invoice0004 struct {
+	TaxRate    float64 `gorm:"default:0"`
+	TaxAmount  float64 `gorm:"default:0"`
+	TemplateID *string `gorm:"size:26"`
+}
+
+func (invoice0004) TableName() string {
+	return "invoices"
+}
+
+type invoiceItem0004 struct {
+	ID          string  `gorm:"primaryKey;size:26"`
+	InvoiceID   string  `gorm:"size:26;not null;index"`
+	Description string  `gorm:"not null"`
+	Quantity    float64 `gorm:"not null;default:1"`
+	UnitPrice   float64 `gorm:"not null;default:0"`
+	Amount      float64 `gorm:"not null;default:0"`
+	Position    int     `gorm:"default:0"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (invoiceItem0004) TableName() string {
+	return "invoice_items"
+}
+
+type invoiceTemplate0004 struct {
+	ID                  string `gorm:"primaryKey;size:26"`
+	Name                string `gorm:"not null;unique"`
+	CompanyName         string `gorm:"not null"`
+	CompanyAddress      string
+	CompanyPhone        string
+	CompanyEmail        string
+	CompanyTaxID        string
+	LogoPath            string
+	PrimaryColor        string `gorm:"default:'#1F4E79'"`
+	PaymentInstructions string
+	FooterText          string
+	IsDefault           bool `gorm:"default:false"`
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	DeletedAt           gorm.DeletedAt `gorm:"index"`
+}
+
+func (invoiceTemplate0004) TableName() string {
+	return "invoice_templates"
+}
@@ -0,0 +1,102 @@
+package migration
+
+import (
+	"gorm.io/gorm"
+)
+
+// Index full-text + trigram untuk pencarian customer. Dokumen per customer
+// (nama, brand, kode, kontak, alamat) dijaga oleh trigger sehingga selalu
+// sinkron walaupun data diubah di luar aplikasi.
+func init() {
+	register(Migration{
+		Version: "0005",
+		Name:    "customer_search",
+		Up: func(tx *gorm.DB) error {
+			statements := []string{
+				`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+				`CREATE TABLE IF NOT EXISTS customer_search_index (
+					customer_id varchar(26) PRIMARY KEY,
+					document text NOT NULL DEFAULT '',
+					search_vector tsvector NOT NULL,
+					updated_at timestamptz NOT NULL DEFAULT NOW()
+				)`,
+				`CREATE INDEX IF NOT EXISTS idx_customer_search_vector ON customer_search_index USING gin (search_vector)`,
+				`CREATE INDEX IF NOT EXISTS idx_customer_search_trgm ON customer_search_index USING gin (document gin_trgm_ops)`,
+				// Nama, brand dan kode berbobot A, kontak B, alamat C
+				`CREATE OR REPLACE FUNCTION refresh_customer_search(cid varchar) RETURNS void AS $$
+				BEGIN
+					DELETE FROM customer_search_index WHERE customer_id = cid;
+					INSERT INTO customer_search_index (customer_id, document, search_vector, updated_at)
+					SELECT c.id,
+						concat_ws(' ', c.name, c.brand_name, c.code, ct.body, ad.body),
+						setweight(to_tsvector('simple', concat_ws(' ', c.name, c.brand_name, c.code)), 'A') ||
+						setweight(to_tsvector('simple', coalesce(ct.body, '')), 'B') ||
+						setweight(to_tsvector('simple', coalesce(ad.body, '')), 'C'),
+						NOW()
+					FROM customers c
+					LEFT JOIN LATERAL (
+						SELECT string_agg(concat_ws(' ', name, email, phone, mobile), ' ') AS body
+						FROM contacts WHERE customer_id = c.id AND deleted_at IS NULL
+					) ct ON true
+					LEFT JOIN LATERAL (
+						SELECT string_agg(concat_ws(' ', city, address), ' ') AS body
+						FROM addresses WHERE customer_id = c.id AND deleted_at IS NULL
+					) ad ON true
+					WHERE c.id = cid AND c.deleted_at IS NULL;
+				END
+				$$ LANGUAGE plpgsql`,
+				`CREATE OR REPLACE FUNCTION customer_search_customer_trigger() RETURNS trigger AS $$
+				BEGIN
+					PERFORM refresh_customer_search(CASE WHEN TG_OP = 'DELETE' THEN OLD.id ELSE NEW.id END);
+					RETURN NULL;
+				END
+				$$ LANGUAGE plpgsql`,
+				`CREATE OR REPLACE FUNCTION customer_search_child_trigger() RETURNS trigger AS $$
+				BEGIN
+					IF TG_OP <> 'INSERT' THEN
+						PERFORM refresh_customer_search(OLD.customer_id);
+					END IF;
+					IF TG_OP <> 'DELETE' AND (TG_OP = 'INSERT' OR NEW.customer_id IS DISTINCT FROM OLD.customer_id) THEN
+						PERFORM refresh_customer_search(NEW.customer_id);
+					END IF;
+					RETURN NULL;
+				END
+				$$ LANGUAGE plpgsql`,
+				`DROP TRIGGER IF EXISTS trg_customer_search ON customers`,
+				`CREATE TRIGGER trg_customer_search AFTER INSERT OR UPDATE OR DELETE ON customers
+					FOR EACH ROW EXECUTE FUNCTION customer_search_customer_trigger()`,
+				`DROP TRIGGER IF EXISTS trg_customer_search ON contacts`,
+				`CREATE TRIGGER trg_customer_search AFTER INSERT OR UPDATE OR DELETE ON contacts
+					FOR EACH ROW EXECUTE FUNCTION customer_search_child_trigger()`,
+				`DROP TRIGGER IF EXISTS trg_customer_search ON addresses`,
+				`CREATE TRIGGER trg_customer_search AFTER INSERT OR UPDATE OR DELETE ON addresses
+					FOR EACH ROW EXECUTE FUNCTION customer_search_child_trigger()`,
+				// Isi index untuk customer yang sudah ada
+				`SELECT refresh_customer_search(id) FROM customers WHERE deleted_at IS NULL`,
+			}
+			for _, statement := range statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			statements := []string{
+				`DROP TRIGGER IF EXISTS trg_customer_search ON customers`,
+				`DROP TRIGGER IF EXISTS trg_customer_search ON contacts`,
+				`DROP TRIGGER IF EXISTS trg_customer_search ON addresses`,
+				`DROP FUNCTION IF EXISTS customer_search_customer_trigger()`,
+				`DROP FUNCTION IF EXISTS customer_search_child_trigger()`,
+				`DROP FUNCTION IF EXISTS refresh_customer_search(varchar)`,
+				`DROP TABLE IF EXISTS customer_search_index`,
+			}
+			for _, statement := range statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
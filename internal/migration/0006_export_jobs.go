@@ -0,0 +1,50 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Antrian export yang diproses worker di background
+func init() {
+	register(Migration{
+		Version: "0006",
+		Name:    "export_jobs",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&exportJob0006{}); err != nil {
+				return err
+			}
+			return addForeignKey(tx, "export_jobs", "fk_export_jobs_user", "user_id", "users")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&exportJob0006{})
+		},
+	})
+}
+
+// Salinan beku entity.ExportJob
+type exportJob0006 struct {
+	ID            string `gorm:"primaryKey;size:26"`
+	UserID        string `gorm:"size:26;not null;index"`
+	Type          string `gorm:"not null"`
+	Format        string `gorm:"not null"`
+	Params        string
+	Columns       string
+	Status        string `gorm:"not null;default:'queued';index"`
+	TotalRows     int    `gorm:"default:0"`
+	ProcessedRows int    `gorm:"default:0"`
+	FilePath      string
+	FileName      string
+	FileSize      int64 `gorm:"default:0"`
+	Error         string
+	StartedAt     *time.Time
+	FinishedAt    *time.Time
+	ExpiresAt     *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (exportJob0006) TableName() string {
+	return "export_jobs"
+}
@@ -0,0 +1,59 @@
+package migration
+
+import (
+	"gorm.io/gorm"
+)
+
+// Activity.CustomerID dan Event.CustomerID sebelumnya uint padahal Customer.ID
+// adalah ULID string, sehingga aktivitas/event tidak bisa dipindah saat merge customer.
+func init() {
+	register(Migration{
+		Version: "0007",
+		Name:    "activity_event_customer_id",
+		Up: func(tx *gorm.DB) error {
+			for _, table := range []string{"activities", "events"} {
+				if err := tx.Exec(`ALTER TABLE ` + table + ` ALTER COLUMN customer_id TYPE varchar(26) USING customer_id::text`).Error; err != nil {
+					return err
+				}
+			}
+			for _, model := range []interface{}{&activity0007{}, &event0007{}} {
+				if !tx.Migrator().HasIndex(model, "CustomerID") {
+					if err := tx.Migrator().CreateIndex(model, "CustomerID"); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, model := range []interface{}{&activity0007{}, &event0007{}} {
+				if err := tx.Migrator().DropIndex(model, "CustomerID"); err != nil {
+					return err
+				}
+			}
+			for _, table := range []string{"activities", "events"} {
+				if err := tx.Exec(`ALTER TABLE ` + table + ` ALTER COLUMN customer_id TYPE bigint USING customer_id::bigint`).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+// Kolom customer_id setelah diubah ke ULID, salinan beku entity.Activity dan entity.Event
+type activity0007 struct {
+	CustomerID string `gorm:"size:26;not null;index"`
+}
+
+func (activity0007) TableName() string {
+	return "activities"
+}
+
+type event0007 struct {
+	CustomerID string `gorm:"size:26;not null;index"`
+}
+
+func (event0007) TableName() string {
+	return "events"
+}
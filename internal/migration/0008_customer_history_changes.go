@@ -0,0 +1,46 @@
+package migration
+
+import (
+	"gorm.io/gorm"
+)
+
+// History customer menyimpan record yang berubah beserta nilai sebelum/sesudah
+// per field, tidak hanya label status.
+func init() {
+	register(Migration{
+		Version: "0008",
+		Name:    "customer_history_changes",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumns(tx, &historyCustomer0008{}, "EntityType", "EntityID", "Action", "Changes"); err != nil {
+				return err
+			}
+			// Entry lama adalah perubahan pada customer itu sendiri
+			if err := tx.Exec(`UPDATE history_customers SET entity_id = customer_id WHERE entity_id IS NULL OR entity_id = ''`).Error; err != nil {
+				return err
+			}
+			if !tx.Migrator().HasIndex(&historyCustomer0008{}, "CustomerID") {
+				return tx.Migrator().CreateIndex(&historyCustomer0008{}, "CustomerID")
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&historyCustomer0008{}, "CustomerID"); err != nil {
+				return err
+			}
+			return dropColumns(tx, &historyCustomer0008{}, "EntityType", "EntityID", "Action", "Changes")
+		},
+	})
+}
+
+// Kolom history_customers yang disentuh migrasi ini, salinan beku entity.HistoryCustomer
+type historyCustomer0008 struct {
+	CustomerID string `gorm:"not null;index"`
+	EntityType string `gorm:"size:32;default:'customer'"`
+	EntityID   string `gorm:"size:36"`
+	Action     string `gorm:"size:16"`
+	Changes    string `gorm:"type:jsonb;default:'[]'"`
+}
+
+func (historyCustomer0008) TableName() string {
+	return "history_customers"
+}
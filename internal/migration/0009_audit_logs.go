@@ -0,0 +1,38 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Audit log untuk semua entity, diisi oleh callback GORM (package audit)
+func init() {
+	register(Migration{
+		Version: "0009",
+		Name:    "audit_logs",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&auditLog0009{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&auditLog0009{})
+		},
+	})
+}
+
+// Salinan beku entity.AuditLog
+type auditLog0009 struct {
+	ID         string    `gorm:"primaryKey;size:26"`
+	EntityType string    `gorm:"size:64;not null;index:idx_audit_logs_entity"`
+	EntityID   string    `gorm:"size:64;index:idx_audit_logs_entity"`
+	Action     string    `gorm:"size:16;not null"`
+	Changes    string    `gorm:"type:jsonb;default:'[]'"`
+	UserID     string    `gorm:"size:26;index"`
+	IP         string    `gorm:"size:64"`
+	RequestID  string    `gorm:"size:64;index"`
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+func (auditLog0009) TableName() string {
+	return "audit_logs"
+}
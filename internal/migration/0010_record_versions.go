@@ -0,0 +1,32 @@
+package migration
+
+import (
+	"gorm.io/gorm"
+)
+
+// Kolom version untuk optimistic locking (ETag / If-Match) pada record yang
+// sering diedit bersamaan. Baris lama mulai dari version 1.
+var versionedTables = []string{"customers", "addresses", "contacts", "workflows", "stages"}
+
+func init() {
+	register(Migration{
+		Version: "0010",
+		Name:    "record_versions",
+		Up: func(tx *gorm.DB) error {
+			for _, table := range versionedTables {
+				if err := tx.Exec(`ALTER TABLE ` + table + ` ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1`).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, table := range versionedTables {
+				if err := tx.Exec(`ALTER TABLE ` + table + ` DROP COLUMN IF EXISTS version`).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,81 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// State machine status customer: satu penulisan status (Draft, Active,
+// Inactive, Blocked) di customers dan status_reasons, plus tabel permintaan
+// perubahan status yang menunggu approval atau dijadwalkan.
+func init() {
+	register(Migration{
+		Version: "0011",
+		Name:    "customer_status_machine",
+		Up: func(tx *gorm.DB) error {
+			statements := []string{
+				`ALTER TABLE status_reasons DROP CONSTRAINT IF EXISTS chk_status_reasons_status`,
+				`UPDATE status_reasons SET status = INITCAP(status) WHERE LOWER(status) IN ('draft', 'active', 'inactive', 'blocked')`,
+				`ALTER TABLE status_reasons ADD CONSTRAINT chk_status_reasons_status CHECK (status IN ('Draft', 'Active', 'Inactive', 'Blocked'))`,
+				`UPDATE customers SET status = INITCAP(status) WHERE LOWER(status) IN ('draft', 'active', 'inactive', 'blocked') AND status <> INITCAP(status)`,
+			}
+			for _, statement := range statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.AutoMigrate(&customerStatusChange0011{}); err != nil {
+				return err
+			}
+			if err := addForeignKey(tx, "customer_status_changes", "fk_customer_status_changes_customer", "customer_id", "customers"); err != nil {
+				return err
+			}
+			return addForeignKey(tx, "customer_status_changes", "fk_customer_status_changes_document", "document_id", "documents")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&customerStatusChange0011{}); err != nil {
+				return err
+			}
+			// Draft/Inactive tidak dikenal constraint lama, baris tersebut
+			// dibiarkan (NOT VALID) daripada dihapus
+			statements := []string{
+				`ALTER TABLE status_reasons DROP CONSTRAINT IF EXISTS chk_status_reasons_status`,
+				`UPDATE status_reasons SET status = LOWER(status)`,
+				`ALTER TABLE status_reasons ADD CONSTRAINT chk_status_reasons_status CHECK (status IN ('active', 'blocked')) NOT VALID`,
+			}
+			for _, statement := range statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+// Salinan beku entity.CustomerStatusChange
+type customerStatusChange0011 struct {
+	ID           string `gorm:"primaryKey;size:26"`
+	CustomerID   string `gorm:"size:26;not null;index"`
+	FromStatus   string `gorm:"type:varchar(20);not null"`
+	ToStatus     string `gorm:"type:varchar(20);not null"`
+	Reason       string
+	Notes        string
+	DocumentID   *string    `gorm:"size:26"`
+	State        string     `gorm:"type:varchar(20);not null;index"`
+	EffectiveAt  *time.Time `gorm:"index"`
+	RevertAt     *time.Time
+	RequestedBy  string `gorm:"size:26;not null"`
+	DecidedBy    string `gorm:"size:26"`
+	DecidedAt    *time.Time
+	DecisionNote string
+	AppliedAt    *time.Time
+	Error        string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (customerStatusChange0011) TableName() string {
+	return "customer_status_changes"
+}
@@ -0,0 +1,98 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Pipeline: customer/opportunity berjalan melewati step (stages_details)
+// sebuah workflow. Step lama diberi urutan sesuai waktu dibuat.
+func init() {
+	register(Migration{
+		Version: "0012",
+		Name:    "pipeline",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&stagesDetail0012{}, "FlowOrder") {
+				if err := addColumns(tx, &stagesDetail0012{}, "FlowOrder"); err != nil {
+					return err
+				}
+				err := tx.Exec(`UPDATE stages_details SET flow_order = ordered.n FROM (
+					SELECT id, ROW_NUMBER() OVER (PARTITION BY stage_id ORDER BY created_at, id) AS n FROM stages_details
+				) ordered WHERE ordered.id = stages_details.id`).Error
+				if err != nil {
+					return err
+				}
+			}
+			if err := tx.AutoMigrate(&pipelineItem0012{}, &pipelineStep0012{}); err != nil {
+				return err
+			}
+			for _, fk := range [][3]string{
+				{"fk_pipeline_items_customer", "customer_id", "customers"},
+				{"fk_pipeline_items_workflow", "workflow_id", "workflows"},
+				{"fk_pipeline_items_current_step", "current_step_id", "stages_details"},
+			} {
+				if err := addForeignKey(tx, "pipeline_items", fk[0], fk[1], fk[2]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&pipelineStep0012{}, &pipelineItem0012{}); err != nil {
+				return err
+			}
+			return dropColumns(tx, &stagesDetail0012{}, "FlowOrder")
+		},
+	})
+}
+
+// Salinan beku entity.StagesDetail (kolom baru), entity.PipelineItem dan entity.PipelineStep
+type stagesDetail0012 struct {
+	FlowOrder int `gorm:"not null;default:0"`
+}
+
+func (stagesDetail0012) TableName() string {
+	return "stages_details"
+}
+
+type pipelineItem0012 struct {
+	ID            string `gorm:"primaryKey;size:26"`
+	WorkflowID    string `gorm:"size:26;not null;index"`
+	StageID       string `gorm:"size:26;not null;index"`
+	CustomerID    string `gorm:"size:26;not null;index"`
+	Type          string `gorm:"type:varchar(20);not null;default:'customer'"`
+	Title         string
+	Value         float64 `gorm:"default:0"`
+	CurrentStepID string  `gorm:"size:26;not null;index"`
+	StepEnteredAt time.Time
+	Status        string `gorm:"type:varchar(20);not null;default:'open';index"`
+	OwnerID       string `gorm:"size:26"`
+	CompletedAt   *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+func (pipelineItem0012) TableName() string {
+	return "pipeline_items"
+}
+
+type pipelineStep0012 struct {
+	ID             string `gorm:"primaryKey;size:26"`
+	PipelineItemID string `gorm:"size:26;not null;index"`
+	StepID         string `gorm:"size:26;not null;index"`
+	StepName       string
+	FlowOrder      int
+	Action         string `gorm:"type:varchar(20);not null"`
+	EnteredAt      time.Time
+	EnteredBy      string `gorm:"size:26"`
+	ExitedAt       *time.Time
+	ExitedBy       string `gorm:"size:26"`
+	Notes          string
+	CreatedAt      time.Time
+}
+
+func (pipelineStep0012) TableName() string {
+	return "pipeline_steps"
+}
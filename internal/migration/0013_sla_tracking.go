@@ -0,0 +1,111 @@
+package migration
+
+import (
+	"time"
+
+	"customer-api/internal/sla"
+
+	"gorm.io/gorm"
+)
+
+// SLA pipeline: due time per kunjungan step dan kalender hari libur. Step
+// yang masih terbuka diberi due time dari SLA step-nya (tanpa hari libur,
+// tabelnya baru dibuat di sini).
+func init() {
+	register(Migration{
+		Version: "0013",
+		Name:    "sla_tracking",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumns(tx, &pipelineItem0013{}, "StepDueAt"); err != nil {
+				return err
+			}
+			if err := addColumns(tx, &pipelineStep0013{}, "DueAt", "BreachedAt", "NotifiedAt"); err != nil {
+				return err
+			}
+			if err := tx.AutoMigrate(&holiday0013{}); err != nil {
+				return err
+			}
+
+			cal, _ := sla.CalendarFromEnv()
+			var open []pipelineStep0013
+			if err := tx.Where("exited_at IS NULL AND due_at IS NULL").Find(&open).Error; err != nil {
+				return err
+			}
+			for _, visit := range open {
+				var step stagesDetail0013
+				if err := tx.Where("id = ?", visit.StepID).First(&step).Error; err != nil {
+					continue
+				}
+				due := cal.Due(visit.EnteredAt, step.Sla, step.Uom)
+				if due == nil {
+					continue
+				}
+				if err := tx.Model(&pipelineStep0013{}).Where("id = ?", visit.ID).Update("due_at", due).Error; err != nil {
+					return err
+				}
+				err := tx.Model(&pipelineItem0013{}).Where("id = ? AND current_step_id = ?", visit.PipelineItemID, visit.StepID).
+					Update("step_due_at", due).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&holiday0013{}); err != nil {
+				return err
+			}
+			if err := dropColumns(tx, &pipelineStep0013{}, "DueAt", "BreachedAt", "NotifiedAt"); err != nil {
+				return err
+			}
+			return dropColumns(tx, &pipelineItem0013{}, "StepDueAt")
+		},
+	})
+}
+
+// Salinan beku kolom yang dipakai migrasi ini
+type pipelineItem0013 struct {
+	ID        string     `gorm:"primaryKey;size:26"`
+	StepDueAt *time.Time `gorm:"index"`
+}
+
+func (pipelineItem0013) TableName() string {
+	return "pipeline_items"
+}
+
+type pipelineStep0013 struct {
+	ID             string `gorm:"primaryKey;size:26"`
+	PipelineItemID string `gorm:"size:26"`
+	StepID         string `gorm:"size:26"`
+	EnteredAt      time.Time
+	ExitedAt       *time.Time
+	DueAt          *time.Time `gorm:"index"`
+	BreachedAt     *time.Time
+	NotifiedAt     *time.Time
+}
+
+func (pipelineStep0013) TableName() string {
+	return "pipeline_steps"
+}
+
+type stagesDetail0013 struct {
+	ID  string `gorm:"primaryKey;size:26"`
+	Sla int
+	Uom string
+}
+
+func (stagesDetail0013) TableName() string {
+	return "stages_details"
+}
+
+type holiday0013 struct {
+	ID        string    `gorm:"primaryKey;size:26"`
+	Date      time.Time `gorm:"type:date;not null;uniqueIndex"`
+	Name      string    `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (holiday0013) TableName() string {
+	return "holidays"
+}
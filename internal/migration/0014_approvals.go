@@ -0,0 +1,100 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Approval berjenjang: workflows_details menjadi level approval dengan urutan
+// dan approver (role atau team). Detail lama diberi urutan sesuai waktu dibuat.
+func init() {
+	register(Migration{
+		Version: "0014",
+		Name:    "approvals",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&workflowsDetail0014{}, "FlowOrder") {
+				if err := addColumns(tx, &workflowsDetail0014{}, "FlowOrder"); err != nil {
+					return err
+				}
+				err := tx.Exec(`UPDATE workflows_details SET flow_order = ordered.n FROM (
+					SELECT id, ROW_NUMBER() OVER (PARTITION BY workflows_id ORDER BY created_at, id) AS n FROM workflows_details
+				) ordered WHERE ordered.id = workflows_details.id`).Error
+				if err != nil {
+					return err
+				}
+			}
+			if err := addColumns(tx, &workflowsDetail0014{}, "ApproverRoleID", "ApproverTeamID"); err != nil {
+				return err
+			}
+			if err := tx.AutoMigrate(&approvalRequest0014{}, &approvalAction0014{}); err != nil {
+				return err
+			}
+			if err := addForeignKey(tx, "approval_requests", "fk_approval_requests_current_step", "current_step_id", "workflows_details"); err != nil {
+				return err
+			}
+			return addForeignKey(tx, "approval_actions", "fk_approval_requests_actions", "approval_request_id", "approval_requests")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&approvalAction0014{}, &approvalRequest0014{}); err != nil {
+				return err
+			}
+			return dropColumns(tx, &workflowsDetail0014{}, "FlowOrder", "ApproverRoleID", "ApproverTeamID")
+		},
+	})
+}
+
+// Salinan beku entity.WorkflowsDetail (kolom baru), entity.ApprovalRequest
+// dan entity.ApprovalAction
+type workflowsDetail0014 struct {
+	FlowOrder      int    `gorm:"not null;default:0"`
+	ApproverRoleID string `gorm:"size:36"`
+	ApproverTeamID string `gorm:"size:26"`
+}
+
+func (workflowsDetail0014) TableName() string {
+	return "workflows_details"
+}
+
+type approvalRequest0014 struct {
+	ID            string `gorm:"primaryKey;size:26"`
+	Action        string `gorm:"type:varchar(50);not null;index"`
+	WorkflowID    string `gorm:"size:26;not null"`
+	EntityType    string `gorm:"type:varchar(50);not null"`
+	EntityID      string `gorm:"size:26;index"`
+	CustomerID    string `gorm:"size:26;index"`
+	Summary       string
+	Value         float64 `gorm:"type:decimal(15,2)"`
+	Payload       string  `gorm:"type:jsonb;default:'{}'"`
+	Status        string  `gorm:"type:varchar(20);not null;index"`
+	CurrentStepID string  `gorm:"size:26"`
+	CurrentLevel  int
+	Levels        int
+	StepDueAt     *time.Time
+	RequestedBy   string `gorm:"size:26;not null;index"`
+	DecidedAt     *time.Time
+	AppliedAt     *time.Time
+	Error         string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (approvalRequest0014) TableName() string {
+	return "approval_requests"
+}
+
+type approvalAction0014 struct {
+	ID                string `gorm:"primaryKey;size:26"`
+	ApprovalRequestID string `gorm:"size:26;not null;index"`
+	StepID            string `gorm:"size:26"`
+	StepName          string
+	Level             int
+	UserID            string `gorm:"size:26;not null"`
+	Decision          string `gorm:"type:varchar(20);not null"`
+	Note              string
+	CreatedAt         time.Time
+}
+
+func (approvalAction0014) TableName() string {
+	return "approval_actions"
+}
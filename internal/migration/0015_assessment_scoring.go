@@ -0,0 +1,145 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Assessment sebagai scorecard: pertanyaan bertipe dan berbobot, opsi jawaban,
+// pengisian per customer beserta jawabannya. Tabel assessments dan
+// assessment_details belum pernah ikut skema awal. Nama pertanyaan tidak lagi
+// unik secara global, pertanyaan yang sama boleh dipakai di assessment lain.
+func init() {
+	register(Migration{
+		Version: "0015",
+		Name:    "assessment_scoring",
+		Up: func(tx *gorm.DB) error {
+			for _, name := range []string{"uni_assessment_details_name", "assessment_details_name_key"} {
+				if tx.Migrator().HasConstraint(&assessmentDetail0015{}, name) {
+					if err := tx.Migrator().DropConstraint(&assessmentDetail0015{}, name); err != nil {
+						return err
+					}
+				}
+			}
+			if tx.Migrator().HasIndex(&assessmentDetail0015{}, "idx_assessment_details_name") {
+				if err := tx.Migrator().DropIndex(&assessmentDetail0015{}, "idx_assessment_details_name"); err != nil {
+					return err
+				}
+			}
+			err := tx.AutoMigrate(
+				&assessment0015{},
+				&assessmentDetail0015{},
+				&assessmentOption0015{},
+				&assessmentRun0015{},
+				&assessmentAnswer0015{},
+			)
+			if err != nil {
+				return err
+			}
+			for _, fk := range [][4]string{
+				{"assessment_details", "fk_assessment_details_assessment", "assessment_id", "assessments"},
+				{"assessment_options", "fk_assessment_details_options", "assessment_detail_id", "assessment_details"},
+				{"assessment_runs", "fk_assessment_runs_assessment", "assessment_id", "assessments"},
+				{"assessment_answers", "fk_assessment_runs_answers", "assessment_run_id", "assessment_runs"},
+			} {
+				if err := addForeignKey(tx, fk[0], fk[1], fk[2], fk[3]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&assessmentAnswer0015{}, &assessmentRun0015{}, &assessmentOption0015{}); err != nil {
+				return err
+			}
+			if err := dropColumns(tx, &assessmentDetail0015{}, "Type", "Weight", "ScaleMin", "ScaleMax", "Required", "SortOrder"); err != nil {
+				return err
+			}
+			return dropColumns(tx, &assessment0015{}, "Description")
+		},
+	})
+}
+
+// Salinan beku model assessment
+type assessment0015 struct {
+	ID          string `gorm:"primaryKey;size:26"`
+	Name        string `gorm:"not null;unique"`
+	Description string
+	RoleID      string `gorm:"not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	IsActive    bool           `gorm:"default:true"`
+}
+
+func (assessment0015) TableName() string {
+	return "assessments"
+}
+
+type assessmentDetail0015 struct {
+	ID           string  `gorm:"primaryKey;size:26"`
+	AssessmentID string  `gorm:"not null"`
+	Name         string  `gorm:"not null"`
+	Type         string  `gorm:"type:varchar(20);not null;default:'scale'"`
+	Weight       float64 `gorm:"not null;default:1"`
+	ScaleMin     int     `gorm:"not null;default:1"`
+	ScaleMax     int     `gorm:"not null;default:5"`
+	Required     bool    `gorm:"default:false"`
+	SortOrder    int     `gorm:"not null;default:0"`
+	IsActive     bool    `gorm:"default:true"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+func (assessmentDetail0015) TableName() string {
+	return "assessment_details"
+}
+
+type assessmentOption0015 struct {
+	ID                 string  `gorm:"primaryKey;size:26"`
+	AssessmentDetailID string  `gorm:"size:26;not null;index"`
+	Label              string  `gorm:"not null"`
+	Score              float64 `gorm:"not null"`
+	SortOrder          int     `gorm:"not null;default:0"`
+	CreatedAt          time.Time
+}
+
+func (assessmentOption0015) TableName() string {
+	return "assessment_options"
+}
+
+type assessmentRun0015 struct {
+	ID            string  `gorm:"primaryKey;size:26"`
+	AssessmentID  string  `gorm:"size:26;not null;index"`
+	CustomerID    string  `gorm:"size:26;not null;index"`
+	UserID        string  `gorm:"size:26;not null"`
+	Score         float64 `gorm:"type:decimal(5,2);not null"`
+	Rating        float64 `gorm:"type:decimal(3,2);not null"`
+	Notes         string
+	RatingApplied bool      `gorm:"default:false"`
+	CreatedAt     time.Time `gorm:"index"`
+}
+
+func (assessmentRun0015) TableName() string {
+	return "assessment_runs"
+}
+
+type assessmentAnswer0015 struct {
+	ID                 string `gorm:"primaryKey;size:26"`
+	AssessmentRunID    string `gorm:"size:26;not null;index"`
+	AssessmentDetailID string `gorm:"size:26;not null"`
+	Question           string
+	Type               string `gorm:"type:varchar(20);not null"`
+	Weight             float64
+	Value              *float64
+	OptionID           *string `gorm:"size:26"`
+	Answer             string
+	Score              float64
+	SortOrder          int
+}
+
+func (assessmentAnswer0015) TableName() string {
+	return "assessment_answers"
+}
@@ -0,0 +1,51 @@
+package migration
+
+import (
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// Role "User" yang sudah punya permission tidak disentuh lagi oleh seed,
+// jadi resource yang ditambahkan setelahnya (pipeline, sla, approvals)
+// diberikan di sini. Daftarnya dibekukan, bukan dibaca dari config.
+var userRoleGrants0016 = []string{
+	"pipeline:read",
+	"pipeline:write",
+	"sla:read",
+	"approvals:read",
+	"approvals:write",
+}
+
+func init() {
+	register(Migration{
+		Version: "0016",
+		Name:    "user_role_permissions",
+		Up: func(tx *gorm.DB) error {
+			var roleIDs []string
+			if err := tx.Raw(`SELECT id FROM roles WHERE role_name = ? AND deleted_at IS NULL`, "User").Scan(&roleIDs).Error; err != nil {
+				return err
+			}
+			if len(roleIDs) == 0 {
+				return nil
+			}
+			for _, name := range userRoleGrants0016 {
+				err := tx.Exec(`INSERT INTO permissions (id, name, created_at, updated_at) VALUES (?, ?, NOW(), NOW())
+					ON CONFLICT (name) DO NOTHING`, ulid.Make().String(), name).Error
+				if err != nil {
+					return err
+				}
+				err = tx.Exec(`INSERT INTO role_permissions (role_id, permission_id)
+					SELECT ?, id FROM permissions WHERE name = ? ON CONFLICT DO NOTHING`, roleIDs[0], name).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec(`DELETE FROM role_permissions
+				WHERE role_id IN (SELECT id FROM roles WHERE role_name = 'User')
+				AND permission_id IN (SELECT id FROM permissions WHERE name IN ?)`, userRoleGrants0016).Error
+		},
+	})
+}
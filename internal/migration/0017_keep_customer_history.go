@@ -0,0 +1,23 @@
+package migration
+
+import (
+	"gorm.io/gorm"
+)
+
+// Purge trash tidak lagi menghapus history_customers, jadi foreign key ke
+// customers (dibuat AutoMigrate lama) dilepas agar riwayat tetap ada setelah
+// customernya dihapus permanen.
+func init() {
+	register(Migration{
+		Version: "0017",
+		Name:    "keep_customer_history",
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec(`ALTER TABLE history_customers DROP CONSTRAINT IF EXISTS fk_history_customers_customer`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			// NOT VALID: riwayat customer yang sudah dipurge tidak ikut diperiksa
+			return tx.Exec(`ALTER TABLE history_customers ADD CONSTRAINT fk_history_customers_customer
+				FOREIGN KEY (customer_id) REFERENCES customers(id) NOT VALID`).Error
+		},
+	})
+}
@@ -0,0 +1,31 @@
+package migration
+
+import (
+	"gorm.io/gorm"
+)
+
+// Satu data hanya boleh punya satu approval request pending. Cek di handler
+// saja bisa kalah balapan dengan request lain, jadi dijaga unique index
+// parsial. Duplikat lama ditutup dulu, yang paling awal dipertahankan.
+func init() {
+	register(Migration{
+		Version: "0018",
+		Name:    "pending_approval_unique",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Exec(`UPDATE approval_requests SET status = 'cancelled', updated_at = NOW()
+				WHERE status = 'pending' AND entity_id <> '' AND id NOT IN (
+					SELECT DISTINCT ON (entity_type, entity_id) id FROM approval_requests
+					WHERE status = 'pending' AND entity_id <> ''
+					ORDER BY entity_type, entity_id, created_at, id
+				)`).Error
+			if err != nil {
+				return err
+			}
+			return tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS uni_approval_requests_pending
+				ON approval_requests (entity_type, entity_id) WHERE status = 'pending' AND entity_id <> ''`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec(`DROP INDEX IF EXISTS uni_approval_requests_pending`).Error
+		},
+	})
+}
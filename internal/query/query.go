@@ -0,0 +1,366 @@
+// Package query is the shared list layer used by the list endpoints: it parses
+// page/page_size, sort, field filters and cursors from the query string and
+// applies them to a GORM query against a per-entity allow-list.
+//
+// Query string syntax:
+//
+//	page=2&page_size=50             offset pagination (limit is an alias of page_size)
+//	sort=-created_at,name           "-" for descending, only allow-listed fields
+//	status=Active                   equality
+//	name[like]=maju                 case-insensitive contains
+//	status[ne]=Blocked              not equal
+//	status[in]=Active,Blocked       one of
+//	created_at[gte]=2025-01-01      range: gt, gte, lt, lte (a date-only lte covers the whole day)
+//	cursor=&page_size=100           cursor pagination on the ULID primary key,
+//	cursor=<next_cursor>            continue from the previous page
+package query
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"customer-api/internal/dto"
+
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// FieldType decides how filter values are parsed and which operators apply
+type FieldType int
+
+const (
+	String FieldType = iota
+	Number
+	Bool
+	Time
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 200
+)
+
+// Field is one filterable/sortable attribute of an entity
+type Field struct {
+	Column string // database column, defaults to the field name
+	Type   FieldType
+}
+
+// Spec is the allow-list of an entity
+type Spec struct {
+	Table           string // used to qualify columns, e.g. "customers"
+	Fields          map[string]Field
+	DefaultSort     string // e.g. "-created_at"
+	DefaultPageSize int
+}
+
+// Params is a parsed list request
+type Params struct {
+	Page     int
+	PageSize int
+	Sort     []string // SQL order expressions
+	Cursor   *string  // nil unless cursor pagination was requested
+	desc     bool     // cursor direction
+	filters  []filter
+}
+
+type filter struct {
+	sql  string
+	args []interface{}
+}
+
+// Error is returned for invalid list parameters and maps to HTTP 400
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func invalid(format string, args ...interface{}) error {
+	return &Error{Message: fmt.Sprintf(format, args...)}
+}
+
+// reserved keys are never treated as field filters
+var reserved = map[string]bool{"page": true, "page_size": true, "limit": true, "sort": true, "cursor": true}
+
+func (s Spec) column(name string) (string, Field, bool) {
+	field, ok := s.Fields[name]
+	if !ok {
+		return "", field, false
+	}
+	column := field.Column
+	if column == "" {
+		column = name
+	}
+	if s.Table != "" && !strings.Contains(column, ".") {
+		column = s.Table + "." + column
+	}
+	return column, field, true
+}
+
+func (s Spec) idColumn() string {
+	if s.Table != "" {
+		return s.Table + ".id"
+	}
+	return "id"
+}
+
+// Parse reads list parameters from the query string. Plain keys that are not
+// in the allow-list are ignored so handlers can keep their own parameters;
+// bracketed keys (name[op]) must refer to an allowed field.
+func (s Spec) Parse(values url.Values) (Params, error) {
+	p := Params{Page: 1, PageSize: s.DefaultPageSize}
+	if p.PageSize == 0 {
+		p.PageSize = DefaultPageSize
+	}
+
+	if v := values.Get("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return p, invalid("page must be a positive integer")
+		}
+		p.Page = page
+	}
+	size := values.Get("page_size")
+	if size == "" {
+		size = values.Get("limit")
+	}
+	if size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 {
+			return p, invalid("page_size must be a positive integer")
+		}
+		if n > MaxPageSize {
+			n = MaxPageSize
+		}
+		p.PageSize = n
+	}
+
+	if cursor, ok := values["cursor"]; ok {
+		value := ""
+		if len(cursor) > 0 {
+			value = cursor[0]
+		}
+		if value != "" {
+			if _, err := ulid.ParseStrict(value); err != nil {
+				return p, invalid("invalid cursor")
+			}
+		}
+		p.Cursor = &value
+		// Cursor hanya bisa diurutkan berdasarkan id (ULID = urutan waktu dibuat)
+		switch values.Get("sort") {
+		case "", "-id":
+			p.desc = true
+		case "id":
+		default:
+			return p, invalid("cursor pagination only supports sort=id or sort=-id")
+		}
+	} else {
+		sort := values.Get("sort")
+		if sort == "" {
+			sort = s.DefaultSort
+		}
+		for _, key := range strings.Split(sort, ",") {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				continue
+			}
+			direction := "ASC"
+			if strings.HasPrefix(key, "-") {
+				direction = "DESC"
+				key = key[1:]
+			}
+			column, _, ok := s.column(key)
+			if key == "id" {
+				column, ok = s.idColumn(), true
+			}
+			if !ok {
+				return p, invalid("cannot sort by %q", key)
+			}
+			p.Sort = append(p.Sort, column+" "+direction)
+		}
+	}
+
+	for key, vals := range values {
+		if reserved[key] || len(vals) == 0 {
+			continue
+		}
+		name, op := key, "eq"
+		if i := strings.Index(key, "["); i > 0 && strings.HasSuffix(key, "]") {
+			name, op = key[:i], key[i+1:len(key)-1]
+		}
+		column, field, ok := s.column(name)
+		if !ok {
+			if op != "eq" {
+				return p, invalid("cannot filter by %q", name)
+			}
+			continue
+		}
+		f, err := buildFilter(name, column, field, op, vals[0])
+		if err != nil {
+			return p, err
+		}
+		p.filters = append(p.filters, f)
+	}
+	return p, nil
+}
+
+func parseValue(field Field, name, raw string) (interface{}, error) {
+	switch field.Type {
+	case Number:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, invalid("%s must be a number", name)
+		}
+		return v, nil
+	case Bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, invalid("%s must be true or false", name)
+		}
+		return v, nil
+	case Time:
+		if v, err := time.Parse(time.RFC3339, raw); err == nil {
+			return v, nil
+		}
+		v, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return nil, invalid("%s must be a date (YYYY-MM-DD) or RFC3339 time", name)
+		}
+		return v, nil
+	}
+	return raw, nil
+}
+
+func buildFilter(name, column string, field Field, op, raw string) (filter, error) {
+	switch op {
+	case "eq", "ne":
+		v, err := parseValue(field, name, raw)
+		if err != nil {
+			return filter{}, err
+		}
+		operator := "="
+		if op == "ne" {
+			operator = "<>"
+		}
+		return filter{sql: column + " " + operator + " ?", args: []interface{}{v}}, nil
+
+	case "like":
+		if field.Type != String {
+			return filter{}, invalid("like is only supported on text fields")
+		}
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(raw)
+		return filter{sql: column + " ILIKE ?", args: []interface{}{"%" + escaped + "%"}}, nil
+
+	case "in":
+		var list []interface{}
+		for _, part := range strings.Split(raw, ",") {
+			v, err := parseValue(field, name, strings.TrimSpace(part))
+			if err != nil {
+				return filter{}, err
+			}
+			list = append(list, v)
+		}
+		return filter{sql: column + " IN ?", args: []interface{}{list}}, nil
+
+	case "gt", "gte", "lt", "lte":
+		if field.Type != Number && field.Type != Time {
+			return filter{}, invalid("%s is only supported on number and date fields", op)
+		}
+		v, err := parseValue(field, name, raw)
+		if err != nil {
+			return filter{}, err
+		}
+		operator := map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}[op]
+		// lte dengan tanggal saja mencakup sampai akhir hari tersebut
+		if t, ok := v.(time.Time); ok && op == "lte" && len(raw) == len("2006-01-02") {
+			v, operator = t.AddDate(0, 0, 1), "<"
+		}
+		return filter{sql: column + " " + operator + " ?", args: []interface{}{v}}, nil
+	}
+	return filter{}, invalid("unknown filter operator %q", op)
+}
+
+// Filter applies only the field filters, e.g. for aggregate queries
+func (p Params) Filter(db *gorm.DB) *gorm.DB {
+	for _, f := range p.filters {
+		db = db.Where(f.sql, f.args...)
+	}
+	return db
+}
+
+// Find runs the filtered, sorted and paginated query into dest (a pointer to
+// a slice). db carries the model and any handler-specific conditions; scopes
+// such as Preload are applied to the data query only, not to the count.
+func (s Spec) Find(db *gorm.DB, p Params, dest interface{}, scopes ...func(*gorm.DB) *gorm.DB) (dto.Pagination, error) {
+	page := dto.Pagination{PageSize: p.PageSize}
+	filtered := p.Filter(db.Model(dest))
+
+	if p.Cursor != nil {
+		id := s.idColumn()
+		data := filtered.Session(&gorm.Session{}).Scopes(scopes...)
+		if p.desc {
+			if *p.Cursor != "" {
+				data = data.Where(id+" < ?", *p.Cursor)
+			}
+			data = data.Order(id + " DESC")
+		} else {
+			if *p.Cursor != "" {
+				data = data.Where(id+" > ?", *p.Cursor)
+			}
+			data = data.Order(id + " ASC")
+		}
+		// Ambil satu baris lebih untuk mengetahui masih ada halaman berikutnya
+		if err := data.Limit(p.PageSize + 1).Find(dest).Error; err != nil {
+			return page, err
+		}
+		next, more := trimCursorPage(dest, p.PageSize)
+		page.NextCursor, page.HasMore = next, more
+		return page, nil
+	}
+
+	var total int64
+	if err := filtered.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return page, err
+	}
+
+	data := filtered.Session(&gorm.Session{}).Scopes(scopes...)
+	for _, order := range p.Sort {
+		data = data.Order(order)
+	}
+	// id sebagai tie-breaker agar urutan antar halaman stabil
+	data = data.Order(s.idColumn())
+	if err := data.Limit(p.PageSize).Offset((p.Page - 1) * p.PageSize).Find(dest).Error; err != nil {
+		return page, err
+	}
+
+	page.Page = p.Page
+	page.Total = &total
+	page.TotalPages = int(math.Ceil(float64(total) / float64(p.PageSize)))
+	page.HasMore = int64(p.Page*p.PageSize) < total
+	return page, nil
+}
+
+// trimCursorPage drops the look-ahead row and returns the ID of the last row
+func trimCursorPage(dest interface{}, size int) (string, bool) {
+	slice := reflect.ValueOf(dest).Elem()
+	more := slice.Len() > size
+	if more {
+		slice.Set(slice.Slice(0, size))
+	}
+	if !more || slice.Len() == 0 {
+		return "", more
+	}
+	last := reflect.Indirect(slice.Index(slice.Len() - 1))
+	if id := last.FieldByName("ID"); id.IsValid() && id.Kind() == reflect.String {
+		return id.String(), more
+	}
+	return "", more
+}
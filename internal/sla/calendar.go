@@ -0,0 +1,262 @@
+// Package sla computes due times on a business-hours calendar and reports
+// breaches through a pluggable Notifier.
+//
+// SLA amounts come from StagesDetail/WorkflowsDetail (Sla + Uom). Only working
+// time counts: outside working hours, on non-working days and on holidays the
+// clock stops. The calendar is configured with environment variables:
+//
+//	SLA_TIMEZONE       Asia/Jakarta (default)
+//	SLA_WORK_HOURS     08:00-17:00 (default)
+//	SLA_WORKDAYS       1,2,3,4,5 (ISO weekday, 1 = Monday; default Monday-Friday)
+//	SLA_AT_RISK_RATIO  0.2 (default: at risk in the last 20% of the SLA)
+package sla
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Asia/Jakarta juga tersedia di image tanpa zoneinfo
+)
+
+// SLA state of an item
+const (
+	StateNone     = "none" // step tanpa SLA
+	StateOnTrack  = "on_track"
+	StateAtRisk   = "at_risk"
+	StateBreached = "breached"
+	StateMet      = "met" // step selesai sebelum due
+)
+
+// Batas hari yang ditelusuri, supaya kalender tanpa hari kerja tidak berputar terus
+const maxDays = 3660
+
+// Calendar is a business-hours calendar
+type Calendar struct {
+	Location    *time.Location
+	WorkStart   time.Duration // sejak tengah malam, mis. 8h
+	WorkEnd     time.Duration
+	Workdays    map[time.Weekday]bool
+	Holidays    map[string]bool // tanggal lokal, format 2006-01-02
+	AtRiskRatio float64
+}
+
+// DefaultCalendar is Monday-Friday 08:00-17:00 in Asia/Jakarta without holidays
+func DefaultCalendar() Calendar {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		loc = time.FixedZone("WIB", 7*60*60)
+	}
+	return Calendar{
+		Location:  loc,
+		WorkStart: 8 * time.Hour,
+		WorkEnd:   17 * time.Hour,
+		Workdays: map[time.Weekday]bool{
+			time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true,
+		},
+		Holidays:    map[string]bool{},
+		AtRiskRatio: 0.2,
+	}
+}
+
+// CalendarFromEnv is DefaultCalendar with the SLA_* environment overrides.
+// Invalid values are reported and the default is kept.
+func CalendarFromEnv() (Calendar, error) {
+	cal := DefaultCalendar()
+	var errs []string
+
+	if v := os.Getenv("SLA_TIMEZONE"); v != "" {
+		if loc, err := time.LoadLocation(v); err == nil {
+			cal.Location = loc
+		} else {
+			errs = append(errs, "SLA_TIMEZONE: "+err.Error())
+		}
+	}
+	if v := os.Getenv("SLA_WORK_HOURS"); v != "" {
+		start, end, err := parseWorkHours(v)
+		if err == nil {
+			cal.WorkStart, cal.WorkEnd = start, end
+		} else {
+			errs = append(errs, "SLA_WORK_HOURS: "+err.Error())
+		}
+	}
+	if v := os.Getenv("SLA_WORKDAYS"); v != "" {
+		days, err := parseWorkdays(v)
+		if err == nil {
+			cal.Workdays = days
+		} else {
+			errs = append(errs, "SLA_WORKDAYS: "+err.Error())
+		}
+	}
+	if v := os.Getenv("SLA_AT_RISK_RATIO"); v != "" {
+		ratio, err := strconv.ParseFloat(v, 64)
+		if err == nil && ratio >= 0 && ratio < 1 {
+			cal.AtRiskRatio = ratio
+		} else {
+			errs = append(errs, "SLA_AT_RISK_RATIO: use a number between 0 and 1")
+		}
+	}
+
+	if len(errs) > 0 {
+		return cal, fmt.Errorf("invalid SLA calendar config: %s", strings.Join(errs, "; "))
+	}
+	return cal, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func parseWorkHours(value string) (time.Duration, time.Duration, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("use HH:MM-HH:MM")
+	}
+	start, err := parseClock(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseClock(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	if end <= start {
+		return 0, 0, fmt.Errorf("end must be after start")
+	}
+	return start, end, nil
+}
+
+func parseWorkdays(value string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, part := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 1 || n > 7 {
+			return nil, fmt.Errorf("use ISO weekdays 1-7 separated by commas")
+		}
+		days[time.Weekday(n%7)] = true
+	}
+	return days, nil
+}
+
+// WorkdayLength is the working time of one day
+func (c Calendar) WorkdayLength() time.Duration {
+	return c.WorkEnd - c.WorkStart
+}
+
+// Duration converts an SLA amount and unit of measure to working time. Days
+// count as full working days. An unknown unit counts as hours, matching the
+// original meaning of the Sla column.
+func (c Calendar) Duration(amount int, uom string) time.Duration {
+	n := time.Duration(amount)
+	switch strings.ToLower(strings.TrimSpace(uom)) {
+	case "minute", "minutes", "min", "menit":
+		return n * time.Minute
+	case "day", "days", "hari":
+		return n * c.WorkdayLength()
+	default:
+		return n * time.Hour
+	}
+}
+
+func (c Calendar) midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, c.Location)
+}
+
+// IsWorkday reports whether the local date of t is a working day
+func (c Calendar) IsWorkday(t time.Time) bool {
+	t = t.In(c.Location)
+	return c.Workdays[t.Weekday()] && !c.Holidays[t.Format("2006-01-02")]
+}
+
+// Add returns the moment d of working time after start
+func (c Calendar) Add(start time.Time, d time.Duration) time.Time {
+	if d <= 0 || len(c.Workdays) == 0 || c.WorkdayLength() <= 0 {
+		return start.Add(d)
+	}
+	t := start.In(c.Location)
+	for i := 0; i < maxDays; i++ {
+		day := c.midnight(t)
+		if c.IsWorkday(day) {
+			open, close := day.Add(c.WorkStart), day.Add(c.WorkEnd)
+			if t.Before(open) {
+				t = open
+			}
+			if t.Before(close) {
+				left := close.Sub(t)
+				if d <= left {
+					return t.Add(d)
+				}
+				d -= left
+			}
+		}
+		t = day.AddDate(0, 0, 1)
+	}
+	return t.Add(d)
+}
+
+// Between returns the working time from from to to, zero when to is not after from
+func (c Calendar) Between(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	if len(c.Workdays) == 0 || c.WorkdayLength() <= 0 {
+		return to.Sub(from)
+	}
+	var total time.Duration
+	t := from.In(c.Location)
+	for i := 0; i < maxDays && t.Before(to); i++ {
+		day := c.midnight(t)
+		if c.IsWorkday(day) {
+			open, close := day.Add(c.WorkStart), day.Add(c.WorkEnd)
+			if t.Before(open) {
+				t = open
+			}
+			end := close
+			if to.Before(end) {
+				end = to
+			}
+			if end.After(t) {
+				total += end.Sub(t)
+			}
+		}
+		t = day.AddDate(0, 0, 1)
+	}
+	return total
+}
+
+// Due returns the due time of an SLA that starts at start, nil without SLA
+func (c Calendar) Due(start time.Time, amount int, uom string) *time.Time {
+	if amount <= 0 {
+		return nil
+	}
+	due := c.Add(start, c.Duration(amount, uom))
+	return &due
+}
+
+// State classifies a step that started at start with the given due time. exited
+// is nil while the item is still in the step.
+func (c Calendar) State(start time.Time, due, exited *time.Time, now time.Time) string {
+	if due == nil {
+		return StateNone
+	}
+	if exited != nil {
+		if exited.After(*due) {
+			return StateBreached
+		}
+		return StateMet
+	}
+	if now.After(*due) {
+		return StateBreached
+	}
+	total := c.Between(start, *due)
+	if total > 0 && float64(c.Between(now, *due)) <= float64(total)*c.AtRiskRatio {
+		return StateAtRisk
+	}
+	return StateOnTrack
+}
@@ -0,0 +1,127 @@
+package sla
+
+import (
+	"testing"
+	"time"
+)
+
+// testCalendar is Monday-Friday 08:00-17:00 UTC with Wednesday 2026-10-21 off
+func testCalendar() Calendar {
+	cal := DefaultCalendar()
+	cal.Location = time.UTC
+	cal.Holidays = map[string]bool{"2026-10-21": true}
+	return cal
+}
+
+// at returns 2026-10-<day> hh:mm UTC; the 19th is a Monday
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestCalendarAdd(t *testing.T) {
+	cal := testCalendar()
+	tests := []struct {
+		name  string
+		start time.Time
+		d     time.Duration
+		want  time.Time
+	}{
+		{"within the day", at(19, 9, 0), 2 * time.Hour, at(19, 11, 0)},
+		{"ends exactly at close", at(19, 8, 0), 9 * time.Hour, at(19, 17, 0)},
+		{"before opening", at(19, 6, 0), time.Hour, at(19, 9, 0)},
+		{"after closing", at(19, 18, 0), time.Hour, at(20, 9, 0)},
+		{"crosses the night", at(19, 16, 0), 2 * time.Hour, at(20, 9, 0)},
+		{"skips the holiday", at(20, 16, 0), 2 * time.Hour, at(22, 9, 0)},
+		{"skips the weekend", at(23, 16, 30), time.Hour, at(26, 8, 30)},
+		{"starts on sunday", at(18, 10, 0), 30 * time.Minute, at(19, 8, 30)},
+		{"several days around the holiday", at(19, 8, 0), 3 * 9 * time.Hour, at(22, 17, 0)},
+		{"zero duration", at(18, 10, 0), 0, at(18, 10, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.Add(tt.start, tt.d); !got.Equal(tt.want) {
+				t.Errorf("Add(%v, %v) = %v, want %v", tt.start, tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendarBetween(t *testing.T) {
+	cal := testCalendar()
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     time.Duration
+	}{
+		{"within the day", at(19, 9, 0), at(19, 11, 0), 2 * time.Hour},
+		{"outside working hours", at(19, 17, 0), at(20, 8, 0), 0},
+		{"crosses the night", at(19, 16, 0), at(20, 9, 0), 2 * time.Hour},
+		{"skips the holiday", at(20, 16, 0), at(22, 9, 0), 2 * time.Hour},
+		{"skips the weekend", at(23, 16, 0), at(26, 9, 0), 2 * time.Hour},
+		{"full week", at(19, 0, 0), at(26, 0, 0), 4 * 9 * time.Hour},
+		{"to before from", at(20, 9, 0), at(19, 9, 0), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.Between(tt.from, tt.to); got != tt.want {
+				t.Errorf("Between(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendarAddBetweenRoundTrip(t *testing.T) {
+	cal := testCalendar()
+	start := at(19, 10, 15)
+	for _, d := range []time.Duration{time.Minute, 7 * time.Hour, 9 * time.Hour, 50 * time.Hour} {
+		if got := cal.Between(start, cal.Add(start, d)); got != d {
+			t.Errorf("Between(start, Add(start, %v)) = %v", d, got)
+		}
+	}
+}
+
+func TestCalendarDuration(t *testing.T) {
+	cal := testCalendar()
+	tests := []struct {
+		amount int
+		uom    string
+		want   time.Duration
+	}{
+		{30, "minutes", 30 * time.Minute},
+		{2, "Hari", 18 * time.Hour},
+		{4, "hours", 4 * time.Hour},
+		{4, "", 4 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := cal.Duration(tt.amount, tt.uom); got != tt.want {
+			t.Errorf("Duration(%d, %q) = %v, want %v", tt.amount, tt.uom, got, tt.want)
+		}
+	}
+}
+
+func TestCalendarState(t *testing.T) {
+	cal := testCalendar()
+	start, due := at(19, 8, 0), at(19, 17, 0)
+	early, late := at(19, 12, 0), at(20, 9, 0)
+	tests := []struct {
+		name   string
+		due    *time.Time
+		exited *time.Time
+		now    time.Time
+		want   string
+	}{
+		{"no sla", nil, nil, at(19, 9, 0), StateNone},
+		{"on track", &due, nil, at(19, 10, 0), StateOnTrack},
+		{"at risk in the last 20%", &due, nil, at(19, 15, 30), StateAtRisk},
+		{"breached while open", &due, nil, at(19, 17, 1), StateBreached},
+		{"met", &due, &early, late, StateMet},
+		{"exited late", &due, &late, late, StateBreached},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.State(start, tt.due, tt.exited, tt.now); got != tt.want {
+				t.Errorf("State() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package sla
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Breach is one item that passed the due time of its current step
+type Breach struct {
+	ItemID       string    `json:"item_id"`
+	CustomerID   string    `json:"customer_id"`
+	CustomerName string    `json:"customer_name"`
+	Title        string    `json:"title"`
+	WorkflowID   string    `json:"workflow_id"`
+	StepID       string    `json:"step_id"`
+	Step         string    `json:"step"`
+	OwnerID      string    `json:"owner_id"`
+	EnteredAt    time.Time `json:"entered_at"`
+	DueAt        time.Time `json:"due_at"`
+	DetectedAt   time.Time `json:"detected_at"`
+}
+
+// Notifier delivers breach notifications. A failed notification is retried
+// on the next monitor run.
+type Notifier interface {
+	NotifyBreach(ctx context.Context, breach Breach) error
+}
+
+// LogNotifier writes breaches to the application log
+type LogNotifier struct{}
+
+func (LogNotifier) NotifyBreach(_ context.Context, b Breach) error {
+	log.Printf("SLA breached: item %s (%s) in step %q since %s, due %s",
+		b.ItemID, b.CustomerName, b.Step, b.EnteredAt.Format(time.RFC3339), b.DueAt.Format(time.RFC3339))
+	return nil
+}
+
+// WebhookNotifier posts each breach as JSON to URL
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (w WebhookNotifier) NotifyBreach(ctx context.Context, b Breach) error {
+	body, err := json.Marshal(map[string]interface{}{"event": "sla.breached", "data": b})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// Notifiers sends every breach to all notifiers
+type Notifiers []Notifier
+
+func (n Notifiers) NotifyBreach(ctx context.Context, b Breach) error {
+	var errs []error
+	for _, notifier := range n {
+		if err := notifier.NotifyBreach(ctx, b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotifierFromEnv logs every breach and also posts it to SLA_WEBHOOK_URL when set
+func NotifierFromEnv() Notifier {
+	notifiers := Notifiers{LogNotifier{}}
+	if url := os.Getenv("SLA_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, WebhookNotifier{URL: url})
+	}
+	return notifiers
+}
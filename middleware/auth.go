@@ -4,6 +4,11 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
+
+	"customer-api/internal/config"
+	"customer-api/internal/entity"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -26,7 +31,7 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		tokenClaims, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
 			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 		if err != nil || !tokenClaims.Valid {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
@@ -49,7 +54,46 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		jti, ok := claims["jti"].(string)
+		if !ok || jti == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+
+		// Cek revocation list (logout)
+		var revoked int64
+		config.DB.Model(&entity.RevokedToken{}).Where("jti = ?", jti).Count(&revoked)
+		if revoked > 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
+		// Cek apakah semua sesi user sudah dicabut setelah token ini diterbitkan
+		var user entity.User
+		if err := config.DB.Select("id", "tokens_valid_after").Where("id = ?", userID).First(&user).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			c.Abort()
+			return
+		}
+		issuedAt, _ := claims.GetIssuedAt()
+		// tokens_valid_after disimpan dalam detik penuh seperti iat, jadi token
+		// yang terbit di detik pencabutan (mis. login ulang) tetap berlaku
+		if user.TokensValidAfter != nil && (issuedAt == nil || issuedAt.Before(*user.TokensValidAfter)) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
+		if expiresAt, _ := claims.GetExpirationTime(); expiresAt != nil {
+			c.Set("token_expires_at", expiresAt.Time)
+		} else {
+			c.Set("token_expires_at", time.Now())
+		}
+
 		c.Set("user_id", userID)
+		c.Set("jti", jti)
 		c.Next()
 	}
 }
\ No newline at end of file
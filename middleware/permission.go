@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+
+	"customer-api/internal/config"
+	"customer-api/internal/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorize checks that the authenticated user's role carries the permission
+// for the given resource. The action is derived from the HTTP method:
+// GET/HEAD -> read, DELETE -> delete, everything else -> write.
+// Must be used after AuthMiddleware.
+func Authorize(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		action := config.ActionWrite
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead:
+			action = config.ActionRead
+		case http.MethodDelete:
+			action = config.ActionDelete
+		}
+
+		checkPermission(c, config.PermissionName(resource, action))
+	}
+}
+
+// RequirePermission checks a single, explicitly named permission such as "customers:delete"
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		checkPermission(c, permission)
+	}
+}
+
+func checkPermission(c *gin.Context, permission string) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		c.Abort()
+		return
+	}
+
+	var user entity.User
+	if err := config.DB.Preload("Role.Permissions").Where("id = ?", userID).First(&user).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		c.Abort()
+		return
+	}
+
+	if roleHasPermission(user, permission) {
+		c.Set("role_id", user.RoleID)
+		c.Next()
+		return
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: missing permission " + permission})
+	c.Abort()
+}
+
+// HasPermission reports whether the authenticated user's role carries the
+// permission, without aborting the request. For handlers that show part of
+// a response only to users with a further permission.
+func HasPermission(c *gin.Context, permission string) bool {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		return false
+	}
+	var user entity.User
+	if err := config.DB.Preload("Role.Permissions").Where("id = ?", userID).First(&user).Error; err != nil {
+		return false
+	}
+	return roleHasPermission(user, permission)
+}
+
+func roleHasPermission(user entity.User, permission string) bool {
+	for _, p := range user.Role.Permissions {
+		if p.Name == permission {
+			return true
+		}
+	}
+	return false
+}
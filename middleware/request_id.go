@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"customer-api/internal/audit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oklog/ulid/v2"
+)
+
+// RequestID gives every request an ID, taken from the X-Request-ID header or
+// generated, echoes it in the response and keeps it for the audit log
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if id == "" || len(id) > 64 {
+			id = ulid.Make().String()
+		}
+		c.Set(audit.RequestIDKey, id)
+		c.Header("X-Request-ID", id)
+		c.Next()
+	}
+}
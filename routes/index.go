@@ -13,30 +13,50 @@ func RegisterRoutes(r *gin.Engine) {
 	// Public routes
 	r.POST("/register", handler.Register)
 	r.POST("/login", handler.Login)
+	r.POST("/refresh", handler.Refresh)
+	r.POST("/logout", middleware.AuthMiddleware(), handler.Logout)
+
+	// Link download export ditandatangani, jadi tidak perlu header Authorization
+	r.GET("/exports/:id/download", handler.DownloadExportFile)
 
 	// Protected routes
 	protected := r.Group("/api")
 	protected.Use(middleware.AuthMiddleware())
 
-	// Register all modules
-	route.RegisterRoleRoutes(protected)
-	route.RegisterCustomerRoutes(protected)
-	route.RegisterAddressRoutes(protected)
-	route.RegisterSosmedRoutes(protected)
-	route.RegisterContactRoutes(protected)
-	route.RegisterStructureRoutes(protected)
-	route.RegisterGroupRoutes(protected)
-	route.RegisterOtherRoutes(protected)
-	route.RegisterActivityRoutes(protected)
-	route.RegisterInvoiceRoutes(protected)
-	route.RegisterPaymentRoutes(protected)
-	route.RegisterStatusRoutes(protected)
-	route.RegisterEventsRoutes(protected)
-	route.RegisterActivityTypeRoutes(protected)
-	route.RegisterStagesRoutes(protected)
-	route.RegisterWorkflowsRoutes(protected)
-	route.RegisterGroupConfig(protected)
-	route.RegisterAssessmentRoutes(protected)
-	route.RegisterTeamsRoutes(protected)
+	// Register all modules, each guarded by its "<resource>:<action>" permission
+	route.RegisterRoleRoutes(protected.Group("", middleware.Authorize("roles")))
+	route.RegisterPermissionRoutes(protected.Group("", middleware.Authorize("roles")))
+	route.RegisterUserRoutes(protected.Group("", middleware.Authorize("users")))
+	route.RegisterCustomerRoutes(protected.Group("", middleware.Authorize("customers")))
+	route.RegisterAddressRoutes(protected.Group("", middleware.Authorize("addresses")))
+	route.RegisterSosmedRoutes(protected.Group("", middleware.Authorize("sosmeds")))
+	route.RegisterContactRoutes(protected.Group("", middleware.Authorize("contacts")))
+	route.RegisterStructureRoutes(protected.Group("", middleware.Authorize("structures")))
+	route.RegisterGroupRoutes(protected.Group("", middleware.Authorize("groups")))
+	route.RegisterOtherRoutes(protected.Group("", middleware.Authorize("others")))
+	route.RegisterActivityRoutes(protected.Group("", middleware.Authorize("activities")))
+	route.RegisterInvoiceRoutes(protected.Group("", middleware.Authorize("invoices")))
+	route.RegisterPaymentRoutes(protected.Group("", middleware.Authorize("payments")))
+	route.RegisterReportRoutes(protected.Group("", middleware.Authorize("invoices")))
+	route.RegisterStatusRoutes(protected.Group("", middleware.Authorize("statuses")))
+	route.RegisterEventsRoutes(protected.Group("", middleware.Authorize("events")))
+	route.RegisterActivityTypeRoutes(protected.Group("", middleware.Authorize("activity_types")))
+	route.RegisterStagesRoutes(protected.Group("", middleware.Authorize("stages")))
+	route.RegisterWorkflowsRoutes(protected.Group("", middleware.Authorize("workflows")))
+	route.RegisterPipelineRoutes(protected.Group("", middleware.Authorize("pipeline")))
+	route.RegisterSLARoutes(protected.Group("", middleware.Authorize("sla")))
+	route.RegisterApprovalRoutes(protected.Group("", middleware.Authorize("approvals")))
+	route.RegisterGroupConfig(protected.Group("", middleware.Authorize("group_configs")))
+	route.RegisterAssessmentRoutes(protected.Group("", middleware.Authorize("assessments")))
+	route.RegisterTeamsRoutes(protected.Group("", middleware.Authorize("teams")))
+	route.RegisterAuditRoutes(protected.Group("", middleware.Authorize("audit_logs")))
+
+	// Export jobs are owned by their creator; the handler checks the read
+	// permission of the exported module
+	route.RegisterExportRoutes(protected)
+
+	// Trash covers several modules; the handler checks the permission of the
+	// record type, and trash:delete for purges
+	route.RegisterTrashRoutes(protected)
 
 }
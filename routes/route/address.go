@@ -10,6 +10,7 @@ func RegisterAddressRoutes(r *gin.RouterGroup) {
 	r.GET("/customers/:id/addresses", handler.GetCustomerAddresses)
 	r.GET("/addresses/:id", handler.GetAddress)
 	r.PUT("/addresses/:id", handler.UpdateAddress)
+	r.PATCH("/addresses/:id", handler.PatchAddress)
 	r.DELETE("/addresses/:id", handler.DeleteAddress)
 	r.POST("/addresses", handler.CreateAddress)
 }
@@ -0,0 +1,15 @@
+package route
+
+import (
+	"customer-api/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterApprovalRoutes(r *gin.RouterGroup) {
+	r.GET("/approvals", handler.GetApprovalRequests) // ?assigned=me: menunggu keputusan user ini
+	r.GET("/approvals/:id", handler.GetApprovalRequest)
+	r.POST("/approvals/:id/approve", handler.ApproveApprovalRequest)
+	r.POST("/approvals/:id/reject", handler.RejectApprovalRequest)
+	r.POST("/approvals/:id/cancel", handler.CancelApprovalRequest)
+}
@@ -19,4 +19,10 @@ func RegisterAssessmentRoutes(r *gin.RouterGroup) {
 	r.PUT("/assessment/:id/details/:detail_id", handler.UpdateAssessmentDetail)
 	r.DELETE("/assessment/:id/details/:detail_id", handler.DeleteAssessmentDetail)
 
+	// pengisian assessment per customer
+	r.POST("/assessment/:id/runs", handler.CreateAssessmentRun)
+	r.GET("/customers/:id/assessments", handler.GetCustomerAssessments)
+	r.GET("/customers/:id/assessments/compare", handler.CompareCustomerAssessments)
+	r.GET("/customers/:id/assessments/:run_id", handler.GetCustomerAssessment)
+
 }
@@ -0,0 +1,11 @@
+package route
+
+import (
+	"customer-api/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAuditRoutes(r *gin.RouterGroup) {
+	r.GET("/audit", handler.GetAuditLogs)
+}
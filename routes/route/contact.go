@@ -10,6 +10,7 @@ func RegisterContactRoutes(r *gin.RouterGroup) {
 	r.GET("/customers/:id/contacts", handler.GetCustomerContacts)
 	r.GET("/contacts/:id", handler.GetContact)
 	r.PUT("/contacts/:id", handler.UpdateContact)
+	r.PATCH("/contacts/:id", handler.PatchContact)
 	r.DELETE("/contacts/:id", handler.DeleteContact)
 	r.POST("/contacts", handler.CreateContact)
 }
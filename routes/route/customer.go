@@ -12,11 +12,17 @@ func RegisterCustomerRoutes(r *gin.RouterGroup) {
 	r.GET("/customers", handler.GetCustomers)
 
 	r.GET("/customers/statistics", handler.GetCustomerStats)
+	r.GET("/customers/search", handler.SearchCustomers)
+	r.GET("/customers/duplicates", handler.GetCustomerDuplicates)
 	// export data
 	r.GET("/customers/export", handler.ExportCustomers)
+	// import data
+	r.POST("/customers/import", handler.ImportCustomers)
+	r.GET("/customers/import/template", handler.GetCustomerImportTemplate)
 
 	// history customer
-	// r.GET("/customers/:id/history", handler.getHistoryCustomerByUserID)
+	r.GET("/customers/:id/history", handler.GetCustomerHistory)
+	r.GET("/customers/:id/timeline", handler.GetCustomerTimeline)
 
 	r.GET("/customers/:id", handler.GetCustomer)
 	r.GET("/customers/:id/with-addresses", handler.GetCustomerWithAddresses)
@@ -26,12 +32,20 @@ func RegisterCustomerRoutes(r *gin.RouterGroup) {
 	r.GET("/customers/:id/with-all", handler.GetCustomerWithAllRelations)
 	// r.GET("/customers/:id/full", handler.GetCustomerFull)
 	r.PUT("/customers/:id", handler.UpdateCustomer)
+	r.PATCH("/customers/:id", handler.PatchCustomer)
 	r.DELETE("/customers/:id", handler.DeleteCustomer)
 	r.POST("/customers/:id/logo", handler.UploadCustomerLogo)
+	r.GET("/customers/:id/duplicates", handler.GetCustomerDuplicatesByID)
+	r.POST("/customers/:id/merge", handler.MergeCustomer)
 
 	// Customer status
 	r.POST("/customers/:id/status", handler.UpdateCustomerStatus)
 	r.GET("/customers/:id/status", handler.GetCustomerStatus)
+	r.GET("/customers/status-transitions", handler.GetCustomerStatusTransitions)
+	r.GET("/customers/:id/status/changes", handler.GetCustomerStatusChanges)
+	r.POST("/customers/:id/status/changes/:change_id/approve", handler.ApproveCustomerStatusChange)
+	r.POST("/customers/:id/status/changes/:change_id/reject", handler.RejectCustomerStatusChange)
+	r.POST("/customers/:id/status/changes/:change_id/cancel", handler.CancelCustomerStatusChange)
 
 	// Customer relations
 	r.GET("/customers/:id/others", handler.GetCustomerOthers)
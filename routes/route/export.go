@@ -0,0 +1,14 @@
+package route
+
+import (
+	"customer-api/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterExportRoutes(r *gin.RouterGroup) {
+	r.POST("/exports", handler.CreateExportJob)
+	r.GET("/exports", handler.GetExportJobs)
+	r.GET("/exports/:id", handler.GetExportJob)
+	r.DELETE("/exports/:id", handler.DeleteExportJob)
+}
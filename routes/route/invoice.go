@@ -1,19 +1,22 @@
 package route
 
 import (
-	// "customer-api/internal/handler"
+	"customer-api/internal/handler"
 
 	"github.com/gin-gonic/gin"
 )
 
 func RegisterInvoiceRoutes(r *gin.RouterGroup) {
-	// r.POST("/invoices", handler.CreateInvoice)
-	// r.GET("/invoices", handler.GetInvoices)
-	// r.GET("/invoices/:id", handler.GetInvoice)
-	// r.PUT("/invoices/:id", handler.UpdateInvoice)
-	// r.DELETE("/invoices/:id", handler.DeleteInvoice)
+	r.POST("/invoices", handler.CreateInvoice)
+	r.GET("/invoices", handler.GetInvoices)
+	r.GET("/invoices/:id", handler.GetInvoice)
+	r.GET("/invoices/:id/pdf", handler.GetInvoicePDF)
+	r.PUT("/invoices/:id", handler.UpdateInvoice)
+	r.DELETE("/invoices/:id", handler.DeleteInvoice)
 
-	// // Invoice-specific payments
-	// r.GET("/invoices/:id/payments", handler.GetInvoicePayments)
-	// r.POST("/invoices/:id/payments", handler.CreateInvoicePayment)
+	r.POST("/invoice-templates", handler.CreateInvoiceTemplate)
+	r.GET("/invoice-templates", handler.GetInvoiceTemplates)
+	r.GET("/invoice-templates/:id", handler.GetInvoiceTemplate)
+	r.PUT("/invoice-templates/:id", handler.UpdateInvoiceTemplate)
+	r.DELETE("/invoice-templates/:id", handler.DeleteInvoiceTemplate)
 }
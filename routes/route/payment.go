@@ -1,15 +1,21 @@
 package route
 
 import (
-	// "customer-api/internal/handler"
+	"customer-api/internal/handler"
 
 	"github.com/gin-gonic/gin"
 )
 
 func RegisterPaymentRoutes(r *gin.RouterGroup) {
-	// r.POST("/payments", handler.CreatePayment)
-	// r.GET("/payments", handler.GetPayments)
-	// r.GET("/payments/:id", handler.GetPayment)
-	// r.PUT("/payments/:id", handler.UpdatePayment)
-	// r.DELETE("/payments/:id", handler.DeletePayment)
+	r.GET("/payments", handler.GetPayments)
+	r.GET("/payments/:id", handler.GetPayment)
+	// Pembayaran tidak diubah/dihapus, koreksi lewat reverse
+	r.POST("/payments/:id/reverse", handler.ReversePayment)
+
+	// Invoice-specific payments
+	r.GET("/invoices/:id/payments", handler.GetInvoicePayments)
+	r.POST("/invoices/:id/payments", handler.CreateInvoicePayment)
+
+	// Ledger piutang per customer
+	r.GET("/customers/:id/ledger", handler.GetCustomerLedger)
 }
@@ -0,0 +1,13 @@
+package route
+
+import (
+	"customer-api/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterPermissionRoutes(r *gin.RouterGroup) {
+	r.GET("/permissions", handler.GetPermissions)
+	r.GET("/roles/:id/permissions", handler.GetRolePermissions)
+	r.PUT("/roles/:id/permissions", handler.UpdateRolePermissions)
+}
@@ -0,0 +1,17 @@
+package route
+
+import (
+	"customer-api/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterPipelineRoutes(r *gin.RouterGroup) {
+	r.POST("/pipeline", handler.CreatePipelineItem)
+	r.GET("/pipeline", handler.GetPipelineItems)
+	r.GET("/pipeline/board", handler.GetPipelineBoard) // Kanban per step
+	r.GET("/pipeline/:id", handler.GetPipelineItem)
+	r.DELETE("/pipeline/:id", handler.DeletePipelineItem)
+	r.POST("/pipeline/:id/advance", handler.AdvancePipelineItem)
+	r.POST("/pipeline/:id/revert", handler.RevertPipelineItem)
+}
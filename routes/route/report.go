@@ -0,0 +1,11 @@
+package route
+
+import (
+	"customer-api/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterReportRoutes(r *gin.RouterGroup) {
+	r.GET("/reports/ar-aging", handler.GetARAgingReport)
+}
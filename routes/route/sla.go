@@ -0,0 +1,16 @@
+package route
+
+import (
+	"customer-api/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSLARoutes(r *gin.RouterGroup) {
+	r.GET("/sla/dashboard", handler.GetSLADashboard)
+
+	// kalender hari libur
+	r.GET("/sla/holidays", handler.GetHolidays)
+	r.POST("/sla/holidays", handler.CreateHoliday)
+	r.DELETE("/sla/holidays/:id", handler.DeleteHoliday)
+}
@@ -10,5 +10,6 @@ func RegisterSosmedRoutes(r *gin.RouterGroup) {
 	r.GET("/customers/:id/sosmeds", handler.GetCustomerSosmeds)
 	r.GET("/sosmeds/:id", handler.GetSosmed)
 	r.PUT("/sosmeds/:id", handler.UpdateSosmed)
+	r.PATCH("/sosmeds/:id", handler.PatchSosmed)
 	r.DELETE("/sosmeds/:id", handler.DeleteSosmed)
 }
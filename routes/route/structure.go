@@ -11,6 +11,7 @@ func RegisterStructureRoutes(r *gin.RouterGroup) {
 	r.GET("/customers/:id/structures/by-level", handler.GetStructuresByLevel)
 	r.GET("/structures/:id", handler.GetStructure)
 	r.PUT("/structures/:id", handler.UpdateStructure)
+	r.PATCH("/structures/:id", handler.PatchStructure)
 	r.DELETE("/structures/:id", handler.DeleteStructure)
 	r.POST("/structures", handler.CreateStructure)
 }
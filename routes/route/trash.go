@@ -0,0 +1,14 @@
+package route
+
+import (
+	"customer-api/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTrashRoutes(r *gin.RouterGroup) {
+	r.GET("/trash", handler.GetTrash)
+	r.POST("/trash/purge", handler.PurgeTrash)
+	r.POST("/trash/:type/:id/restore", handler.RestoreTrash)
+	r.DELETE("/trash/:type/:id", handler.PurgeTrashItem)
+}
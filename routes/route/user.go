@@ -0,0 +1,12 @@
+package route
+
+import (
+	"customer-api/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterUserRoutes(r *gin.RouterGroup) {
+	r.PUT("/users/:id/role", handler.UpdateUserRole)
+	r.POST("/users/:id/revoke-sessions", handler.RevokeUserSessions)
+}
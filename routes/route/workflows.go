@@ -9,6 +9,8 @@ import (
 func RegisterWorkflowsRoutes(r *gin.RouterGroup) {
 	r.POST("/workflows", handler.CreateWorkflows)
 	r.GET("/workflows", handler.GetWorkflows)
+	r.POST("/workflows/resolve", handler.ResolveWorkflow)              // dry run routing threshold
+	r.PUT("/workflows/thresholds", handler.UpdateWorkflowThresholds)   // ubah beberapa rentang sekaligus
 	r.GET("/workflows/:id", handler.GetWorkflow)
 	r.PUT("/workflows/:id", handler.UpdateWorkflow)
 	r.DELETE("/workflows/:id", handler.DeleteWorkflow)